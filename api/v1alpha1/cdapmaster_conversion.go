@@ -1,5 +1,5 @@
 /*
-Copyright 2018 The Kubernetes Authors.
+Copyright 2022.
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -14,6 +14,15 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Package v1alpha1 contains test custom resource
-// +k8s:deepcopy-gen=package,register
 package v1alpha1
+
+import ctrl "sigs.k8s.io/controller-runtime"
+
+// Hub marks v1alpha1, the storage version, as the version the other versions of CDAPMaster are converted through.
+func (*CDAPMaster) Hub() {}
+
+// SetupWebhookWithManager registers the conversion webhook of CDAPMaster at /convert. The other versions must be
+// registered in the scheme of the manager.
+func (r *CDAPMaster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete()
+}
@@ -18,8 +18,8 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-reconciler/pkg/status"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -39,16 +39,33 @@ type CDAPMasterSpec struct {
 	UserInterfaceImage string `json:"userInterfaceImage,omitempty"`
 	// ImagePullPolicy is the policy for pulling docker images on Pod creation.
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets is a list of secrets for pulling docker images from private registries. They are used by
+	// all the service pods and the upgrade jobs. They are intentionally not passed to the pods launched by CDAP for
+	// programs, whose service account should list the secrets instead.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
 	// SecuritySecret is secret that contains security related configurations for CDAP.
 	SecuritySecret string `json:"securitySecret,omitempty"`
 	// ServiceAccountName is the service account for all the service pods.
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 	// Env is a list of environment variables for the all service containers.
 	Env []corev1.EnvVar `json:"env,omitempty"`
+	// EnvFrom is a list of sources to populate environment variables for all the service containers.
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
 	// LocationURI is an URI specifying an object storage for CDAP.
 	LocationURI string `json:"locationURI"`
-	// Config is a set of configurations that goes into cdap-site.xml.
+	// Config is a set of configurations that goes into cdap-site.xml. Keys prefixed with "hadoop:" go into
+	// core-site.xml instead, without the prefix. Use HadoopConfig for new Hadoop configurations.
 	Config map[string]string `json:"config,omitempty"`
+	// HadoopConfig is a set of Hadoop configuration files, e.g. core-site.xml, hdfs-site.xml, yarn-site.xml or
+	// mapred-site.xml, written to the Hadoop configuration ConfigMap. Key is the file name. Value is the set of
+	// properties of the file. Properties of core-site.xml take precedence over the ones set with the "hadoop:"
+	// prefix in Config.
+	HadoopConfig map[string]map[string]string `json:"hadoopConfig,omitempty"`
+	// SecretConfig is a set of sensitive configurations whose values are read from secrets. They go into
+	// cdap-security.xml, which is stored in a Secret generated by the operator instead of the cdap-site.xml ConfigMap.
+	// Key is the property name. Value selects the secret key holding the property value.
+	// Pods are restarted when any of the referenced values change.
+	SecretConfig map[string]corev1.SecretKeySelector `json:"secretConfig,omitempty"`
 	// ConfigMapVolumes defines a map from ConfigMap names to volume mount path.
 	// Key is the configmap object name. Value is the mount path.
 	// This adds ConfigMap data to the directory specified by the volume mount path.
@@ -63,6 +80,14 @@ type CDAPMasterSpec struct {
 	SystemAppConfigs map[string]string `json:"systemappconfigs,omitempty"`
 	// LogLevels is a set of logger name to log level settings.
 	LogLevels map[string]string `json:"logLevels,omitempty"`
+	// Logging configures the log format and additional log appenders of the services.
+	Logging *LoggingSpec `json:"logging,omitempty"`
+	// JVM configures the max heap size and JVM options of all services.
+	JVM *JVMSpec `json:"jvm,omitempty"`
+	// ResourceRecommendations enables recommending the resources of the services from the CPU and memory usage of
+	// their containers, read from the metrics.k8s.io API. Recommendations are reported in the status and, with
+	// AutoApply, set as the resources of the services during a maintenance window.
+	ResourceRecommendations *ResourceRecommendationSpec `json:"resourceRecommendations,omitempty"`
 	// AppFabric is specification for the CDAP app-fabric service.
 	AppFabric AppFabricSpec `json:"appFabric,omitempty"`
 	// Logs is specification for the CDAP logging service.
@@ -114,16 +139,56 @@ type CDAPMasterSpec struct {
 	SystemMetricsExporter *SystemMetricExporterSpec `json:"systemMetricsExporter,omitempty"`
 	// SecurityContext defines the security context for all pods for all services.
 	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	// PodSecurityContext defines the pod-level security context for all services. When set, it takes precedence over
+	// the pod-level fields of SecurityContext.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// ContainerSecurityContext defines the security context for all containers of all services. When set, it takes
+	// precedence over the container-level fields of SecurityContext.
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+	// SecurityContextPreset fills in the fields left unset in the pod and container security contexts of all services.
+	// The "restricted" preset complies with the restricted Pod Security Standard.
+	SecurityContextPreset SecurityContextPreset `json:"securityContextPreset,omitempty"`
 	// AdditionalVolumes defines a list of additional volumes for all services.
 	// For information on supported volume types, see https://kubernetes.io/docs/concepts/storage/volumes/.
 	AdditionalVolumes []corev1.Volume `json:"additionalVolumes,omitempty"`
 	// AdditionalVolumeMounts defines a list of additional volume mounts for all services.
 	// For information on supported volume mount types, see https://kubernetes.io/docs/concepts/storage/volumes/.
 	AdditionalVolumeMounts []corev1.VolumeMount `json:"additionalVolumeMounts,omitempty"`
+	// ExtraContainers defines a list of sidecar containers, e.g. log shippers or proxies, added to the pods of all
+	// services. Extra containers are left out of the CRD schema to keep it within the size limits of the API server
+	// and etcd, so they are only validated when the pods are created.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraContainers []corev1.Container `json:"extraContainers,omitempty"`
+	// ExtraInitContainers defines a list of init containers added to the pods of all services. They run after the
+	// init containers added by the operator.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraInitContainers []corev1.Container `json:"extraInitContainers,omitempty"`
+	// ExtraContainersMountConfig mounts the CDAP configuration volumes, i.e. pod info, cdap-site.xml, the hadoop
+	// configurations, the system app configurations and the security secret, into the extra containers and init
+	// containers at the same paths as in the service containers. Volumes already mounted by a container are skipped.
+	ExtraContainersMountConfig bool `json:"extraContainersMountConfig,omitempty"`
 	// MutationConfigs specifies mutations that can be applied to resources with the "cdap.instance" label.
-	// Mutations can include adding init containers, tolerations and node selectors to pods. To use mutations,
+	// Mutations can include adding init containers, sidecars, tolerations, node selectors, env vars and volumes to pods,
+	// as well as overriding their affinity, priority class and resources. To use mutations,
 	// the admission control webhook should be enabled in the cdap operator.
 	MutationConfigs []MutationConfig `json:"mutationConfigs,omitempty"`
+	// MutationFailurePolicy defines how the admission control webhook handles pods of this instance that it fails to
+	// mutate. "Ignore" admits the pod without mutations while "Fail" rejects it. The policy is remembered by the webhook
+	// after the CDAPMaster is deleted, so that pods created during deletion, e.g. by cleanup jobs, are handled the same.
+	// Defaults to the policy set by the --webhook-failure-policy flag of the cdap operator.
+	MutationFailurePolicy MutationFailurePolicy `json:"mutationFailurePolicy,omitempty"`
+	// TLS enables HTTPS on the router and UI services. The key pair either comes from an existing secret
+	// or is requested from cert-manager. When set, the operator mounts the key pair into the router and UI
+	// containers, sets the SSL related properties in cdap-site.xml and exposes the services over HTTPS.
+	TLS *TLSSpec `json:"tls,omitempty"`
+	// ManifestPatchesConfigMap is the name of a ConfigMap with patches applied to the statefulsets, deployments and
+	// services generated for the CDAP services. They allow setting fields that are not modeled by CDAPMaster, e.g.
+	// hostAliases or dnsConfig. Each entry is a YAML document with a "target", selecting the generated objects by
+	// "kind" and optionally "name", a "type", either "StrategicMerge" (default) or "JSON6902", and the "patch".
+	// Entries are applied in the order of their keys.
+	ManifestPatchesConfigMap string `json:"manifestPatchesConfigMap,omitempty"`
 }
 
 // CDAPServiceSpec defines the base set of specifications applicable to all master services.
@@ -132,6 +197,10 @@ type CDAPMasterSpec struct {
 type CDAPServiceSpec struct {
 	// Metadata for the service.
 	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Enabled deploys the service. Set it to false to not deploy the service. When not set, the service is deployed
+	// unless the cdap-site.xml toggle related to the service, e.g. "enable.preview" for the preview service, is false.
+	// The toggle is kept in sync with this field. Optional services are also disabled when their specification is nil.
+	Enabled *bool `json:"enabled,omitempty"`
 	// ServiceAccountName overrides the service account for the service pods.
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 	// Resources are Compute resources required by the service.
@@ -143,8 +212,18 @@ type CDAPServiceSpec struct {
 	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
 	// PriorityClassName is to specify the priority of the pods for this service.
 	PriorityClassName *string `json:"priorityClassName,omitempty"`
+	// ImagePullSecrets overrides the secrets for pulling docker images for the service pods.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
 	// Env is a list of environment variables for the master service container.
 	Env []corev1.EnvVar `json:"env,omitempty"`
+	// EnvFrom is a list of sources to populate environment variables for the service container.
+	// They are appended to the ones in CDAPMasterSpec.
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// LogLevels is a set of logger name to log level settings for the service. They take precedence over the ones
+	// in CDAPMasterSpec.
+	LogLevels map[string]string `json:"logLevels,omitempty"`
+	// JVM overrides CDAPMasterSpec.JVM for the service.
+	JVM *JVMSpec `json:"jvm,omitempty"`
 	// ConfigMapVolumes defines a map from ConfigMap names to volume mount path for this service
 	// Key is the configmap object name. Value is the mount path.
 	// This adds ConfigMap data to the directory specified by the volume mount path.
@@ -159,8 +238,26 @@ type CDAPServiceSpec struct {
 	// AdditionalVolumeMounts defines a list of additional volume mounts for the service.
 	// For information on supported volume mount types, see https://kubernetes.io/docs/concepts/storage/volumes/.
 	AdditionalVolumeMounts []corev1.VolumeMount `json:"additionalVolumeMounts,omitempty"`
+	// ExtraContainers defines a list of sidecar containers added to the service pods. They are appended to the ones
+	// in CDAPMasterSpec.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraContainers []corev1.Container `json:"extraContainers,omitempty"`
+	// ExtraInitContainers defines a list of init containers added to the service pods. They are appended to the
+	// ones in CDAPMasterSpec.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraInitContainers []corev1.Container `json:"extraInitContainers,omitempty"`
+	// ExtraContainersMountConfig overrides CDAPMasterSpec.ExtraContainersMountConfig for the extra containers and
+	// init containers of the service, and for those of CDAPMasterSpec in the pods of the service. Services sharing
+	// a pod must not override it with different values.
+	ExtraContainersMountConfig *bool `json:"extraContainersMountConfig,omitempty"`
 	// SecurityContext overrides the security context for the service pods.
 	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	// PodSecurityContext overrides the pod-level security context for the service pods.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// ContainerSecurityContext overrides the security context for the containers of the service pods.
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
 	// EnableSystemMetrics is an optional field that is considered along with CDAPMasterSpec.SystemMetricsExporter
 	// to start a metrics collection container in statefulsets. SystemMetricsExporter is a global setting in CDAPMasterSpec.
 	// When SystemMetricsExporter is absent, it disables metrics collection for all stateful services.
@@ -172,6 +269,11 @@ type CDAPServiceSpec struct {
 	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
 	// Affinity describes node affinity scheduling rules for the service.
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations allow the service pods to be scheduled onto nodes with matching taints.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// TopologySpreadConstraints describes how the service pods ought to spread across topology domains.
+	// When absent, pods of scalable services with more than one replica are spread across zones on a best effort basis.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 }
 
 // CDAPScalableServiceSpec defines the base specification for master services that can have more than one instance.
@@ -284,12 +386,28 @@ type ArtifactCacheSpec struct {
 // SystemMetricExporterSpec defines the specification for the SystemMetricsExporter service.
 type SystemMetricExporterSpec struct {
 	CDAPServiceSpec `json:",inline"`
+	// JMXSecurity enables authentication and optionally SSL for the JMX server that the metrics sidecar
+	// connects to. When absent, the JMX server is bound to localhost without authentication and SSL.
+	JMXSecurity *JMXSecuritySpec `json:"jmxSecurity,omitempty"`
+}
+
+// JMXSecuritySpec defines the credentials and keystores used to secure the JMX server of services
+// with system metrics collection enabled.
+type JMXSecuritySpec struct {
+	// PasswordSecret is the name of the secret containing the "jmxremote.password" and "jmxremote.access" files.
+	// Each file has a single "<role> <value>" line, with the role granted "readonly" access.
+	// When empty, the operator generates the secret cdap-<name>-jmx with a random password.
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+	// KeystoreSecret is the name of the secret containing the "jmxremote.ssl.properties" file, which sets the
+	// javax.net.ssl keystore properties of the JMX server, and the "truststore.jks" file used by the metrics
+	// sidecar. SSL is enabled for the JMX server and its RMI registry when set.
+	KeystoreSecret string `json:"keystoreSecret,omitempty"`
 }
 
 // CDAPMasterStatus defines the observed state of CDAPMaster
 type CDAPMasterStatus struct {
-	status.Meta          `json:",inline"`
-	status.ComponentMeta `json:",inline"`
+	Meta          `json:",inline"`
+	ComponentMeta `json:",inline"`
 	// ImageToUse is the Docker image of CDAP backend the operator uses to deploy.
 	ImageToUse string `json:"imageToUse,omitempty"`
 	// UserInterfaceImageToUse is the Docker image of CDAP UI the operator uses to deploy.
@@ -298,9 +416,19 @@ type CDAPMasterStatus struct {
 	UpgradeStartTimeMillis int64 `json:"upgradeStartTimeMillis,omitempty"`
 	// DowngradeStartTimeMillis is the start time in milliseconds of the downgrade process
 	DowngradeStartTimeMillis int64 `json:"downgradeStartTimeMillis,omitempty"`
+	// JVM is the max heap size and JVM options applied to the containers of the services, keyed by
+	// container name, e.g. appfabric.
+	JVM map[string]JVMStatus `json:"jvm,omitempty"`
+	// ResourceRecommendations are the resources recommended for the containers of the services from their observed
+	// usage, keyed by container name, e.g. appfabric.
+	ResourceRecommendations map[string]ResourceRecommendation `json:"resourceRecommendations,omitempty"`
+	// ResourceRecommendationsAppliedTime is the last time recommendations were applied to the resources of the
+	// services.
+	ResourceRecommendationsAppliedTime *metav1.Time `json:"resourceRecommendationsAppliedTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
+//+kubebuilder:storageversion
 
 // CDAPMaster is the Schema for the cdapmasters API
 type CDAPMaster struct {
@@ -323,6 +451,7 @@ type CDAPMasterList struct {
 // SecurityContext defines fields for setting corev1.SecurityContext for containers and
 // corev1.PodSecurityContext for pods.
 // For additional information, see https://kubernetes.io/docs/tasks/configure-pod-container/security-context/.
+// For settings not covered here, use PodSecurityContext and ContainerSecurityContext instead.
 type SecurityContext struct {
 	// RunAsUser runs the pod as the specified user ID. It is applied at the pod level.
 	RunAsUser *int64 `json:"runAsUser,omitempty"`
@@ -346,13 +475,35 @@ type SecurityContext struct {
 	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
 }
 
+// SecurityContextPreset is a named set of defaults for pod and container security contexts.
+// +kubebuilder:validation:Enum=restricted
+type SecurityContextPreset string
+
+const (
+	// SecurityContextPresetRestricted runs containers as non-root with the runtime default seccomp profile,
+	// all capabilities dropped and privilege escalation disallowed.
+	SecurityContextPresetRestricted SecurityContextPreset = "restricted"
+)
+
+// MutationFailurePolicy defines how the admission control webhook handles pods that it fails to mutate.
+// +kubebuilder:validation:Enum=Fail;Ignore
+type MutationFailurePolicy string
+
+const (
+	// MutationFailurePolicyFail rejects pods that the webhook fails to mutate.
+	MutationFailurePolicyFail MutationFailurePolicy = "Fail"
+	// MutationFailurePolicyIgnore admits pods that the webhook fails to mutate without mutations.
+	MutationFailurePolicyIgnore MutationFailurePolicy = "Ignore"
+)
+
 // MutationConfig defines mutations that can be applied to resources with the "cdap.instance" label and that
 // satisfy a label selector.
 type MutationConfig struct {
 	// LabelSelector selects resources to apply the mutation to.
 	LabelSelector metav1.LabelSelector `json:"labelSelector,omitempty"`
 	// PodMutations specifies mutations that are be applied to pods which satisfy specified label selectors.
-	// Pod mutations can include adding init containers, tolerations and node selectors etc.
+	// Pod mutations can include adding init containers, tolerations and node selectors etc. Mutations are
+	// idempotent, so that re-admission of a pod doesn't add duplicates.
 	PodMutations PodMutationConfig `json:"podMutations,omitempty"`
 }
 
@@ -365,6 +516,186 @@ type PodMutationConfig struct {
 	NodeSelector *map[string]string `json:"nodeSelectors,omitempty"`
 	// Tolerations specifies additional tolerations that will be added to the pod.
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// ContainersAfter specifies a list of sidecar containers that will be added after the containers
+	// specified in the pod spec.
+	ContainersAfter []corev1.Container `json:"containersAfter,omitempty"`
+	// Env specifies environment variables that will be set in the containers specified in the pod spec.
+	// They replace the environment variables of the same name.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Volumes specifies additional volumes that will be added to the pod.
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts specifies additional volume mounts that will be added to the containers specified in the pod spec.
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// Labels specifies additional labels that will be added to the pod.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations specifies additional annotations that will be added to the pod.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Affinity replaces the affinity of the pod.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PriorityClassName replaces the priority class of the pod.
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+	// Resources overrides the compute resources of the containers specified in the pod spec. Only the requests
+	// and limits set here are replaced.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// TLSSpec defines the TLS key pair used by the router and UI services.
+type TLSSpec struct {
+	// SecretName is the name of the secret holding the TLS key pair. The secret must contain the
+	// "tls.crt" and "tls.key" entries used by the UI and a "tls-combined.pem" entry with the private key
+	// and certificate chain used by the router.
+	// When CertManager is set, this is the secret cert-manager writes to and defaults to cdap-<name>-tls.
+	SecretName string `json:"secretName,omitempty"`
+	// CertManager requests a cert-manager Certificate for the router and UI services.
+	// Requires cert-manager to be installed in the cluster.
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+}
+
+// CertManagerSpec defines the cert-manager Certificate created for the router and UI services.
+type CertManagerSpec struct {
+	// IssuerRef references the cert-manager Issuer or ClusterIssuer that signs the certificate.
+	IssuerRef CertManagerIssuerRef `json:"issuerRef"`
+	// DNSNames is a list of additional DNS names for the certificate. The in-cluster DNS names of the
+	// router and UI services are always included.
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// Duration is the requested lifetime of the certificate.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before the certificate expiry cert-manager should renew it.
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// CertManagerIssuerRef references a cert-manager issuer.
+type CertManagerIssuerRef struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+	// Kind of the issuer, either Issuer or ClusterIssuer. Defaults to Issuer.
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer. Defaults to cert-manager.io.
+	Group string `json:"group,omitempty"`
+}
+
+// LoggingSpec defines the logging of the CDAP services.
+type LoggingSpec struct {
+	// Format is the format of the console logs of the services and of the log files of programs. "Pattern" is the
+	// plain text pattern layout. "JSON" writes one JSON object per line with the timestamp, level, thread, logger,
+	// message and exception fields. Defaults to Pattern.
+	Format LogFormat `json:"format,omitempty"`
+	// AppendersConfigMap is the name of a ConfigMap with additional logback appenders, e.g. a syslog appender or
+	// an HTTP collector. Each entry is an XML fragment with one or more <appender> elements, which are added to
+	// logback.xml and logback-container.xml of programs in the order of the keys and attached to the root logger.
+	AppendersConfigMap string `json:"appendersConfigMap,omitempty"`
+}
+
+// LogFormat is the format of the console logs.
+// +kubebuilder:validation:Enum=Pattern;JSON
+type LogFormat string
+
+const (
+	// LogFormatPattern writes logs as plain text with the pattern layout.
+	LogFormatPattern LogFormat = "Pattern"
+	// LogFormatJSON writes logs as one JSON object per line.
+	LogFormatJSON LogFormat = "JSON"
+)
+
+// JVMSpec defines the max heap size and JVM options of the service containers.
+type JVMSpec struct {
+	// HeapPolicy decides how the max heap size is derived from the memory of the container, which is the larger of
+	// its memory request and limit. "Default" takes the larger of memory minus 768Mi and 60% of memory. "Ratio" takes
+	// HeapPercentage of memory. "Reserved" takes memory minus ReservedNonHeap. "ContainerAware" sets
+	// -XX:MaxRAMPercentage to HeapPercentage, letting the JVM size the heap from the container memory limit.
+	// The JAVA_HEAPMAX env var of the service takes precedence over the policy. Defaults to Default.
+	HeapPolicy HeapPolicy `json:"heapPolicy,omitempty"`
+	// HeapPercentage is the percentage of memory used for the heap by the Ratio and ContainerAware policies.
+	// Defaults to 60 for Ratio and 75 for ContainerAware.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	HeapPercentage *int32 `json:"heapPercentage,omitempty"`
+	// ReservedNonHeap is the memory reserved for non heap usage by the Reserved policy. Defaults to 768Mi.
+	ReservedNonHeap *resource.Quantity `json:"reservedNonHeap,omitempty"`
+	// GCOptions are JVM options for garbage collection, e.g. "-XX:+UseG1GC" or "-XX:MaxGCPauseMillis=200".
+	GCOptions []string `json:"gcOptions,omitempty"`
+	// HeapDumpPath enables writing a heap dump to the path on OutOfMemoryError. It should be on a persistent volume,
+	// e.g. under the data directory of stateful services or on an additional volume.
+	HeapDumpPath string `json:"heapDumpPath,omitempty"`
+}
+
+// HeapPolicy is the policy deriving the max heap size from the memory of a container.
+// +kubebuilder:validation:Enum=Default;Ratio;Reserved;ContainerAware
+type HeapPolicy string
+
+const (
+	// HeapPolicyDefault takes the larger of memory minus 768Mi and 60% of memory.
+	HeapPolicyDefault HeapPolicy = "Default"
+	// HeapPolicyRatio takes a percentage of memory.
+	HeapPolicyRatio HeapPolicy = "Ratio"
+	// HeapPolicyReserved takes memory minus a reserved amount.
+	HeapPolicyReserved HeapPolicy = "Reserved"
+	// HeapPolicyContainerAware lets the JVM size the heap from the container memory limit.
+	HeapPolicyContainerAware HeapPolicy = "ContainerAware"
+)
+
+// JVMStatus is the max heap size and JVM options applied to the container of a service.
+type JVMStatus struct {
+	// MaxHeap is the max heap option, e.g. "-Xmx3221225472" or "-XX:MaxRAMPercentage=75.0" for the Java services,
+	// or "--max-old-space-size=1536" for the UI.
+	MaxHeap string `json:"maxHeap,omitempty"`
+	// Options are the GC and heap dump options.
+	Options []string `json:"options,omitempty"`
+}
+
+// ResourceRecommendationSpec defines how the resources of the services are recommended from the observed usage of
+// their containers. Usage is sampled on each reconciliation and kept in memory by the operator, so samples are lost
+// when the operator restarts.
+type ResourceRecommendationSpec struct {
+	// RequestPercentile is the percentile of the observed usage recommended as resource requests. Defaults to 90.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	RequestPercentile *int32 `json:"requestPercentile,omitempty"`
+	// LimitPercentile is the percentile of the observed usage recommended as resource limits. Defaults to 99.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	LimitPercentile *int32 `json:"limitPercentile,omitempty"`
+	// MarginPercentage is the percentage added to the percentiles of the observed usage. Defaults to 15.
+	// +kubebuilder:validation:Minimum=0
+	MarginPercentage *int32 `json:"marginPercentage,omitempty"`
+	// Window is how long usage samples are kept, e.g. "168h" to cover weekly peaks. Defaults to 24h.
+	Window *metav1.Duration `json:"window,omitempty"`
+	// MinSamples is the number of usage samples of a container needed before its resources are recommended.
+	// Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	MinSamples int32 `json:"minSamples,omitempty"`
+	// AutoApply, if set, updates the resources of the services with the recommendations once per maintenance window.
+	// Updating the resources restarts the pods of the services.
+	AutoApply *ResourceAutoApplySpec `json:"autoApply,omitempty"`
+}
+
+// ResourceAutoApplySpec defines when and within which bounds recommended resources are applied to the services.
+type ResourceAutoApplySpec struct {
+	// MinAllowed is the lower bound of the applied requests and limits, e.g. {"cpu": "100m", "memory": "512Mi"}.
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+	// MaxAllowed is the upper bound of the applied requests and limits, e.g. {"cpu": "4", "memory": "16Gi"}.
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+	// MaintenanceWindow is the daily window in which recommendations are applied.
+	MaintenanceWindow MaintenanceWindow `json:"maintenanceWindow"`
+}
+
+// MaintenanceWindow is a daily time window.
+type MaintenanceWindow struct {
+	// Start is the start time of the window in UTC, in the HH:MM format, e.g. "02:00".
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// Duration is the length of the window. Defaults to 1h.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ResourceRecommendation is the resources recommended for the container of a service.
+type ResourceRecommendation struct {
+	// Requests are the recommended CPU and memory requests.
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// Limits are the recommended CPU and memory limits.
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+	// Samples is the number of usage samples the recommendation is based on.
+	Samples int32 `json:"samples,omitempty"`
 }
 
 func init() {
@@ -0,0 +1,48 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// maxCRDSize is the default etcd request size limit, which is below the
+// 3MiB request limit of the API server.
+const maxCRDSize = 1536 * 1024
+
+func TestCRDSize(t *testing.T) {
+	data, err := os.ReadFile("../../config/crd/bases/cdap.cdap.io_cdapmasters.yaml")
+	if err != nil {
+		t.Fatalf("failed to read CRD: %v", err)
+	}
+	j, err := yaml.ToJSON(data)
+	if err != nil {
+		t.Fatalf("failed to convert CRD to JSON: %v", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, j); err != nil {
+		t.Fatalf("failed to compact CRD: %v", err)
+	}
+	if compact.Len() > maxCRDSize {
+		t.Errorf("CRD is %d bytes, want at most %d bytes", compact.Len(), maxCRDSize)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The status types below keep the JSON shape of the status written by earlier versions of the operator,
+// so that existing CDAPMaster objects are read back unchanged.
+
+// Standard condition types
+const (
+	// ConditionReady => controller considers this resource Ready
+	ConditionReady ConditionType = "Ready"
+	// ConditionSettled => observed generation == generation + settled means controller is done acting
+	ConditionSettled ConditionType = "Settled"
+	// ConditionError => last recorded error
+	ConditionError ConditionType = "Error"
+
+	ReasonInit = "Init"
+)
+
+// Statefulset is a generic status holder for stateful-set
+type Statefulset struct {
+	// Replicas defines the no of MySQL instances desired
+	Replicas int32 `json:"replicas"`
+	// ReadyReplicas defines the no of MySQL instances that are ready
+	ReadyReplicas int32 `json:"readycount"`
+	// CurrentReplicas defines the no of MySQL instances that are created
+	CurrentReplicas int32 `json:"currentcount"`
+	// progress is a fuzzy indicator. Interpret as a percentage (0-100)
+	// eg: for statefulsets, progress = 100*readyreplicas/replicas
+	Progress int32 `json:"progress"`
+}
+
+// Pdb is a generic status holder for pdb
+type Pdb struct {
+	// currentHealthy
+	CurrentHealthy int32 `json:"currenthealthy"`
+	// desiredHealthy
+	DesiredHealthy int32 `json:"desiredhealthy"`
+}
+
+// ExtendedStatus is a holder of additional status for well known types
+type ExtendedStatus struct {
+	// StatefulSet status
+	STS *Statefulset `json:"sts,omitempty"`
+	// PDB status
+	PDB *Pdb `json:"pdb,omitempty"`
+}
+
+// ComponentMeta is a generic set of fields for component status objects
+type ComponentMeta struct {
+	// Resources embeds a list of object statuses
+	// +optional
+	ComponentList `json:",inline,omitempty"`
+}
+
+// Meta is a generic set of fields for status objects
+type Meta struct {
+	// ObservedGeneration is the most recent generation observed. It corresponds to the
+	// Object's generation, which is updated on mutation by the API Server.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" protobuf:"varint,1,opt,name=observedGeneration"`
+	// Conditions represents the latest state of the object
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,10,rep,name=conditions"`
+}
+
+// ComponentList is a generic status holder for the top level resource
+type ComponentList struct {
+	// Object status array for all matching objects
+	Objects []ObjectStatus `json:"components,omitempty"`
+}
+
+// ObjectStatus is a generic status holder for objects
+type ObjectStatus struct {
+	// Link to object
+	Link string `json:"link,omitempty"`
+	// Name of object
+	Name string `json:"name,omitempty"`
+	// Kind of object
+	Kind string `json:"kind,omitempty"`
+	// Object group
+	Group string `json:"group,omitempty"`
+	// Status. Values: InProgress, Ready, Unknown
+	Status string `json:"status,omitempty"`
+	// ExtendedStatus adds Kind specific status information for well known types
+	ExtendedStatus `json:",inline,omitempty"`
+}
+
+// ConditionType encodes information on the condition
+type ConditionType string
+
+// Condition describes the state of an object at a certain point.
+type Condition struct {
+	// Type of condition.
+	Type ConditionType `json:"type" protobuf:"bytes,1,opt,name=type,casttype=StatefulSetConditionType"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status" protobuf:"bytes,2,opt,name=status,casttype=k8s.io/api/core/v1.ConditionStatus"`
+	// The reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,4,opt,name=reason"`
+	// A human readable message indicating details about the transition.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,5,opt,name=message"`
+	// Last time the condition was probed
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty" protobuf:"bytes,3,opt,name=lastProbeTime"`
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" protobuf:"bytes,3,opt,name=lastTransitionTime"`
+}
+
+func (m *Meta) addCondition(ctype ConditionType, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	m.Conditions = append(m.Conditions, Condition{
+		Type:               ctype,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setConditionValue updates or creates a new condition
+func (m *Meta) setConditionValue(ctype ConditionType, status corev1.ConditionStatus, reason, message string) {
+	c := m.GetCondition(ctype)
+	if c == nil {
+		m.addCondition(ctype, status, reason, message)
+		return
+	}
+	if c.Status == status && c.Reason == reason && c.Message == message {
+		return
+	}
+	now := metav1.Now()
+	c.LastUpdateTime = now
+	if c.Status != status {
+		c.LastTransitionTime = now
+	}
+	c.Status = status
+	c.Reason = reason
+	c.Message = message
+}
+
+// RemoveCondition removes the condition with the provided type.
+func (m *Meta) RemoveCondition(ctype ConditionType) {
+	for i, c := range m.Conditions {
+		if c.Type == ctype {
+			m.Conditions[i] = m.Conditions[len(m.Conditions)-1]
+			m.Conditions = m.Conditions[:len(m.Conditions)-1]
+			break
+		}
+	}
+}
+
+// GetCondition get existing condition
+func (m *Meta) GetCondition(ctype ConditionType) *Condition {
+	for i := range m.Conditions {
+		if m.Conditions[i].Type == ctype {
+			return &m.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue - if condition is true
+func (m *Meta) IsConditionTrue(ctype ConditionType) bool {
+	if c := m.GetCondition(ctype); c != nil {
+		return c.Status == corev1.ConditionTrue
+	}
+	return false
+}
+
+// SetError - shortcut to set error condition
+func (m *Meta) SetError(reason, message string) {
+	m.SetCondition(ConditionError, reason, message)
+}
+
+// ClearError - shortcut to clear error condition
+func (m *Meta) ClearError() {
+	m.ClearCondition(ConditionError, "NoError", "No error seen")
+}
+
+// EnsureCondition useful for adding default conditions
+func (m *Meta) EnsureCondition(ctype ConditionType) {
+	if c := m.GetCondition(ctype); c != nil {
+		return
+	}
+	m.addCondition(ctype, corev1.ConditionUnknown, ReasonInit, "Not Observed")
+}
+
+// EnsureStandardConditions - helper to inject standard conditions
+func (m *Meta) EnsureStandardConditions() {
+	m.EnsureCondition(ConditionReady)
+	m.EnsureCondition(ConditionSettled)
+	m.EnsureCondition(ConditionError)
+}
+
+// ClearCondition updates or creates a new condition
+func (m *Meta) ClearCondition(ctype ConditionType, reason, message string) {
+	m.setConditionValue(ctype, corev1.ConditionFalse, reason, message)
+}
+
+// SetCondition updates or creates a new condition
+func (m *Meta) SetCondition(ctype ConditionType, reason, message string) {
+	m.setConditionValue(ctype, corev1.ConditionTrue, reason, message)
+}
+
+// ResetComponentList - reset component list objects
+func (cm *ComponentMeta) ResetComponentList() {
+	cm.ComponentList.Objects = []ObjectStatus{}
+}
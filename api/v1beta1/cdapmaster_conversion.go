@@ -0,0 +1,328 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// serviceNames lists the services in the order of CDAPMasterSpec.Services converted from v1alpha1
+var serviceNames = []ServiceName{
+	ServiceAppFabric, ServiceLogs, ServiceMessaging, ServiceMetadata, ServiceMetrics, ServicePreview, ServiceRouter,
+	ServiceUserInterface, ServiceSupportBundle, ServiceTetheringAgent, ServiceArtifactCache, ServiceRuntime,
+	ServiceAuthentication, ServiceSystemMetricsExporter,
+}
+
+// hubServiceFields points to the fields of a service in the v1alpha1 spec. Fields the service doesn't support
+// are nil.
+// +kubebuilder:object:generate=false
+type hubServiceFields struct {
+	base     *v1alpha1.CDAPServiceSpec
+	replicas **int32
+	stateful *v1alpha1.CDAPStatefulServiceSpec
+	external *v1alpha1.CDAPExternalServiceSpec
+	jmx      **v1alpha1.JMXSecuritySpec
+}
+
+// hubService returns the fields of the service in the v1alpha1 spec. Nil optional services are allocated when
+// allocate is set, otherwise nil is returned for them.
+func hubService(spec *v1alpha1.CDAPMasterSpec, name ServiceName, allocate bool) (*hubServiceFields, error) {
+	switch name {
+	case ServiceAppFabric:
+		return &hubServiceFields{base: &spec.AppFabric.CDAPServiceSpec, stateful: &spec.AppFabric.CDAPStatefulServiceSpec}, nil
+	case ServiceLogs:
+		return &hubServiceFields{base: &spec.Logs.CDAPServiceSpec, stateful: &spec.Logs.CDAPStatefulServiceSpec}, nil
+	case ServiceMessaging:
+		return &hubServiceFields{base: &spec.Messaging.CDAPServiceSpec, stateful: &spec.Messaging.CDAPStatefulServiceSpec}, nil
+	case ServiceMetadata:
+		return &hubServiceFields{base: &spec.Metadata.CDAPServiceSpec, replicas: &spec.Metadata.Replicas}, nil
+	case ServiceMetrics:
+		return &hubServiceFields{base: &spec.Metrics.CDAPServiceSpec, stateful: &spec.Metrics.CDAPStatefulServiceSpec}, nil
+	case ServicePreview:
+		return &hubServiceFields{base: &spec.Preview.CDAPServiceSpec, stateful: &spec.Preview.CDAPStatefulServiceSpec}, nil
+	case ServiceRouter:
+		s := &spec.Router.CDAPExternalServiceSpec
+		return &hubServiceFields{base: &s.CDAPServiceSpec, replicas: &s.Replicas, external: s}, nil
+	case ServiceUserInterface:
+		s := &spec.UserInterface.CDAPExternalServiceSpec
+		return &hubServiceFields{base: &s.CDAPServiceSpec, replicas: &s.Replicas, external: s}, nil
+	case ServiceSupportBundle:
+		if spec.SupportBundle == nil && allocate {
+			spec.SupportBundle = &v1alpha1.SupportBundleSpec{}
+		}
+		if s := spec.SupportBundle; s != nil {
+			return &hubServiceFields{base: &s.CDAPServiceSpec, stateful: &s.CDAPStatefulServiceSpec}, nil
+		}
+	case ServiceTetheringAgent:
+		if spec.TetheringAgent == nil && allocate {
+			spec.TetheringAgent = &v1alpha1.TetheringAgentSpec{}
+		}
+		if s := spec.TetheringAgent; s != nil {
+			return &hubServiceFields{base: &s.CDAPServiceSpec, stateful: &s.CDAPStatefulServiceSpec}, nil
+		}
+	case ServiceArtifactCache:
+		if spec.ArtifactCache == nil && allocate {
+			spec.ArtifactCache = &v1alpha1.ArtifactCacheSpec{}
+		}
+		if s := spec.ArtifactCache; s != nil {
+			return &hubServiceFields{base: &s.CDAPServiceSpec, stateful: &s.CDAPStatefulServiceSpec}, nil
+		}
+	case ServiceRuntime:
+		if spec.Runtime == nil && allocate {
+			spec.Runtime = &v1alpha1.RuntimeSpec{}
+		}
+		if s := spec.Runtime; s != nil {
+			return &hubServiceFields{base: &s.CDAPServiceSpec, stateful: &s.CDAPStatefulServiceSpec, replicas: &s.Replicas}, nil
+		}
+	case ServiceAuthentication:
+		if spec.Authentication == nil && allocate {
+			spec.Authentication = &v1alpha1.AuthenticationSpec{}
+		}
+		if s := spec.Authentication; s != nil {
+			return &hubServiceFields{base: &s.CDAPServiceSpec, replicas: &s.Replicas}, nil
+		}
+	case ServiceSystemMetricsExporter:
+		if spec.SystemMetricsExporter == nil && allocate {
+			spec.SystemMetricsExporter = &v1alpha1.SystemMetricExporterSpec{}
+		}
+		if s := spec.SystemMetricsExporter; s != nil {
+			return &hubServiceFields{base: &s.CDAPServiceSpec, jmx: &s.JMXSecurity}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown service %q", name)
+	}
+	return nil, nil
+}
+
+// ConvertTo converts this CDAPMaster to the hub version, v1alpha1.
+func (src *CDAPMaster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.CDAPMaster)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	s, d := &src.Spec, &dst.Spec
+	d.Image = s.Image
+	d.UserInterfaceImage = s.UserInterfaceImage
+	d.ImagePullPolicy = s.ImagePullPolicy
+	d.ImagePullSecrets = s.ImagePullSecrets
+	d.SecuritySecret = s.SecuritySecret
+	d.ServiceAccountName = s.ServiceAccountName
+	d.Env = s.Env
+	d.EnvFrom = s.EnvFrom
+	d.LocationURI = s.LocationURI
+	d.Config = s.Config.Site
+	d.HadoopConfig = s.Config.Hadoop
+	d.SecretConfig = s.Config.Secrets
+	d.SystemAppConfigs = s.Config.SystemApps
+	d.LogLevels = s.Config.LogLevels
+	d.ConfigMapVolumes = s.ConfigMapVolumes
+	d.SecretVolumes = s.SecretVolumes
+	d.PodSecurityContext = s.PodSecurityContext
+	d.ContainerSecurityContext = s.ContainerSecurityContext
+	d.SecurityContextPreset = v1alpha1.SecurityContextPreset(s.SecurityContextPreset)
+	d.AdditionalVolumes = s.AdditionalVolumes
+	d.AdditionalVolumeMounts = s.AdditionalVolumeMounts
+	d.ExtraContainers = s.ExtraContainers
+	d.ExtraInitContainers = s.ExtraInitContainers
+	d.ExtraContainersMountConfig = s.ExtraContainersMountConfig
+	d.MutationFailurePolicy = v1alpha1.MutationFailurePolicy(s.MutationFailurePolicy)
+	d.ManifestPatchesConfigMap = s.ManifestPatchesConfigMap
+	if err := convertFields(&s.SecurityContext, &d.SecurityContext); err != nil {
+		return err
+	}
+	if err := convertFields(&s.MutationConfigs, &d.MutationConfigs); err != nil {
+		return err
+	}
+	if err := convertFields(&s.TLS, &d.TLS); err != nil {
+		return err
+	}
+	if err := convertFields(&s.Logging, &d.Logging); err != nil {
+		return err
+	}
+	if err := convertFields(&s.JVM, &d.JVM); err != nil {
+		return err
+	}
+	if err := convertFields(&s.ResourceRecommendations, &d.ResourceRecommendations); err != nil {
+		return err
+	}
+
+	for i := range s.Services {
+		service := &s.Services[i]
+		fields, err := hubService(d, service.Name, true)
+		if err != nil {
+			return err
+		}
+		if err := service.convertTo(fields); err != nil {
+			return err
+		}
+	}
+
+	return convertFields(&src.Status, &dst.Status)
+}
+
+// ConvertFrom converts from the hub version, v1alpha1, to this version.
+func (dst *CDAPMaster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.CDAPMaster)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	s, d := &src.Spec, &dst.Spec
+	d.Image = s.Image
+	d.UserInterfaceImage = s.UserInterfaceImage
+	d.ImagePullPolicy = s.ImagePullPolicy
+	d.ImagePullSecrets = s.ImagePullSecrets
+	d.SecuritySecret = s.SecuritySecret
+	d.ServiceAccountName = s.ServiceAccountName
+	d.Env = s.Env
+	d.EnvFrom = s.EnvFrom
+	d.LocationURI = s.LocationURI
+	d.Config = ConfigSpec{
+		Site:       s.Config,
+		Hadoop:     s.HadoopConfig,
+		Secrets:    s.SecretConfig,
+		SystemApps: s.SystemAppConfigs,
+		LogLevels:  s.LogLevels,
+	}
+	d.ConfigMapVolumes = s.ConfigMapVolumes
+	d.SecretVolumes = s.SecretVolumes
+	d.PodSecurityContext = s.PodSecurityContext
+	d.ContainerSecurityContext = s.ContainerSecurityContext
+	d.SecurityContextPreset = SecurityContextPreset(s.SecurityContextPreset)
+	d.AdditionalVolumes = s.AdditionalVolumes
+	d.AdditionalVolumeMounts = s.AdditionalVolumeMounts
+	d.ExtraContainers = s.ExtraContainers
+	d.ExtraInitContainers = s.ExtraInitContainers
+	d.ExtraContainersMountConfig = s.ExtraContainersMountConfig
+	d.MutationFailurePolicy = MutationFailurePolicy(s.MutationFailurePolicy)
+	d.ManifestPatchesConfigMap = s.ManifestPatchesConfigMap
+	if err := convertFields(&s.SecurityContext, &d.SecurityContext); err != nil {
+		return err
+	}
+	if err := convertFields(&s.MutationConfigs, &d.MutationConfigs); err != nil {
+		return err
+	}
+	if err := convertFields(&s.TLS, &d.TLS); err != nil {
+		return err
+	}
+	if err := convertFields(&s.Logging, &d.Logging); err != nil {
+		return err
+	}
+	if err := convertFields(&s.JVM, &d.JVM); err != nil {
+		return err
+	}
+	if err := convertFields(&s.ResourceRecommendations, &d.ResourceRecommendations); err != nil {
+		return err
+	}
+
+	d.Services = nil
+	for _, name := range serviceNames {
+		fields, err := hubService(s, name, false)
+		if err != nil {
+			return err
+		}
+		// Optional services that are nil in v1alpha1 are not listed
+		if fields == nil {
+			continue
+		}
+		service := ServiceSpec{Name: name}
+		if err := service.convertFrom(fields); err != nil {
+			return err
+		}
+		d.Services = append(d.Services, service)
+	}
+
+	return convertFields(&src.Status, &dst.Status)
+}
+
+// convertTo sets the fields of the service in the v1alpha1 spec
+func (s *ServiceSpec) convertTo(f *hubServiceFields) error {
+	*f.base = v1alpha1.CDAPServiceSpec{}
+	if err := convertFields(&s.CDAPServiceSpec, f.base); err != nil {
+		return err
+	}
+	f.base.Enabled = s.Enabled
+	if s.Replicas != nil {
+		if f.replicas == nil {
+			return fmt.Errorf("service %s doesn't support replicas", s.Name)
+		}
+		*f.replicas = s.Replicas
+	}
+	if s.Storage != nil {
+		if f.stateful == nil {
+			return fmt.Errorf("service %s doesn't support storage", s.Name)
+		}
+		f.stateful.StorageSize = s.Storage.Size
+		f.stateful.StorageClassName = s.Storage.StorageClassName
+	}
+	if s.Network != nil {
+		if f.external == nil {
+			return fmt.Errorf("service %s doesn't support network", s.Name)
+		}
+		f.external.ServiceType = (*string)(s.Network.Type)
+		f.external.LoadBalancerIP = s.Network.LoadBalancerIP
+		f.external.ServicePort = s.Network.Port
+		f.external.Annotations = s.Network.Annotations
+	}
+	if s.JMXSecurity != nil {
+		if f.jmx == nil {
+			return fmt.Errorf("service %s doesn't support jmxSecurity", s.Name)
+		}
+		*f.jmx = (*v1alpha1.JMXSecuritySpec)(s.JMXSecurity)
+	}
+	return nil
+}
+
+// convertFrom sets the fields of the service from the v1alpha1 spec
+func (s *ServiceSpec) convertFrom(f *hubServiceFields) error {
+	if err := convertFields(f.base, &s.CDAPServiceSpec); err != nil {
+		return err
+	}
+	s.Enabled = f.base.Enabled
+	if f.replicas != nil {
+		s.Replicas = *f.replicas
+	}
+	if f.stateful != nil && (f.stateful.StorageSize != "" || f.stateful.StorageClassName != nil) {
+		s.Storage = &StorageSpec{
+			Size:             f.stateful.StorageSize,
+			StorageClassName: f.stateful.StorageClassName,
+		}
+	}
+	if e := f.external; e != nil && (e.ServiceType != nil || e.LoadBalancerIP != nil || e.ServicePort != nil || e.Annotations != nil) {
+		s.Network = &NetworkSpec{
+			Type:           (*corev1.ServiceType)(e.ServiceType),
+			LoadBalancerIP: e.LoadBalancerIP,
+			Port:           e.ServicePort,
+			Annotations:    e.Annotations,
+		}
+	}
+	if f.jmx != nil {
+		s.JMXSecurity = (*JMXSecuritySpec)(*f.jmx)
+	}
+	return nil
+}
+
+// convertFields copies between types of both versions that have the same JSON representation
+func convertFields(in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
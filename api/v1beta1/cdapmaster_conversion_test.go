@@ -0,0 +1,423 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHubRoundTrip(t *testing.T) {
+	testCases := []struct {
+		description string
+		hub         *v1alpha1.CDAPMaster
+	}{
+		{
+			description: "test_cr",
+			hub:         readHub(t, "../../controllers/testdata/cdap_master_cr.json"),
+		},
+		{
+			description: "all_fields",
+			hub:         fullHub(),
+		},
+		{
+			description: "optional_services_disabled",
+			hub: &v1alpha1.CDAPMaster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec:       v1alpha1.CDAPMasterSpec{LocationURI: "hdfs://hadoop:9000"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			spoke := &CDAPMaster{}
+			if err := spoke.ConvertFrom(tc.hub.DeepCopy()); err != nil {
+				t.Fatalf("ConvertFrom() returned unexpected error: %v", err)
+			}
+			got := &v1alpha1.CDAPMaster{}
+			if err := spoke.ConvertTo(got); err != nil {
+				t.Fatalf("ConvertTo() returned unexpected error: %v", err)
+			}
+			// The apiVersion and kind are set by the conversion webhook.
+			if diff := cmp.Diff(tc.hub, got, cmpopts.IgnoreFields(v1alpha1.CDAPMaster{}, "TypeMeta")); diff != "" {
+				t.Errorf("Round trip through v1beta1 changed the object (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestFullHubSetsAllFields makes sure that fullHub, and therefore TestHubRoundTrip, covers the fields added to
+// the v1alpha1 spec.
+func TestFullHubSetsAllFields(t *testing.T) {
+	spec := reflect.ValueOf(fullHub().Spec)
+	for i := 0; i < spec.NumField(); i++ {
+		if spec.Field(i).IsZero() {
+			t.Errorf("fullHub() doesn't set field %s", spec.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestSpokeRoundTrip(t *testing.T) {
+	disabled := false
+	testCases := []struct {
+		description string
+		spoke       *CDAPMaster
+	}{
+		{
+			description: "core_services_only",
+			spoke:       &CDAPMaster{ObjectMeta: metav1.ObjectMeta{Name: "test"}, Spec: CDAPMasterSpec{Services: coreServices()}},
+		},
+		{
+			description: "all_fields",
+			spoke: &CDAPMaster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: map[string]string{"owner": "cdap"}},
+				Spec: CDAPMasterSpec{
+					Image:       "gcr.io/cdapio/cdap:6.10.0",
+					LocationURI: "gs://bucket",
+					Config: ConfigSpec{
+						Site:       map[string]string{"enable.preview": "true"},
+						Secrets:    map[string]corev1.SecretKeySelector{"data.storage.sql.jdbc.password": {Key: "password"}},
+						SystemApps: map[string]string{"app.json": "{}"},
+						LogLevels:  map[string]string{"io.cdap": "DEBUG"},
+					},
+					Services: append(coreServices(),
+						ServiceSpec{Name: ServiceRuntime, Enabled: boolPtr(true), Replicas: int32Ptr(2), Storage: &StorageSpec{Size: "10Gi"}},
+						ServiceSpec{Name: ServiceSystemMetricsExporter, Enabled: boolPtr(true), JMXSecurity: &JMXSecuritySpec{PasswordSecret: "jmx"}},
+					),
+					MutationFailurePolicy: MutationFailurePolicyIgnore,
+					TLS:                   &TLSSpec{SecretName: "tls"},
+				},
+			},
+		},
+		{
+			description: "disabled_core_service",
+			spoke: &CDAPMaster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: CDAPMasterSpec{
+					Services: append(coreServices()[:5],
+						ServiceSpec{Name: ServicePreview, Enabled: &disabled},
+						ServiceSpec{Name: ServiceRouter},
+						ServiceSpec{Name: ServiceUserInterface},
+					),
+				},
+			},
+		},
+		{
+			description: "disabled_optional_service_preserved",
+			spoke: &CDAPMaster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: CDAPMasterSpec{
+					Services: append(coreServices(),
+						ServiceSpec{Name: ServiceSupportBundle, Enabled: &disabled, Storage: &StorageSpec{Size: "20Gi"}},
+						ServiceSpec{Name: ServiceAuthentication, Enabled: boolPtr(true), Replicas: int32Ptr(3)},
+					),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			hub := &v1alpha1.CDAPMaster{}
+			if err := tc.spoke.DeepCopy().ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo() returned unexpected error: %v", err)
+			}
+			got := &CDAPMaster{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.spoke, got); diff != "" {
+				t.Errorf("Round trip through v1alpha1 changed the object (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+	disabled := false
+	testCases := []struct {
+		description string
+		services    []ServiceSpec
+		want        func(spec *v1alpha1.CDAPMasterSpec) bool
+		wantErr     string
+	}{
+		{
+			description: "unlisted_optional_service_disabled",
+			want:        func(spec *v1alpha1.CDAPMasterSpec) bool { return spec.Runtime == nil },
+		},
+		{
+			description: "listed_optional_service_enabled",
+			services:    []ServiceSpec{{Name: ServiceArtifactCache}},
+			want:        func(spec *v1alpha1.CDAPMasterSpec) bool { return spec.ArtifactCache != nil },
+		},
+		{
+			description: "network_converted",
+			services: []ServiceSpec{{
+				Name:    ServiceRouter,
+				Network: &NetworkSpec{Type: serviceTypePtr(corev1.ServiceTypeLoadBalancer), Port: int32Ptr(443)},
+			}},
+			want: func(spec *v1alpha1.CDAPMasterSpec) bool {
+				return *spec.Router.ServiceType == "LoadBalancer" && *spec.Router.ServicePort == 443
+			},
+		},
+		{
+			description: "disabled_core_service_converted",
+			services:    []ServiceSpec{{Name: ServicePreview, Enabled: &disabled}},
+			want: func(spec *v1alpha1.CDAPMasterSpec) bool {
+				return spec.Preview.Enabled != nil && !*spec.Preview.Enabled
+			},
+		},
+		{
+			description: "disabled_optional_service_converted",
+			services:    []ServiceSpec{{Name: ServiceSupportBundle, Enabled: &disabled}},
+			want: func(spec *v1alpha1.CDAPMasterSpec) bool {
+				return spec.SupportBundle != nil && spec.SupportBundle.Enabled != nil && !*spec.SupportBundle.Enabled
+			},
+		},
+		{
+			description: "unsupported_replicas_rejected",
+			services:    []ServiceSpec{{Name: ServiceAppFabric, Replicas: int32Ptr(2)}},
+			wantErr:     "service appFabric doesn't support replicas",
+		},
+		{
+			description: "unsupported_storage_rejected",
+			services:    []ServiceSpec{{Name: ServiceMetadata, Storage: &StorageSpec{Size: "10Gi"}}},
+			wantErr:     "service metadata doesn't support storage",
+		},
+		{
+			description: "unsupported_network_rejected",
+			services:    []ServiceSpec{{Name: ServiceLogs, Network: &NetworkSpec{Port: int32Ptr(80)}}},
+			wantErr:     "service logs doesn't support network",
+		},
+		{
+			description: "unsupported_jmx_security_rejected",
+			services:    []ServiceSpec{{Name: ServiceMetrics, JMXSecurity: &JMXSecuritySpec{}}},
+			wantErr:     "service metrics doesn't support jmxSecurity",
+		},
+		{
+			description: "unknown_service_rejected",
+			services:    []ServiceSpec{{Name: "scheduler"}},
+			wantErr:     `unknown service "scheduler"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			spoke := &CDAPMaster{Spec: CDAPMasterSpec{Services: tc.services}}
+			hub := &v1alpha1.CDAPMaster{}
+			err := spoke.ConvertTo(hub)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ConvertTo() returned unexpected error: want %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertTo() returned unexpected error: %v", err)
+			}
+			if !tc.want(&hub.Spec) {
+				t.Errorf("ConvertTo() returned unexpected spec: %+v", hub.Spec)
+			}
+		})
+	}
+}
+
+func readHub(t *testing.T, path string) *v1alpha1.CDAPMaster {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	hub := &v1alpha1.CDAPMaster{}
+	if err := json.Unmarshal(b, hub); err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	return hub
+}
+
+// fullHub returns a v1alpha1 CDAPMaster with all the spec fields set.
+func fullHub() *v1alpha1.CDAPMaster {
+	// Times are serialized with a precision of seconds.
+	now := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := v1alpha1.CDAPServiceSpec{
+		ObjectMeta:         metav1.ObjectMeta{Labels: map[string]string{"tier": "backend"}},
+		ServiceAccountName: "cdap",
+		Resources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		NodeSelector:        map[string]string{"pool": "cdap"},
+		PriorityClassName:   strPtr("high"),
+		Env:                 []corev1.EnvVar{{Name: "JAVA_HEAPMAX", Value: "-Xmx1g"}},
+		EnableSystemMetrics: boolPtr(true),
+		Tolerations:         []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+		ExtraContainers:     []corev1.Container{{Name: "proxy", Image: "envoyproxy/envoy:v1.24.0"}},
+		LogLevels:           map[string]string{"io.cdap.cdap.internal.app": "DEBUG"},
+		JVM:                 &v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyRatio, HeapPercentage: int32Ptr(70)},
+	}
+	stateful := v1alpha1.CDAPStatefulServiceSpec{StorageSize: "100Gi", StorageClassName: strPtr("standard")}
+	external := v1alpha1.CDAPExternalServiceSpec{
+		ServiceType:    strPtr("LoadBalancer"),
+		LoadBalancerIP: strPtr("10.0.0.1"),
+		ServicePort:    int32Ptr(443),
+		Annotations:    map[string]string{"cloud.google.com/load-balancer-type": "Internal"},
+	}
+	external.CDAPServiceSpec = service
+	external.Replicas = int32Ptr(2)
+
+	hub := &v1alpha1.CDAPMaster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "cdap.cdap.io/v1alpha1", Kind: "CDAPMaster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Generation: 3},
+		Spec: v1alpha1.CDAPMasterSpec{
+			Image:              "gcr.io/cdapio/cdap:6.10.0",
+			UserInterfaceImage: "gcr.io/cdapio/cdap-ui:6.10.0",
+			ImagePullPolicy:    corev1.PullIfNotPresent,
+			ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "registry"}},
+			SecuritySecret:     "cdap-security",
+			ServiceAccountName: "cdap",
+			Env:                []corev1.EnvVar{{Name: "TZ", Value: "UTC"}},
+			EnvFrom:            []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env"}}}},
+			LocationURI:        "gs://bucket",
+			Config:             map[string]string{"enable.preview": "true"},
+			HadoopConfig:       map[string]map[string]string{"hdfs-site.xml": {"dfs.replication": "2"}},
+			SecretConfig:       map[string]corev1.SecretKeySelector{"data.storage.sql.jdbc.password": {Key: "password"}},
+			ConfigMapVolumes:   map[string]string{"hadoop": "/etc/hadoop/conf"},
+			SecretVolumes:      map[string]string{"keytab": "/etc/security/keytabs"},
+			SystemAppConfigs:   map[string]string{"app.json": "{}"},
+			LogLevels:          map[string]string{"io.cdap": "DEBUG"},
+			Logging:            &v1alpha1.LoggingSpec{Format: v1alpha1.LogFormatJSON, AppendersConfigMap: "appenders"},
+			JVM:                &v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyContainerAware, GCOptions: []string{"-XX:+UseG1GC"}, HeapDumpPath: "/data/heapdump"},
+			Metadata:           v1alpha1.MetadataSpec{},
+			SupportBundle:      &v1alpha1.SupportBundleSpec{},
+			TetheringAgent:     &v1alpha1.TetheringAgentSpec{},
+			ArtifactCache:      &v1alpha1.ArtifactCacheSpec{},
+			Runtime:            &v1alpha1.RuntimeSpec{},
+			Authentication:     &v1alpha1.AuthenticationSpec{},
+			SystemMetricsExporter: &v1alpha1.SystemMetricExporterSpec{
+				JMXSecurity: &v1alpha1.JMXSecuritySpec{PasswordSecret: "jmx", KeystoreSecret: "jmx-ssl"},
+			},
+			SecurityContext:            &v1alpha1.SecurityContext{RunAsUser: int64Ptr(1000), RunAsNonRoot: boolPtr(true)},
+			PodSecurityContext:         &corev1.PodSecurityContext{FSGroup: int64Ptr(1000)},
+			ContainerSecurityContext:   &corev1.SecurityContext{ReadOnlyRootFilesystem: boolPtr(true)},
+			SecurityContextPreset:      v1alpha1.SecurityContextPresetRestricted,
+			AdditionalVolumes:          []corev1.Volume{{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+			AdditionalVolumeMounts:     []corev1.VolumeMount{{Name: "scratch", MountPath: "/scratch"}},
+			ExtraContainers:            []corev1.Container{{Name: "fluent-bit", Image: "fluent/fluent-bit:2.0"}},
+			ExtraInitContainers:        []corev1.Container{{Name: "fetch-secrets", Image: "busybox"}},
+			ExtraContainersMountConfig: true,
+			MutationConfigs: []v1alpha1.MutationConfig{{
+				LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"cdap.twill.app": "preview"}},
+				PodMutations:  v1alpha1.PodMutationConfig{NodeSelector: &map[string]string{"pool": "preview"}},
+			}},
+			MutationFailurePolicy:    v1alpha1.MutationFailurePolicyIgnore,
+			ManifestPatchesConfigMap: "cdap-patches",
+			TLS: &v1alpha1.TLSSpec{CertManager: &v1alpha1.CertManagerSpec{
+				IssuerRef: v1alpha1.CertManagerIssuerRef{Name: "ca", Kind: "ClusterIssuer"},
+				Duration:  &metav1.Duration{Duration: 90 * 24 * time.Hour},
+			}},
+			ResourceRecommendations: &v1alpha1.ResourceRecommendationSpec{
+				RequestPercentile: int32Ptr(95),
+				Window:            &metav1.Duration{Duration: 168 * time.Hour},
+				AutoApply: &v1alpha1.ResourceAutoApplySpec{
+					MaxAllowed:        corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Gi")},
+					MaintenanceWindow: v1alpha1.MaintenanceWindow{Start: "02:00"},
+				},
+			},
+		},
+		Status: v1alpha1.CDAPMasterStatus{
+			Meta: v1alpha1.Meta{
+				ObservedGeneration: 3,
+				Conditions: []v1alpha1.Condition{{
+					Type:               v1alpha1.ConditionReady,
+					Status:             corev1.ConditionTrue,
+					LastUpdateTime:     now,
+					LastTransitionTime: now,
+				}},
+			},
+			ImageToUse:             "gcr.io/cdapio/cdap:6.10.0",
+			UpgradeStartTimeMillis: 1672531200000,
+			JVM:                    map[string]v1alpha1.JVMStatus{"AppFabric": {MaxHeap: "-XX:MaxRAMPercentage=75.0"}},
+			ResourceRecommendations: map[string]v1alpha1.ResourceRecommendation{
+				"appfabric": {Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}, Samples: 12},
+			},
+			ResourceRecommendationsAppliedTime: &now,
+		},
+	}
+	spec := &hub.Spec
+	for _, s := range []*v1alpha1.CDAPServiceSpec{
+		&spec.AppFabric.CDAPServiceSpec, &spec.Logs.CDAPServiceSpec, &spec.Messaging.CDAPServiceSpec,
+		&spec.Metadata.CDAPServiceSpec, &spec.Metrics.CDAPServiceSpec, &spec.Preview.CDAPServiceSpec,
+		&spec.SupportBundle.CDAPServiceSpec, &spec.TetheringAgent.CDAPServiceSpec, &spec.ArtifactCache.CDAPServiceSpec,
+		&spec.Runtime.CDAPServiceSpec, &spec.Authentication.CDAPServiceSpec, &spec.SystemMetricsExporter.CDAPServiceSpec,
+	} {
+		*s = *service.DeepCopy()
+	}
+	for _, s := range []*v1alpha1.CDAPStatefulServiceSpec{
+		&spec.AppFabric.CDAPStatefulServiceSpec, &spec.Logs.CDAPStatefulServiceSpec, &spec.Messaging.CDAPStatefulServiceSpec,
+		&spec.Metrics.CDAPStatefulServiceSpec, &spec.Preview.CDAPStatefulServiceSpec, &spec.SupportBundle.CDAPStatefulServiceSpec,
+		&spec.TetheringAgent.CDAPStatefulServiceSpec, &spec.ArtifactCache.CDAPStatefulServiceSpec, &spec.Runtime.CDAPStatefulServiceSpec,
+	} {
+		*s = *stateful.DeepCopy()
+	}
+	spec.Preview.Enabled = boolPtr(false)
+	spec.TetheringAgent.Enabled = boolPtr(true)
+	spec.Metadata.Replicas = int32Ptr(2)
+	spec.Runtime.Replicas = int32Ptr(2)
+	spec.Authentication.Replicas = int32Ptr(2)
+	spec.Router.CDAPExternalServiceSpec = *external.DeepCopy()
+	spec.UserInterface.CDAPExternalServiceSpec = *external.DeepCopy()
+	return hub
+}
+
+// coreServices returns the core services as listed by ConvertFrom.
+func coreServices() []ServiceSpec {
+	var services []ServiceSpec
+	for _, name := range serviceNames[:8] {
+		services = append(services, ServiceSpec{Name: name})
+	}
+	return services
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func serviceTypePtr(t corev1.ServiceType) *corev1.ServiceType {
+	return &t
+}
@@ -0,0 +1,554 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CDAPMasterSpec defines the desired state of CDAPMaster
+type CDAPMasterSpec struct {
+	// Image is the docker image name for the CDAP backend.
+	Image string `json:"image,omitempty"`
+	// UserInterfaceImage is the docker image name for the CDAP UI.
+	UserInterfaceImage string `json:"userInterfaceImage,omitempty"`
+	// ImagePullPolicy is the policy for pulling docker images on Pod creation.
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets is a list of secrets for pulling docker images from private registries. They are used by
+	// all the service pods and the upgrade jobs. They are intentionally not passed to the pods launched by CDAP for
+	// programs, whose service account should list the secrets instead.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// SecuritySecret is secret that contains security related configurations for CDAP.
+	SecuritySecret string `json:"securitySecret,omitempty"`
+	// ServiceAccountName is the service account for all the service pods.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Env is a list of environment variables for the all service containers.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// EnvFrom is a list of sources to populate environment variables for all the service containers.
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// LocationURI is an URI specifying an object storage for CDAP.
+	LocationURI string `json:"locationURI"`
+	// Config holds the configurations of CDAP.
+	Config ConfigSpec `json:"config,omitempty"`
+	// ConfigMapVolumes defines a map from ConfigMap names to volume mount path.
+	// Key is the configmap object name. Value is the mount path.
+	// This adds ConfigMap data to the directory specified by the volume mount path.
+	ConfigMapVolumes map[string]string `json:"configMapVolumes,omitempty"`
+	// SecretVolumes defines a map from Secret names to volume mount path.
+	// Key is the secret object name. Value is the mount path.
+	// This adds Secret data to the directory specified by the volume mount path.
+	SecretVolumes map[string]string `json:"secretVolumes,omitempty"`
+	// Services is the specification of each CDAP service. The core services run unless they are listed with
+	// enabled set to false, while the optional services only run when they are listed and not disabled.
+	// +listType=map
+	// +listMapKey=name
+	Services []ServiceSpec `json:"services,omitempty"`
+	// SecurityContext defines the security context for all pods for all services.
+	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	// PodSecurityContext defines the pod-level security context for all services. When set, it takes precedence over
+	// the pod-level fields of SecurityContext.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// ContainerSecurityContext defines the security context for all containers of all services. When set, it takes
+	// precedence over the container-level fields of SecurityContext.
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+	// SecurityContextPreset fills in the fields left unset in the pod and container security contexts of all services.
+	// The "restricted" preset complies with the restricted Pod Security Standard.
+	SecurityContextPreset SecurityContextPreset `json:"securityContextPreset,omitempty"`
+	// AdditionalVolumes defines a list of additional volumes for all services.
+	// For information on supported volume types, see https://kubernetes.io/docs/concepts/storage/volumes/.
+	AdditionalVolumes []corev1.Volume `json:"additionalVolumes,omitempty"`
+	// AdditionalVolumeMounts defines a list of additional volume mounts for all services.
+	// For information on supported volume mount types, see https://kubernetes.io/docs/concepts/storage/volumes/.
+	AdditionalVolumeMounts []corev1.VolumeMount `json:"additionalVolumeMounts,omitempty"`
+	// ExtraContainers defines a list of sidecar containers, e.g. log shippers or proxies, added to the pods of all
+	// services.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraContainers []corev1.Container `json:"extraContainers,omitempty"`
+	// ExtraInitContainers defines a list of init containers added to the pods of all services. They run after the
+	// init containers added by the operator.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraInitContainers []corev1.Container `json:"extraInitContainers,omitempty"`
+	// ExtraContainersMountConfig mounts the CDAP configuration volumes, i.e. pod info, cdap-site.xml, the hadoop
+	// configurations, the system app configurations and the security secret, into the extra containers and init
+	// containers at the same paths as in the service containers. Volumes already mounted by a container are skipped.
+	ExtraContainersMountConfig bool `json:"extraContainersMountConfig,omitempty"`
+	// MutationConfigs specifies mutations that can be applied to resources with the "cdap.instance" label.
+	// To use mutations, the admission control webhook should be enabled in the cdap operator.
+	MutationConfigs []MutationConfig `json:"mutationConfigs,omitempty"`
+	// MutationFailurePolicy defines how the admission control webhook handles pods of this instance that it fails to
+	// mutate. "Ignore" admits the pod without mutations while "Fail" rejects it.
+	// Defaults to the policy set by the --webhook-failure-policy flag of the cdap operator.
+	MutationFailurePolicy MutationFailurePolicy `json:"mutationFailurePolicy,omitempty"`
+	// TLS enables HTTPS on the router and UI services.
+	TLS *TLSSpec `json:"tls,omitempty"`
+	// Logging configures the log format and additional log appenders of the services.
+	Logging *LoggingSpec `json:"logging,omitempty"`
+	// JVM configures the max heap size and JVM options of all services.
+	JVM *JVMSpec `json:"jvm,omitempty"`
+	// ResourceRecommendations enables recommending the resources of the services from their observed usage.
+	ResourceRecommendations *ResourceRecommendationSpec `json:"resourceRecommendations,omitempty"`
+	// ManifestPatchesConfigMap is the name of a ConfigMap with patches applied to the statefulsets, deployments and
+	// services generated for the CDAP services. They allow setting fields that are not modeled by CDAPMaster, e.g.
+	// hostAliases or dnsConfig. Each entry is a YAML document with a "target", selecting the generated objects by
+	// "kind" and optionally "name", a "type", either "StrategicMerge" (default) or "JSON6902", and the "patch".
+	// Entries are applied in the order of their keys.
+	ManifestPatchesConfigMap string `json:"manifestPatchesConfigMap,omitempty"`
+}
+
+// ConfigSpec defines the configurations of CDAP. Values are strings, as they are written to the CDAP
+// configuration files.
+type ConfigSpec struct {
+	// Site is a set of configurations that goes into cdap-site.xml. Keys prefixed with "hadoop:" go into
+	// core-site.xml instead, without the prefix. Use Hadoop for new Hadoop configurations.
+	Site map[string]string `json:"site,omitempty"`
+	// Hadoop is a set of Hadoop configuration files, e.g. core-site.xml or hdfs-site.xml. Key is the file name.
+	// Value is the set of properties of the file.
+	Hadoop map[string]map[string]string `json:"hadoop,omitempty"`
+	// Secrets is a set of sensitive configurations whose values are read from secrets. They go into
+	// cdap-security.xml, which is stored in a Secret generated by the operator.
+	// Key is the property name. Value selects the secret key holding the property value.
+	// Pods are restarted when any of the referenced values change.
+	Secrets map[string]corev1.SecretKeySelector `json:"secrets,omitempty"`
+	// SystemApps specifies configs used by CDAP to run system apps dynamically. Key is the file name and value
+	// is the JSON app config written to that file.
+	SystemApps map[string]string `json:"systemApps,omitempty"`
+	// LogLevels is a set of logger name to log level settings.
+	LogLevels map[string]string `json:"logLevels,omitempty"`
+}
+
+// ServiceName is the name of a CDAP service.
+// +kubebuilder:validation:Enum=appFabric;logs;messaging;metadata;metrics;preview;router;userInterface;supportBundle;tetheringAgent;artifactCache;runtime;authentication;systemMetricsExporter
+type ServiceName string
+
+// Core services, which run unless disabled
+const (
+	ServiceAppFabric     ServiceName = "appFabric"
+	ServiceLogs          ServiceName = "logs"
+	ServiceMessaging     ServiceName = "messaging"
+	ServiceMetadata      ServiceName = "metadata"
+	ServiceMetrics       ServiceName = "metrics"
+	ServicePreview       ServiceName = "preview"
+	ServiceRouter        ServiceName = "router"
+	ServiceUserInterface ServiceName = "userInterface"
+)
+
+// Optional services, which only run when enabled
+const (
+	ServiceSupportBundle         ServiceName = "supportBundle"
+	ServiceTetheringAgent        ServiceName = "tetheringAgent"
+	ServiceArtifactCache         ServiceName = "artifactCache"
+	ServiceRuntime               ServiceName = "runtime"
+	ServiceAuthentication        ServiceName = "authentication"
+	ServiceSystemMetricsExporter ServiceName = "systemMetricsExporter"
+)
+
+// ServiceSpec defines the specification of a CDAP service. Replicas, Storage, Network and JMXSecurity are only
+// supported by the services that use them.
+type ServiceSpec struct {
+	// Name is the name of the service.
+	Name ServiceName `json:"name"`
+	// Enabled runs the service. When not set, listed services run unless disabled by their cdap-site.xml toggle,
+	// e.g. "enable.preview" for the preview service.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	CDAPServiceSpec `json:",inline"`
+	// Replicas is number of replicas for the metadata, router, userInterface, runtime and authentication services.
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Storage is the persistent volume of stateful services.
+	Storage *StorageSpec `json:"storage,omitempty"`
+	// Network is the kubernetes service exposing the router and userInterface services.
+	Network *NetworkSpec `json:"network,omitempty"`
+	// JMXSecurity enables authentication and optionally SSL for the JMX server that the metrics sidecar
+	// connects to. Only supported by the systemMetricsExporter service.
+	JMXSecurity *JMXSecuritySpec `json:"jmxSecurity,omitempty"`
+}
+
+// CDAPServiceSpec defines the base set of specifications applicable to all master services.
+type CDAPServiceSpec struct {
+	// Metadata for the service.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// ServiceAccountName overrides the service account for the service pods.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Resources are Compute resources required by the service.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// NodeSelector is a selector which must be true for the pod to fit on a node.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// RuntimeClassName refers to a RuntimeClass object in the node.k8s.io group, which should be used
+	// to run pods for this service.
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+	// PriorityClassName is to specify the priority of the pods for this service.
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+	// ImagePullSecrets overrides the secrets for pulling docker images for the service pods.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Env is a list of environment variables for the master service container.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// EnvFrom is a list of sources to populate environment variables for the service container.
+	// They are appended to the ones in CDAPMasterSpec.
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// LogLevels is a set of logger name to log level settings for the service. They take precedence over the ones
+	// in the config.
+	LogLevels map[string]string `json:"logLevels,omitempty"`
+	// JVM overrides the JVM configuration of CDAPMasterSpec for the service.
+	JVM *JVMSpec `json:"jvm,omitempty"`
+	// ConfigMapVolumes defines a map from ConfigMap names to volume mount path for this service.
+	ConfigMapVolumes map[string]string `json:"configMapVolumes,omitempty"`
+	// SecretVolumes defines a map from Secret names to volume mount path for this service.
+	SecretVolumes map[string]string `json:"secretVolumes,omitempty"`
+	// AdditionalVolumes defines a list of additional volumes to mount to the service.
+	AdditionalVolumes []corev1.Volume `json:"additionalVolumes,omitempty"`
+	// AdditionalVolumeMounts defines a list of additional volume mounts for the service.
+	AdditionalVolumeMounts []corev1.VolumeMount `json:"additionalVolumeMounts,omitempty"`
+	// ExtraContainers defines a list of sidecar containers added to the service pods.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraContainers []corev1.Container `json:"extraContainers,omitempty"`
+	// ExtraInitContainers defines a list of init containers added to the service pods.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	ExtraInitContainers []corev1.Container `json:"extraInitContainers,omitempty"`
+	// ExtraContainersMountConfig overrides CDAPMasterSpec.ExtraContainersMountConfig for the service, including
+	// the extra containers of CDAPMasterSpec in the pods of the service.
+	ExtraContainersMountConfig *bool `json:"extraContainersMountConfig,omitempty"`
+	// SecurityContext overrides the security context for the service pods.
+	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	// PodSecurityContext overrides the pod-level security context for the service pods.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// ContainerSecurityContext overrides the security context for the containers of the service pods.
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+	// EnableSystemMetrics starts a metrics collection container in the pods of stateful services when the
+	// systemMetricsExporter service is enabled.
+	EnableSystemMetrics *bool `json:"enableSystemMetrics,omitempty"`
+	// Lifecycle is to specify Container Lifecycle hooks provided by Kubernetes for containers.
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+	// Affinity describes node affinity scheduling rules for the service.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations allow the service pods to be scheduled onto nodes with matching taints.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// TopologySpreadConstraints describes how the service pods ought to spread across topology domains.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// StorageSpec defines the persistent volume of a stateful service.
+type StorageSpec struct {
+	// Size is the size of the persistent volume, e.g. 200Gi.
+	Size string `json:"size,omitempty"`
+	// StorageClassName is the name of the StorageClass for the persistent volume.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// NetworkSpec defines the kubernetes service exposing a CDAP service.
+type NetworkSpec struct {
+	// Type is the type of the kubernetes service. Defaults to NodePort.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	Type *corev1.ServiceType `json:"type,omitempty"`
+	// LoadBalancerIP is the static IP address reserved for the service's load balancer.
+	LoadBalancerIP *string `json:"loadBalancerIP,omitempty"`
+	// Port is the port number for the service.
+	Port *int32 `json:"port,omitempty"`
+	// Annotations are the metadata annotations for the kubernetes service.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// JMXSecuritySpec defines the credentials and keystores used to secure the JMX server of services
+// with system metrics collection enabled.
+type JMXSecuritySpec struct {
+	// PasswordSecret is the name of the secret containing the "jmxremote.password" and "jmxremote.access" files.
+	// When empty, the operator generates the secret cdap-<name>-jmx with a random password.
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+	// KeystoreSecret is the name of the secret containing the "jmxremote.ssl.properties" and "truststore.jks"
+	// files. SSL is enabled for the JMX server and its RMI registry when set.
+	KeystoreSecret string `json:"keystoreSecret,omitempty"`
+}
+
+// CDAPMasterStatus defines the observed state of CDAPMaster
+type CDAPMasterStatus struct {
+	Meta          `json:",inline"`
+	ComponentMeta `json:",inline"`
+	// ImageToUse is the Docker image of CDAP backend the operator uses to deploy.
+	ImageToUse string `json:"imageToUse,omitempty"`
+	// UserInterfaceImageToUse is the Docker image of CDAP UI the operator uses to deploy.
+	UserInterfaceImageToUse string `json:"userInterfaceImageToUse,omitempty"`
+	// UpgradeStartTimeMillis is the start time in milliseconds of the upgrade process
+	UpgradeStartTimeMillis int64 `json:"upgradeStartTimeMillis,omitempty"`
+	// DowngradeStartTimeMillis is the start time in milliseconds of the downgrade process
+	DowngradeStartTimeMillis int64 `json:"downgradeStartTimeMillis,omitempty"`
+	// JVM is the max heap size and JVM options applied to the containers of the services, keyed by
+	// container name, e.g. appfabric.
+	JVM map[string]JVMStatus `json:"jvm,omitempty"`
+	// ResourceRecommendations are the resources recommended for the containers of the services, keyed by
+	// container name, e.g. appfabric.
+	ResourceRecommendations map[string]ResourceRecommendation `json:"resourceRecommendations,omitempty"`
+	// ResourceRecommendationsAppliedTime is the last time recommendations were applied to the services.
+	ResourceRecommendationsAppliedTime *metav1.Time `json:"resourceRecommendationsAppliedTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:unservedversion
+
+// CDAPMaster is the Schema for the cdapmasters API. The version is not served
+// until the conversion webhook is enabled, see config/crd/kustomization.yaml.
+type CDAPMaster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CDAPMasterSpec   `json:"spec,omitempty"`
+	Status CDAPMasterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CDAPMasterList contains a list of CDAPMaster
+type CDAPMasterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CDAPMaster `json:"items"`
+}
+
+// SecurityContext defines fields for setting corev1.SecurityContext for containers and
+// corev1.PodSecurityContext for pods.
+type SecurityContext struct {
+	// RunAsUser runs the pod as the specified user ID. It is applied at the pod level.
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// RunAsGroup runs the pod as the specified group ID. It is applied at the pod level.
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// FSGroup mounts volumes as the specified group ID and gives the primary user access
+	// to that group. It is applied at the pod level.
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// AllowPrivilegeEscalation prevents the container process from running SUID binaries.
+	// It is applied at the container level.
+	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty"`
+	// RunAsNonRoot indicates that the container must run as a non-root user.
+	RunAsNonRoot *bool `json:"runAsNonRoot,omitempty"`
+	// Privileged runs container in privileged mode. It is applied at the container level.
+	Privileged *bool `json:"privileged,omitempty"`
+	// ReadOnlyRootFilesystem specifies whether the container's root filesystem is read-only.
+	// It is applied at the container level.
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+}
+
+// SecurityContextPreset is a named set of defaults for pod and container security contexts.
+// +kubebuilder:validation:Enum=restricted
+type SecurityContextPreset string
+
+const (
+	// SecurityContextPresetRestricted runs containers as non-root with the runtime default seccomp profile,
+	// all capabilities dropped and privilege escalation disallowed.
+	SecurityContextPresetRestricted SecurityContextPreset = "restricted"
+)
+
+// MutationFailurePolicy defines how the admission control webhook handles pods that it fails to mutate.
+// +kubebuilder:validation:Enum=Fail;Ignore
+type MutationFailurePolicy string
+
+const (
+	// MutationFailurePolicyFail rejects pods that the webhook fails to mutate.
+	MutationFailurePolicyFail MutationFailurePolicy = "Fail"
+	// MutationFailurePolicyIgnore admits pods that the webhook fails to mutate without mutations.
+	MutationFailurePolicyIgnore MutationFailurePolicy = "Ignore"
+)
+
+// MutationConfig defines mutations that can be applied to resources with the "cdap.instance" label and that
+// satisfy a label selector.
+type MutationConfig struct {
+	// LabelSelector selects resources to apply the mutation to.
+	LabelSelector metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// PodMutations specifies mutations that are be applied to pods which satisfy specified label selectors.
+	PodMutations PodMutationConfig `json:"podMutations,omitempty"`
+}
+
+// PodMutationConfig specifies a mutation along with the selector to identify target pods.
+type PodMutationConfig struct {
+	// InitContainersBefore specifies a list of init containers that will be added before the
+	// the init containers specified in the pod spec.
+	InitContainersBefore []corev1.Container `json:"initContainersBefore,omitempty"`
+	// NodeSelectors specifies additional node selectors that will be added to the pod.
+	NodeSelector *map[string]string `json:"nodeSelectors,omitempty"`
+	// Tolerations specifies additional tolerations that will be added to the pod.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// ContainersAfter specifies a list of sidecar containers that will be added after the containers
+	// specified in the pod spec.
+	ContainersAfter []corev1.Container `json:"containersAfter,omitempty"`
+	// Env specifies environment variables that will be set in the containers specified in the pod spec.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Volumes specifies additional volumes that will be added to the pod.
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts specifies additional volume mounts that will be added to the containers specified in the pod spec.
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// Labels specifies additional labels that will be added to the pod.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations specifies additional annotations that will be added to the pod.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Affinity replaces the affinity of the pod.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PriorityClassName replaces the priority class of the pod.
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+	// Resources overrides the compute resources of the containers specified in the pod spec.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// TLSSpec defines the TLS key pair used by the router and UI services.
+type TLSSpec struct {
+	// SecretName is the name of the secret holding the TLS key pair. The secret must contain the
+	// "tls.crt" and "tls.key" entries used by the UI and a "tls-combined.pem" entry with the private key
+	// and certificate chain used by the router.
+	// When CertManager is set, this is the secret cert-manager writes to and defaults to cdap-<name>-tls.
+	SecretName string `json:"secretName,omitempty"`
+	// CertManager requests a cert-manager Certificate for the router and UI services.
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+}
+
+// CertManagerSpec defines the cert-manager Certificate created for the router and UI services.
+type CertManagerSpec struct {
+	// IssuerRef references the cert-manager Issuer or ClusterIssuer that signs the certificate.
+	IssuerRef CertManagerIssuerRef `json:"issuerRef"`
+	// DNSNames is a list of additional DNS names for the certificate.
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// Duration is the requested lifetime of the certificate.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before the certificate expiry cert-manager should renew it.
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// CertManagerIssuerRef references a cert-manager issuer.
+type CertManagerIssuerRef struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+	// Kind of the issuer, either Issuer or ClusterIssuer. Defaults to Issuer.
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer. Defaults to cert-manager.io.
+	Group string `json:"group,omitempty"`
+}
+
+// LoggingSpec defines the logging of the CDAP services.
+type LoggingSpec struct {
+	// Format is the format of the console logs of the services and of the log files of programs, either "Pattern"
+	// or "JSON". Defaults to Pattern.
+	Format LogFormat `json:"format,omitempty"`
+	// AppendersConfigMap is the name of a ConfigMap with additional logback appenders. Each entry is an XML
+	// fragment with one or more <appender> elements, which are attached to the root logger.
+	AppendersConfigMap string `json:"appendersConfigMap,omitempty"`
+}
+
+// LogFormat is the format of the console logs.
+// +kubebuilder:validation:Enum=Pattern;JSON
+type LogFormat string
+
+const (
+	// LogFormatPattern writes logs as plain text with the pattern layout.
+	LogFormatPattern LogFormat = "Pattern"
+	// LogFormatJSON writes logs as one JSON object per line.
+	LogFormatJSON LogFormat = "JSON"
+)
+
+// JVMSpec defines the max heap size and JVM options of the service containers.
+type JVMSpec struct {
+	// HeapPolicy decides how the max heap size is derived from the memory of the container, either "Default",
+	// "Ratio", "Reserved" or "ContainerAware". Defaults to Default.
+	HeapPolicy HeapPolicy `json:"heapPolicy,omitempty"`
+	// HeapPercentage is the percentage of memory used for the heap by the Ratio and ContainerAware policies.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	HeapPercentage *int32 `json:"heapPercentage,omitempty"`
+	// ReservedNonHeap is the memory reserved for non heap usage by the Reserved policy. Defaults to 768Mi.
+	ReservedNonHeap *resource.Quantity `json:"reservedNonHeap,omitempty"`
+	// GCOptions are JVM options for garbage collection.
+	GCOptions []string `json:"gcOptions,omitempty"`
+	// HeapDumpPath enables writing a heap dump to the path on OutOfMemoryError.
+	HeapDumpPath string `json:"heapDumpPath,omitempty"`
+}
+
+// HeapPolicy is the policy deriving the max heap size from the memory of a container.
+// +kubebuilder:validation:Enum=Default;Ratio;Reserved;ContainerAware
+type HeapPolicy string
+
+const (
+	// HeapPolicyDefault takes the larger of memory minus 768Mi and 60% of memory.
+	HeapPolicyDefault HeapPolicy = "Default"
+	// HeapPolicyRatio takes a percentage of memory.
+	HeapPolicyRatio HeapPolicy = "Ratio"
+	// HeapPolicyReserved takes memory minus a reserved amount.
+	HeapPolicyReserved HeapPolicy = "Reserved"
+	// HeapPolicyContainerAware lets the JVM size the heap from the container memory limit.
+	HeapPolicyContainerAware HeapPolicy = "ContainerAware"
+)
+
+// JVMStatus is the max heap size and JVM options applied to the container of a service.
+type JVMStatus struct {
+	// MaxHeap is the max heap option of the service container.
+	MaxHeap string `json:"maxHeap,omitempty"`
+	// Options are the GC and heap dump options.
+	Options []string `json:"options,omitempty"`
+}
+
+// ResourceRecommendationSpec defines how the resources of the services are recommended from observed usage.
+type ResourceRecommendationSpec struct {
+	// RequestPercentile is the percentile of the observed usage recommended as requests. Defaults to 90.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	RequestPercentile *int32 `json:"requestPercentile,omitempty"`
+	// LimitPercentile is the percentile of the observed usage recommended as limits. Defaults to 99.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	LimitPercentile *int32 `json:"limitPercentile,omitempty"`
+	// MarginPercentage is the percentage added to the percentiles. Defaults to 15.
+	// +kubebuilder:validation:Minimum=0
+	MarginPercentage *int32 `json:"marginPercentage,omitempty"`
+	// Window is how long usage samples are kept. Defaults to 24h.
+	Window *metav1.Duration `json:"window,omitempty"`
+	// MinSamples is the number of usage samples needed before recommending. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	MinSamples int32 `json:"minSamples,omitempty"`
+	// AutoApply, if set, applies the recommendations to the services once per maintenance window.
+	AutoApply *ResourceAutoApplySpec `json:"autoApply,omitempty"`
+}
+
+// ResourceAutoApplySpec defines when and within which bounds recommended resources are applied to the services.
+type ResourceAutoApplySpec struct {
+	// MinAllowed is the lower bound of the applied requests and limits.
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+	// MaxAllowed is the upper bound of the applied requests and limits.
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+	// MaintenanceWindow is the daily window in which recommendations are applied.
+	MaintenanceWindow MaintenanceWindow `json:"maintenanceWindow"`
+}
+
+// MaintenanceWindow is a daily time window.
+type MaintenanceWindow struct {
+	// Start is the start time of the window in UTC, in the HH:MM format.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// Duration is the length of the window. Defaults to 1h.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ResourceRecommendation is the resources recommended for the container of a service.
+type ResourceRecommendation struct {
+	// Requests are the recommended CPU and memory requests.
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// Limits are the recommended CPU and memory limits.
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+	// Samples is the number of usage samples the recommendation is based on.
+	Samples int32 `json:"samples,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CDAPMaster{}, &CDAPMasterList{})
+}
@@ -1,9 +1,12 @@
 /*
-Copyright 2018 Google LLC
+Copyright 2022.
+
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
+
     http://www.apache.org/licenses/LICENSE-2.0
+
 Unless required by applicable law or agreed to in writing, software
 distributed under the License is distributed on an "AS IS" BASIS,
 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
@@ -11,34 +14,28 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package status
+package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// Constants
+// The status types below have the same JSON shape as the v1alpha1 ones, so that the status is converted as is.
+
+// Standard condition types
 const (
-	// Ready => controller considers this resource Ready
-	Ready = "Ready"
-	// TrafficReady => pod sees external traffic
-	TrafficReady = "TrafficReady"
-	// Qualified => functionally tested
-	Qualified = "Qualified"
-	// Settled => observed generation == generation + settled means controller is done acting functionally tested
-	// Cleanup => it is set to track finalizer failures
-	Cleanup = "Cleanup"
-	// Error => last recorded error
-	Error = "Error"
-
-	Settled = "Settled"
+	// ConditionReady => controller considers this resource Ready
+	ConditionReady ConditionType = "Ready"
+	// ConditionSettled => observed generation == generation + settled means controller is done acting
+	ConditionSettled ConditionType = "Settled"
+	// ConditionError => last recorded error
+	ConditionError ConditionType = "Error"
 
 	ReasonInit = "Init"
 )
 
 // Statefulset is a generic status holder for stateful-set
-// +k8s:deepcopy-gen=true
 type Statefulset struct {
 	// Replicas defines the no of MySQL instances desired
 	Replicas int32 `json:"replicas"`
@@ -60,7 +57,6 @@ type Pdb struct {
 }
 
 // ExtendedStatus is a holder of additional status for well known types
-// +k8s:deepcopy-gen=true
 type ExtendedStatus struct {
 	// StatefulSet status
 	STS *Statefulset `json:"sts,omitempty"`
@@ -69,7 +65,6 @@ type ExtendedStatus struct {
 }
 
 // ComponentMeta is a generic set of fields for component status objects
-// +k8s:deepcopy-gen=true
 type ComponentMeta struct {
 	// Resources embeds a list of object statuses
 	// +optional
@@ -77,7 +72,6 @@ type ComponentMeta struct {
 }
 
 // Meta is a generic set of fields for status objects
-// +k8s:deepcopy-gen=true
 type Meta struct {
 	// ObservedGeneration is the most recent generation observed. It corresponds to the
 	// Object's generation, which is updated on mutation by the API Server.
@@ -91,14 +85,12 @@ type Meta struct {
 }
 
 // ComponentList is a generic status holder for the top level resource
-// +k8s:deepcopy-gen=true
 type ComponentList struct {
 	// Object status array for all matching objects
 	Objects []ObjectStatus `json:"components,omitempty"`
 }
 
 // ObjectStatus is a generic status holder for objects
-// +k8s:deepcopy-gen=true
 type ObjectStatus struct {
 	// Link to object
 	Link string `json:"link,omitempty"`
@@ -118,7 +110,6 @@ type ObjectStatus struct {
 type ConditionType string
 
 // Condition describes the state of an object at a certain point.
-// +k8s:deepcopy-gen=true
 type Condition struct {
 	// Type of condition.
 	Type ConditionType `json:"type" protobuf:"bytes,1,opt,name=type,casttype=StatefulSetConditionType"`
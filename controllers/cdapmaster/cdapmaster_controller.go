@@ -14,11 +14,13 @@ limitations under the License.
 package cdapmaster
 
 import (
+	"context"
+
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler/manager/k8s"
 )
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -31,7 +33,7 @@ import (
 // so they are part of reconciling loop just for cleaning up k8s objects created by previous version of operator.
 //
 // Details:
-// The library controller-reconciler used by this operator internally adds a label in the form of
+// The CDAPMaster reconciler adds a label in the form of
 // <package_name>.<handler_struct_name> to k8s objects, thus identifying k8s objects managed by this operator.
 // The new version of operator has different package and handler struct names, therefore without this file upgrading
 // operator from old version to the new one in a kubernetes cluster with CDAP service deployed would cause the new
@@ -76,7 +78,7 @@ type TetheringAgent struct{}
 type ArtifactCache struct{}
 
 // Objects - handler Objects
-func (b *Base) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (b *Base) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -90,7 +92,7 @@ func (b *Base) Observables(rsrc interface{}, labels map[string]string, dependent
 }
 
 // Objects for Messaging service
-func (s *Messaging) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *Messaging) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -103,7 +105,7 @@ func (s *Messaging) Observables(rsrc interface{}, labels map[string]string, depe
 }
 
 // Objects for AppFabric service
-func (s *AppFabric) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *AppFabric) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -116,7 +118,7 @@ func (s *AppFabric) Observables(rsrc interface{}, labels map[string]string, depe
 }
 
 // Objects for Logs service
-func (s *Logs) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *Logs) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -129,7 +131,7 @@ func (s *Logs) Observables(rsrc interface{}, labels map[string]string, dependent
 }
 
 // Objects for Metadata service
-func (s *Metadata) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *Metadata) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -142,7 +144,7 @@ func (s *Metadata) Observables(rsrc interface{}, labels map[string]string, depen
 }
 
 // Objects for Metrics service
-func (s *Metrics) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *Metrics) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -155,7 +157,7 @@ func (s *Metrics) Observables(rsrc interface{}, labels map[string]string, depend
 }
 
 // Objects for Preview service
-func (s *Preview) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *Preview) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -168,7 +170,7 @@ func (s *Preview) Observables(rsrc interface{}, labels map[string]string, depend
 }
 
 // Objects for Router service
-func (s *Router) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *Router) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -182,7 +184,7 @@ func (s *Router) Observables(rsrc interface{}, labels map[string]string, depende
 }
 
 // Objects for UserInterface service
-func (s *UserInterface) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *UserInterface) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -196,7 +198,7 @@ func (s *UserInterface) Observables(rsrc interface{}, labels map[string]string,
 }
 
 // Objects for SupportBundle service
-func (s *SupportBundle) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *SupportBundle) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -209,7 +211,7 @@ func (s *SupportBundle) Observables(rsrc interface{}, labels map[string]string,
 }
 
 // Objects for TetheringAgent service
-func (s *TetheringAgent) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *TetheringAgent) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -222,7 +224,7 @@ func (s *TetheringAgent) Observables(rsrc interface{}, labels map[string]string,
 }
 
 // Objects for ArtifactCache service
-func (s *ArtifactCache) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (s *ArtifactCache) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	return []reconciler.Object{}, nil
 }
 
@@ -17,28 +17,34 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"text/template"
 
 	"cdap.io/cdap-operator/controllers/cdapmaster"
 	batchv1 "k8s.io/api/batch/v1"
-	"sigs.k8s.io/controller-reconciler/pkg/finalizer"
 
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
-	gr "sigs.k8s.io/controller-reconciler/pkg/genericreconciler"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler/manager/k8s"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // CDAPMasterReconciler reconciles a CDAPMaster object
@@ -46,50 +52,101 @@ type CDAPMasterReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// MaxConcurrentReconciles is the number of CDAPMaster objects reconciled in parallel. Defaults to 1.
+	MaxConcurrentReconciles int
+	// ShardSelector, if set, limits reconciliation to the CDAPMaster objects with matching labels.
+	ShardSelector labels.Selector
 }
 
+// SetupWithManager watches CDAPMaster along with all the kinds of objects created by the handlers, so that drift
+// and upgrade job progress are reconciled right away instead of on the next periodic reconciliation. cert-manager
+// Certificates are not watched, as cert-manager may not be installed in the cluster. The ConfigMaps and Secrets
+// referred by CDAPMaster are watched through field indexes, so that their changes are rolled out right away too.
 func (r *CDAPMasterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(context.Background(), &v1alpha1.CDAPMaster{}, referredConfigMapsIndex, referredNamesIndexer(&corev1.ConfigMap{})); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(context.Background(), &v1alpha1.CDAPMaster{}, referredSecretsIndex, referredNamesIndexer(&corev1.Secret{})); err != nil {
+		return err
+	}
+	ownedObjects := builder.WithPredicates(ownedObjectPredicate)
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.CDAPMaster{}).
-		Complete(NewReconciler(mgr))
+		For(&v1alpha1.CDAPMaster{}, builder.WithPredicates(shardPredicate(r.ShardSelector))).
+		WithLogConstructor(r.logConstructor).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Owns(&appsv1.StatefulSet{}, ownedObjects).
+		Owns(&appsv1.Deployment{}, ownedObjects).
+		Owns(&corev1.Service{}, ownedObjects).
+		Owns(&corev1.ConfigMap{}, ownedObjects).
+		Owns(&corev1.Secret{}, ownedObjects).
+		Owns(&batchv1.Job{}, ownedObjects).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, referringMasters(mgr.GetClient(), referredConfigMapsIndex, r.ShardSelector), ownedObjects).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, referringMasters(mgr.GetClient(), referredSecretsIndex, r.ShardSelector), ownedObjects).
+		Complete(NewReconciler(mgr).
+			WithShardSelector(r.ShardSelector).
+			WithResourceRecommender(&metricsAPILister{reader: mgr.GetAPIReader()}))
+}
+
+// logConstructor returns the logger of each reconciliation, which the controller adds a reconcileID to. Handlers
+// add their name as "handler", and objects are logged with their "kind" and "object" name, so that the logs of a
+// CDAPMaster can be filtered by namespace and name.
+func (r *CDAPMasterReconciler) logConstructor(req *reconcile.Request) logr.Logger {
+	logger := r.Log
+	if logger.GetSink() == nil {
+		logger = ctrl.Log.WithName("controllers").WithName("CDAPMaster")
+	}
+	if req != nil {
+		logger = logger.WithValues("namespace", req.Namespace, "name", req.Name)
+	}
+	return logger
 }
 
 // TBD kubebuilder:rbac:groups=app.k8s.io,resources=applications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cdap.cdap.io,resources=cdapmasters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cdap.cdap.io,resources=cdapmasters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
 // Intentionally leave a blank line, otherwise controller-gen won't generate RBAC
 
-func NewReconciler(mgr manager.Manager) *gr.Reconciler {
+func NewReconciler(mgr manager.Manager) *Reconciler {
 	// Registering cdapmaster.* handlers (from old version tags/v1.0) in order to support backward compatibility
 	// Essentially those handler will delete CDAP services and configures created by previous version of operator
 	// and let the handlers in the new operator to re-deploy CDAP.
-	return gr.
-		WithManager(mgr).
-		For(&v1alpha1.CDAPMaster{}, v1alpha1.GroupVersion).
-		Using(&cdapmaster.Base{}).
-		Using(&cdapmaster.Messaging{}).
-		Using(&cdapmaster.AppFabric{}).
-		Using(&cdapmaster.Metrics{}).
-		Using(&cdapmaster.Logs{}).
-		Using(&cdapmaster.Metadata{}).
-		Using(&cdapmaster.Preview{}).
-		Using(&cdapmaster.Router{}).
-		Using(&cdapmaster.UserInterface{}).
-		Using(&cdapmaster.SupportBundle{}).
-		Using(&cdapmaster.TetheringAgent{}).
-		Using(&cdapmaster.ArtifactCache{}).
-		Using(&VersionUpdateHandler{}).
-		Using(&ConfigMapHandler{}).
-		Using(&ServiceHandler{}).
-		WithErrorHandler(HandleError).
-		WithDefaulter(ApplyDefaults).
-		Build()
+	return &Reconciler{
+		client: mgr.GetClient(),
+		rm: k8s.NewRsrcManager(mgr.GetClient(), mgr.GetScheme()).
+			WithFieldManager(fieldManager).
+			WithLegacyFieldManagers(legacyFieldManager).
+			WithEventRecorder(mgr.GetEventRecorderFor(fieldManager)),
+		handlers: []Handler{
+			&cdapmaster.Base{},
+			&cdapmaster.Messaging{},
+			&cdapmaster.AppFabric{},
+			&cdapmaster.Metrics{},
+			&cdapmaster.Logs{},
+			&cdapmaster.Metadata{},
+			&cdapmaster.Preview{},
+			&cdapmaster.Router{},
+			&cdapmaster.UserInterface{},
+			&cdapmaster.SupportBundle{},
+			&cdapmaster.TetheringAgent{},
+			&cdapmaster.ArtifactCache{},
+			&VersionUpdateHandler{},
+			&ConfigMapHandler{},
+			&SecretHandler{},
+			&ServiceHandler{},
+			&CertificateHandler{},
+		},
+	}
 }
 
 func HandleError(resource interface{}, err error, kind string) {
@@ -132,6 +189,10 @@ func ApplyDefaults(resource interface{}) {
 	spec.Config[confRouterBindPort] = strconv.Itoa(int(*spec.Router.ServicePort))
 	spec.Config[confUserInterfaceBindPort] = strconv.Itoa(int(*spec.UserInterface.ServicePort))
 
+	if spec.TLS != nil && spec.TLS.SecretName == "" {
+		spec.TLS.SecretName = getObjName(r, objectNameTLS)
+	}
+
 	// Set the default local data directory if it is not set in cdap-cr.
 	if _, ok := spec.Config[confLocalDataDirKey]; !ok {
 		spec.Config[confLocalDataDirKey] = confLocalDataDirVal
@@ -163,7 +224,51 @@ func ApplyDefaults(resource interface{}) {
 
 	r.Status.ResetComponentList()
 	r.Status.EnsureStandardConditions()
-	finalizer.EnsureStandard(r)
+	controllerutil.AddFinalizer(r, finalizerCleanup)
+}
+
+// getSiteConfig returns the properties of cdap-site.xml. Properties derived from other fields of the spec are
+// added here rather than to spec.config, so they are removed from cdap-site.xml together with the field.
+func getSiteConfig(master *v1alpha1.CDAPMaster) map[string]string {
+	spec := &master.Spec
+	config := make(map[string]string, len(spec.Config))
+	for k, v := range spec.Config {
+		config[k] = v
+	}
+	setDefault := func(key, value string) {
+		if _, ok := config[key]; !ok {
+			config[key] = value
+		}
+	}
+
+	// When TLS is enabled, the router and UI serve HTTPS on the service ports using the mounted key pair.
+	if spec.TLS != nil {
+		config[confSSLExternalEnabled] = "true"
+		config[confRouterSSLServerPort] = strconv.Itoa(int(*spec.Router.ServicePort))
+		config[confUserInterfaceSSLBindPort] = strconv.Itoa(int(*spec.UserInterface.ServicePort))
+		setDefault(confRouterSSLServerCertPath, defaultTLSMountPath+"/"+tlsCombinedPEMKey)
+		setDefault(confUserInterfaceSSLCert, defaultTLSMountPath+"/"+tlsCertKey)
+		setDefault(confUserInterfaceSSLKey, defaultTLSMountPath+"/"+tlsPrivateKeyKey)
+	}
+
+	// Let the metrics sidecar know how to connect to the secured JMX server
+	if spec.SystemMetricsExporter != nil && spec.SystemMetricsExporter.JMXSecurity != nil {
+		setDefault(confJMXServerPasswordFile, jmxAuthMountPath+"/"+jmxPasswordFileKey)
+		config[confJMXServerSSLEnabled] = strconv.FormatBool(spec.SystemMetricsExporter.JMXSecurity.KeystoreSecret != "")
+	}
+
+	// Keep the cdap-site.xml toggles in sync with the services that are deployed
+	for service, key := range serviceConfToggles {
+		if enabled, err := isServiceEnabled(master, service); err == nil {
+			config[key] = strconv.FormatBool(enabled)
+		}
+	}
+
+	// Sensitive configurations only go into cdap-security.xml, which is stored in a secret.
+	for property := range spec.SecretConfig {
+		delete(config, property)
+	}
+	return config
 }
 
 /////////////////////////////////////////////////////////
@@ -178,53 +283,53 @@ func (h *ConfigMapHandler) Observables(rsrc interface{}, labels map[string]strin
 		Get()
 }
 
-func (h *ConfigMapHandler) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+// DependentResources returns the ConfigMap of the log appenders
+func (h *ConfigMapHandler) DependentResources(rsrc interface{}) []reconciler.Object {
+	return getLoggingDependents(rsrc.(*v1alpha1.CDAPMaster))
+}
+
+func (h *ConfigMapHandler) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	var expected []reconciler.Object
 	m := rsrc.(*v1alpha1.CDAPMaster)
 
-	configs := map[string][]string{
-		configMapCConf: {"cdap-site.xml", "logback.xml", "logback-container.xml"},
-		configMapHConf: {"core-site.xml"},
-	}
-
 	templateData := struct {
-		Master *v1alpha1.CDAPMaster
+		Master     *v1alpha1.CDAPMaster
+		SiteConfig map[string]string
 	}{
-		Master: m,
-	}
-
-	fillTemplate := func(templateFile string) (string, error) {
-		tmpl, err := template.New(templateFile).Funcs(template.FuncMap{
-			"hasPrefix": func(str, prefix string) bool {
-				return strings.HasPrefix(str, prefix)
-			},
-			"trimPrefix": func(str, prefix string) string {
-				return strings.TrimPrefix(str, prefix)
-			},
-		}).ParseFiles(templateDir + templateFile)
-		if err != nil {
-			return "", err
-		}
-		var output strings.Builder
-		if err := tmpl.Execute(&output, templateData); err != nil {
-			return "", err
-		}
-		return output.String(), nil
+		Master:     m,
+		SiteConfig: getSiteConfig(m),
 	}
 
+	// Creates the cdap config object with cdap-site.xml and the logback files
 	mergedLabelmap := mergeMaps(m.Labels, rsrclabels)
-	for key, templateFiles := range configs {
-		spec := newConfigMapSpec(m, getObjName(m, key), mergedLabelmap)
-		for _, file := range templateFiles {
-			data, err := fillTemplate(file)
-			if err != nil {
-				return nil, err
-			}
-			spec = spec.AddData(file, data)
-		}
-		obj := buildConfigMapObject(spec)
-		expected = append(expected, obj)
+	cdapConfigSpec := newConfigMapSpec(m, getObjName(m, configMapCConf), mergedLabelmap)
+	if m.Spec.MutationFailurePolicy != "" {
+		cdapConfigSpec = cdapConfigSpec.AddAnnotation(annotationMutationFailurePolicy, string(m.Spec.MutationFailurePolicy))
+	}
+	data, err := fillTemplate(templateCDAPSite, templateData)
+	if err != nil {
+		return nil, err
+	}
+	cdapConfigSpec = cdapConfigSpec.AddData(templateCDAPSite, data)
+	logback, err := renderLogback(m, dependent)
+	if err != nil {
+		return nil, err
 	}
+	for filename, data := range logback {
+		cdapConfigSpec = cdapConfigSpec.AddData(filename, data)
+	}
+	expected = append(expected, buildConfigMapObject(cdapConfigSpec))
+
+	// Creates the hadoop config object. Creates one data object per hadoop config file.
+	hadoopConfig, err := renderHadoopConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	hadoopConfigSpec := newConfigMapSpec(m, getObjName(m, configMapHConf), mergedLabelmap)
+	for filename, data := range hadoopConfig {
+		hadoopConfigSpec = hadoopConfigSpec.AddData(filename, data)
+	}
+	expected = append(expected, buildConfigMapObject(hadoopConfigSpec))
 
 	// Creates system app config object. Creates one data object per system app config file.
 	sysAppConfigSpec := newConfigMapSpec(m, getObjName(m, configMapSysAppConf), mergedLabelmap)
@@ -235,13 +340,31 @@ func (h *ConfigMapHandler) Objects(rsrc interface{}, rsrclabels map[string]strin
 	return expected, nil
 }
 
+// Fill the config file template with the supplied data
+func fillTemplate(templateFile string, templateData interface{}) (string, error) {
+	tmpl, err := template.New(templateFile).Funcs(template.FuncMap{
+		"hasPrefix": func(str, prefix string) bool {
+			return strings.HasPrefix(str, prefix)
+		},
+	}).ParseFiles(templateDir + templateFile)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	if err := tmpl.Execute(&output, templateData); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
 func buildConfigMapObject(spec *ConfigMapSpec) reconciler.Object {
 	// Creates the configMap object
 	configMap := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      spec.Name,
-			Namespace: spec.Namespace,
-			Labels:    spec.Labels,
+			Namespace:   spec.Namespace,
+			Labels:      spec.Labels,
+			Annotations: spec.Annotations,
 		},
 		Data: spec.Data,
 	}
@@ -257,6 +380,79 @@ func buildConfigMapObject(spec *ConfigMapSpec) reconciler.Object {
 	return obj
 }
 
+//////////////////////////////////////////////////////
+///// Handling reconciling operator owned Secrets /////
+//////////////////////////////////////////////////////
+type SecretHandler struct{}
+
+func (h *SecretHandler) Observables(rsrc interface{}, labels map[string]string, dependent []reconciler.Object) []reconciler.Observable {
+	return k8s.NewObservables().
+		WithLabels(labels).
+		For(&corev1.SecretList{}).
+		Get()
+}
+
+// DependentResources returns the secrets referenced by secretConfig in CR
+func (h *SecretHandler) DependentResources(rsrc interface{}) []reconciler.Object {
+	return getSecretConfigDependents(rsrc.(*v1alpha1.CDAPMaster))
+}
+
+func (h *SecretHandler) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+	var expected []reconciler.Object
+	m := rsrc.(*v1alpha1.CDAPMaster)
+	mergedLabelmap := mergeMaps(m.Labels, rsrclabels)
+
+	// Render sensitive configurations into cdap-security.xml, which is mounted along with cdap-site.xml
+	if sconf := getSConfName(m); sconf != "" {
+		data, err := renderSecurityConf(m, dependent)
+		if err != nil {
+			return nil, err
+		}
+		spec := newSecretSpec(m, sconf, mergedLabelmap).AddData(templateCDAPSecurity, data)
+		expected = append(expected, buildSecretObject(spec))
+	}
+
+	// Generate the JMX password and access files if not provided in CR. The secret is created once and never
+	// updated, so that the generated password stays the same across reconciling iterations.
+	if m.Spec.SystemMetricsExporter != nil && m.Spec.SystemMetricsExporter.JMXSecurity != nil &&
+		m.Spec.SystemMetricsExporter.JMXSecurity.PasswordSecret == "" {
+		password, err := reconciler.RandomAlphanumericString(jmxGeneratedPasswordLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JMX password: %w", err)
+		}
+		spec := newSecretSpec(m, getObjName(m, objectNameJMX), mergedLabelmap).
+			AddData(jmxPasswordFileKey, fmt.Sprintf("%s %s\n", jmxGeneratedRole, password)).
+			AddData(jmxAccessFileKey, fmt.Sprintf("%s readonly\n", jmxGeneratedRole))
+		obj := buildSecretObject(spec)
+		reconciler.NoUpdate(&obj, nil)
+		expected = append(expected, obj)
+	}
+	return expected, nil
+}
+
+func buildSecretObject(spec *SecretSpec) reconciler.Object {
+	// Creates the secret object
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spec.Name,
+			Namespace: spec.Namespace,
+			Labels:    spec.Labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: spec.Data,
+	}
+
+	obj := reconciler.Object{
+		Type:      k8s.Type,
+		Lifecycle: reconciler.LifecycleManaged,
+		Obj: &k8s.Object{
+			Obj:     secret.DeepCopyObject().(metav1.Object),
+			ObjList: &corev1.SecretList{},
+		},
+	}
+	return obj
+}
+
 ///////////////////////////////////////////////////////////
 ///// Handling reconciling deployment of all services /////
 ///////////////////////////////////////////////////////////
@@ -271,11 +467,18 @@ func (h *ServiceHandler) Observables(rsrc interface{}, labels map[string]string,
 		Get()
 }
 
-func (h *ServiceHandler) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+// DependentResources returns the secrets referenced by secretConfig in CR, as pods are restarted when they change,
+// and the ConfigMap of the manifest patches
+func (h *ServiceHandler) DependentResources(rsrc interface{}) []reconciler.Object {
+	m := rsrc.(*v1alpha1.CDAPMaster)
+	return append(getSecretConfigDependents(m), getManifestPatchesDependents(m)...)
+}
+
+func (h *ServiceHandler) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	var expected, objs []reconciler.Object
 
 	m := rsrc.(*v1alpha1.CDAPMaster)
-	// Merge in labels (e.g. "using: <handler method name>") added by underlying reconciler-controller library
+	// Merge in labels (e.g. "using: <handler type name>") added by the reconciler for each handler
 	labels := mergeMaps(m.Labels, rsrclabels)
 
 	// Build deployment specification that defines the statefulset, deployment, node port services to be created.
@@ -283,10 +486,24 @@ func (h *ServiceHandler) Objects(rsrc interface{}, rsrclabels map[string]string,
 	if err != nil {
 		return []reconciler.Object{}, err
 	}
+	configHash, err := getConfigHash(m, dependent)
+	if err != nil {
+		return []reconciler.Object{}, err
+	}
+	spec = spec.setConfigHash(configHash)
+	m.Status.JVM = getJVMStatus(spec)
 	objs, err = buildObjectsForDeploymentPlan(spec)
 	if err != nil {
 		return []reconciler.Object{}, err
 	}
+	// Apply the user provided patches for the fields not modeled by CR
+	patches, err := getManifestPatches(m, dependent)
+	if err != nil {
+		return []reconciler.Object{}, err
+	}
+	if err := applyManifestPatches(objs, patches); err != nil {
+		return []reconciler.Object{}, err
+	}
 	expected = append(expected, objs...)
 
 	// Copy NodePort from observed to ensure k8s services' nodePorts stay the same across reconciling iterators
@@ -337,6 +554,40 @@ func CopyNodePortIfAny(expected, observed []reconciler.Object) {
 	}
 }
 
+//////////////////////////////////////////////////////////////////
+///// Handling reconciling cert-manager Certificate for TLS /////
+//////////////////////////////////////////////////////////////////
+type CertificateHandler struct{}
+
+func (h *CertificateHandler) Observables(rsrc interface{}, labels map[string]string, dependent []reconciler.Object) []reconciler.Observable {
+	m := rsrc.(*v1alpha1.CDAPMaster)
+	// Only observe Certificates when requested in CR, as cert-manager may not be installed in the cluster.
+	if m.Spec.TLS == nil || m.Spec.TLS.CertManager == nil {
+		return []reconciler.Observable{}
+	}
+	return k8s.NewObservables().
+		WithLabels(labels).
+		For(newCertificateList()).
+		Get()
+}
+
+func (h *CertificateHandler) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+	m := rsrc.(*v1alpha1.CDAPMaster)
+	if m.Spec.TLS == nil || m.Spec.TLS.CertManager == nil {
+		return []reconciler.Object{}, nil
+	}
+	labels := mergeMaps(m.Labels, rsrclabels)
+	return []reconciler.Object{buildCertificateObject(m, labels)}, nil
+}
+
+// Differs only reports a difference when the Certificate spec changes, as the generic spec comparison
+// does not apply to unstructured objects.
+func (h *CertificateHandler) Differs(expected reconciler.Object, observed reconciler.Object) bool {
+	e := getCertificateSpec(expected.Obj.(*k8s.Object).Obj)
+	o := getCertificateSpec(observed.Obj.(*k8s.Object).Obj)
+	return !reflect.DeepEqual(e, o)
+}
+
 ///////////////////////////////////////////////////////
 ///// Handler for image version upgrade/downgrade /////
 ///////////////////////////////////////////////////////
@@ -349,8 +600,8 @@ func (h *VersionUpdateHandler) Observables(rsrc interface{}, labels map[string]s
 		Get()
 }
 
-func (h *VersionUpdateHandler) Objects(rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+func (h *VersionUpdateHandler) Objects(ctx context.Context, rsrc interface{}, rsrclabels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
 	m := rsrc.(*v1alpha1.CDAPMaster)
 	labels := mergeMaps(m.Labels, rsrclabels)
-	return handleVersionUpdate(m, labels, observed)
+	return handleVersionUpdate(ctx, m, labels, observed)
 }
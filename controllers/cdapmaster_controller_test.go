@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"context"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Mutation failure policy", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+		})
+		// Return the annotations of the ConfigMaps generated by the config map handler, keyed by ConfigMap name
+		getAnnotations := func() map[string]map[string]string {
+			handler := &ConfigMapHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, nil, nil)
+			Expect(err).To(BeNil())
+			annotations := make(map[string]map[string]string)
+			for _, obj := range objs {
+				configMap := obj.Obj.(*k8s.Object).Obj.(*corev1.ConfigMap)
+				annotations[configMap.Name] = configMap.Annotations
+			}
+			return annotations
+		}
+		It("Recorded on the cconf ConfigMap", func() {
+			master.Spec.MutationFailurePolicy = v1alpha1.MutationFailurePolicyIgnore
+			annotations := getAnnotations()
+			Expect(annotations["cdap-test-cconf"]).To(HaveKeyWithValue(annotationMutationFailurePolicy, "Ignore"))
+		})
+		It("Not recorded when unset", func() {
+			annotations := getAnnotations()
+			Expect(annotations["cdap-test-cconf"]).NotTo(HaveKey(annotationMutationFailurePolicy))
+		})
+	})
+})
@@ -1,5 +1,7 @@
 package controllers
 
+import "time"
+
 // ServiceName is the name identifying various CDAP services
 type ServiceName = string
 
@@ -54,6 +56,7 @@ const (
 const (
 	// cconf and hconf
 	confExploreEnabled                    = "explore.enabled"
+	confPreviewEnabled                    = "enable.preview"
 	confLocalDataDirKey                   = "local.data.dir"
 	confLocalDataDirVal                   = "/data"
 	confRouterServerAddress               = "router.server.address"
@@ -64,7 +67,20 @@ const (
 	confTwillSecurityWorkerSecretDiskName = "twill.security.worker.secret.disk.name"
 	confTwillSecurityWorkerSecretDiskPath = "twill.security.worker.secret.disk.path"
 	confJMXServerPort                     = "jmx.metrics.collector.server.port"
+	confJMXServerPasswordFile             = "jmx.metrics.collector.server.password.file"
+	confJMXServerSSLEnabled               = "jmx.metrics.collector.server.ssl.enabled"
 	confSecretMountDefaultMode            = "secret.mount.default.mode"
+	confSSLExternalEnabled                = "ssl.external.enabled"
+	confRouterSSLServerPort               = "router.ssl.server.port"
+	confRouterSSLServerCertPath           = "router.ssl.server.cert.path"
+	confUserInterfaceSSLBindPort          = "dashboard.ssl.bind.port"
+	confUserInterfaceSSLCert              = "dashboard.ssl.cert"
+	confUserInterfaceSSLKey               = "dashboard.ssl.key"
+
+	// Hadoop configurations
+	confHadoopPrefix = "hadoop:"
+	confFSDefaultFS  = "fs.defaultFS"
+	hadoopCoreSite   = "core-site.xml"
 
 	// default values
 	defaultImage                  = "gcr.io/cdapio/cdap:latest"
@@ -73,6 +89,30 @@ const (
 	defaultStorageSize            = "200Gi"
 	defaultSecuritySecretPath     = "/etc/cdap/security"
 	defaultSecretMountDefaultMode = 420
+	defaultTLSMountPath           = "/etc/cdap/tls"
+
+	// TLS key pair entries, following the kubernetes.io/tls secret layout and the cert-manager CombinedPEM output
+	tlsCertKey         = "tls.crt"
+	tlsPrivateKeyKey   = "tls.key"
+	tlsCombinedPEMKey  = "tls-combined.pem"
+	tlsServicePortName = "https"
+
+	// cert-manager
+	certManagerGroup       = "cert-manager.io"
+	certManagerVersion     = "v1"
+	certManagerKind        = "Certificate"
+	certManagerIssuerKind  = "Issuer"
+	certManagerCombinedPEM = "CombinedPEM"
+
+	// HTTPS readiness probes of the router and UI when TLS is enabled
+	routerReadinessProbePath        = "/status"
+	userInterfaceReadinessProbePath = "/"
+
+	// Field manager of the objects applied server-side by the operator
+	fieldManager = "cdap-operator"
+	// Field manager of the objects updated by the operator before server-side apply, which the API server derives
+	// from the name of the operator binary
+	legacyFieldManager = "manager"
 
 	// kubernetes labels
 	labelInstanceKey        = "cdap.instance"
@@ -88,13 +128,26 @@ const (
 	configMapCConf      = "cconf"
 	configMapHConf      = "hconf"
 	configMapSysAppConf = "sysappconf"
+	objectNameTLS       = "tls"
+	secretSConf         = "sconf"
 
 	// yaml template
-	templateDir         = "templates/"
-	templateStatefulSet = "cdap-sts.yaml"
-	templateDeployment  = "cdap-deployment.yaml"
-	templateService     = "cdap-service.yaml"
-	templateUpgradeJob  = "upgrade-job.yaml"
+	templateDir          = "templates/"
+	templateStatefulSet  = "cdap-sts.yaml"
+	templateDeployment   = "cdap-deployment.yaml"
+	templateService      = "cdap-service.yaml"
+	templateUpgradeJob   = "upgrade-job.yaml"
+	templateCDAPSecurity = "cdap-security.xml"
+	templateHadoopSite   = "hadoop-site.xml"
+	templateCDAPSite     = "cdap-site.xml"
+	templateLogback      = "logback.xml"
+	templateLogbackCont  = "logback-container.xml"
+
+	// pod annotations
+	annotationConfigHash = "cdap.io/config-hash"
+	// annotationMutationFailurePolicy records the mutation failure policy of the CDAPMaster on its cconf ConfigMap,
+	// so that the webhook still applies it to pods created while the CDAPMaster is being deleted.
+	annotationMutationFailurePolicy = "cdap.io/mutation-failure-policy"
 
 	// Image version upgrade/downgrade
 	imageVersionLatest = "latest"
@@ -111,6 +164,15 @@ const (
 	javaMinHeapRatio          = float64(0.6)
 	javaReservedNonHeap       = int64(768 * 1024 * 1024)
 	javaMaxHeapSizeEnvVarName = "JAVA_HEAPMAX"
+	// Heap policies and JVM options
+	javaDefaultHeapPercentage        = int32(60)
+	javaContainerAwareHeapPercentage = int32(75)
+	javaMaxRAMPercentageOptFormat    = "-XX:MaxRAMPercentage=%d.0"
+	javaHeapDumpOnOOMOpt             = "-XX:+HeapDumpOnOutOfMemoryError"
+	javaHeapDumpPathOptFormat        = "-XX:HeapDumpPath=%s"
+	// Max heap size of Node.js, in MiB, for the UI
+	nodeOptionsEnvVarName        = "NODE_OPTIONS"
+	nodeMaxOldSpaceSizeOptFormat = "--max-old-space-size=%d"
 
 	// System Metrics sidecar related
 	defaultJMXport     = 11022
@@ -119,8 +181,52 @@ const (
 	// and only requests from localhost can connect to it.
 	// -Djava.rmi.server.hostname=localhost is required for clients to use 127.0.0.1 to connect to rmi server
 	// instead of public IP
-	// TODO(CDAP-18783): Enable SSL and authentication for JMX
+	// SSL and authentication are only enabled when JMXSecurity is set in SystemMetricExporterSpec.
 	jmxServerOptFormat = "-Djava.rmi.server.hostname=localhost -Dcom.sun.management.jmxremote=true -Dcom.sun.management.jmxremote.host=localhost -Dcom.sun.management.jmxremote.port=%s  -Dcom.sun.management.jmxremote.ssl=false  -Dcom.sun.management.jmxremote.authenticate=false"
+	// Secure variant with password authentication. The ssl flags are appended by jmxServerSSLOptFormat or
+	// jmxServerNoSSLOpt.
+	jmxServerSecureOptFormat = "-Djava.rmi.server.hostname=localhost -Dcom.sun.management.jmxremote=true -Dcom.sun.management.jmxremote.host=localhost -Dcom.sun.management.jmxremote.port=%s -Dcom.sun.management.jmxremote.authenticate=true -Dcom.sun.management.jmxremote.password.file=%s -Dcom.sun.management.jmxremote.access.file=%s"
+	jmxServerSSLOptFormat    = "-Dcom.sun.management.jmxremote.ssl=true -Dcom.sun.management.jmxremote.registry.ssl=true -Dcom.sun.management.jmxremote.ssl.config.file=%s"
+	jmxServerNoSSLOpt        = "-Dcom.sun.management.jmxremote.ssl=false"
+	jmxClientSSLOptFormat    = "-Djavax.net.ssl.trustStore=%s"
+
+	// JMX credentials and keystores
+	jmxPasswordFileKey        = "jmxremote.password"
+	jmxAccessFileKey          = "jmxremote.access"
+	jmxSSLConfigFileKey       = "jmxremote.ssl.properties"
+	jmxTrustStoreKey          = "truststore.jks"
+	jmxAuthVolumeName         = "cdap-jmx-auth"
+	jmxAuthSecretVolumeName   = "cdap-jmx-auth-secret"
+	jmxAuthInitContainerName  = "cdap-jmx-auth-init"
+	jmxSSLVolumeName          = "cdap-jmx-ssl"
+	jmxAuthMountPath          = "/etc/cdap/jmx/auth"
+	jmxAuthSecretMountPath    = "/etc/cdap/jmx/auth-secret"
+	jmxSSLMountPath           = "/etc/cdap/jmx/ssl"
+	jmxGeneratedRole          = "cdapmetrics"
+	jmxGeneratedPasswordLen   = 32
+	objectNameJMX             = "jmx"
+	jmxSecretMountDefaultMode = 0444
+	// Copies the JMX password and access files from the secret, given as the first argument, and makes the copies
+	// only readable and writable by the owner
+	jmxAuthInitCommandFormat = "cp %[1]s/jmxremote.password %[1]s/jmxremote.access %[2]s/ && chmod 0600 %[2]s/jmxremote.password %[2]s/jmxremote.access"
+
+	// Logback pattern of the JSON log format, one JSON object per line, with the string values escaped
+	logbackJSONPattern = `{"timestamp":"%d{"yyyy-MM-dd'T'HH:mm:ss.SSSXXX"}","level":"%level","thread":"%replace(%replace(%replace(%replace(%thread){'\\\\', '\\\\\\\\'}){'"', '\\\\"'}){'\r?\n', '\\\\n'}){'\t', '\\\\t'}","logger":"%logger","message":"%replace(%replace(%replace(%replace(%message){'\\\\', '\\\\\\\\'}){'"', '\\\\"'}){'\r?\n', '\\\\n'}){'\t', '\\\\t'}","exception":"%replace(%replace(%replace(%replace(%exception){'\\\\', '\\\\\\\\'}){'"', '\\\\"'}){'\r?\n', '\\\\n'}){'\t', '\\\\t'}"}%n%nopex`
+
+	// Logback file of services with their own log levels, in the cconf volume
+	cconfMountPath             = "/etc/cdap/conf"
+	logbackConfigFileOptFormat = "-Dlogback.configurationFile=%s"
+
+	// Resource recommendations from the usage of the service containers
+	defaultRecommendationRequestPercentile = int32(90)
+	defaultRecommendationLimitPercentile   = int32(99)
+	defaultRecommendationMarginPercentage  = int32(15)
+	defaultRecommendationWindow            = 24 * time.Hour
+	defaultRecommendationMinSamples        = int32(10)
+	defaultMaintenanceWindowDuration       = time.Hour
+	maintenanceWindowStartFormat           = "15:04"
+	// maxUsageSamples bounds the samples kept per container, dropping the oldest ones
+	maxUsageSamples = 10000
 
 	Bytes     = int64(1)
 	kiloBytes = int64(1024)
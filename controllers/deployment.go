@@ -8,13 +8,15 @@ import (
 	"strings"
 
 	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler/manager/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// deploymentPlanner is shared by concurrent reconciles. It is only written by init, and getPlan returns copies.
 var deploymentPlanner *DeploymentPlan
 
 func init() {
@@ -75,7 +77,26 @@ func (d *DeploymentPlan) getPlan(numPods int32) (*ServiceGroups, error) {
 	if !ok {
 		return nil, fmt.Errorf("unsupported deployment plan for NumPods %d", numPods)
 	}
-	return &s, nil
+	return s.deepCopy(), nil
+}
+
+// Return a copy of the service groups, so that callers can't modify the plan
+func (s *ServiceGroups) deepCopy() *ServiceGroups {
+	c := &ServiceGroups{
+		stateful:       make(map[ServiceGroupName]ServiceGroup, len(s.stateful)),
+		deployment:     make(map[ServiceGroupName]ServiceGroup, len(s.deployment)),
+		networkService: make(map[NetworkServiceName]ServiceName, len(s.networkService)),
+	}
+	for name, group := range s.stateful {
+		c.stateful[name] = append(ServiceGroup{}, group...)
+	}
+	for name, group := range s.deployment {
+		c.deployment[name] = append(ServiceGroup{}, group...)
+	}
+	for name, service := range s.networkService {
+		c.networkService[name] = service
+	}
+	return c
 }
 
 // Build deployment plan (e.g. a list of statefulsets, deployments and NodePort services)
@@ -107,8 +128,8 @@ func buildDeploymentPlanSpec(master *v1alpha1.CDAPMaster, labels map[string]stri
 		if err != nil {
 			return nil, err
 		}
-		// stateful could be nil when the list of services are optional and they are disabled in CR
-		// (i.e. service spec is set to nil)
+		// stateful could be nil when the list of services are disabled in CR
+		// (i.e. service spec is set to nil or enabled is false)
 		if stateful == nil {
 			continue
 		}
@@ -123,8 +144,8 @@ func buildDeploymentPlanSpec(master *v1alpha1.CDAPMaster, labels map[string]stri
 		if err != nil {
 			return nil, err
 		}
-		// deploymentSpec could be nil when the list of services are optional and they are disabled in CR
-		// (i.e. service spec is set to nil)
+		// deploymentSpec could be nil when the list of services are disabled in CR
+		// (i.e. service spec is set to nil or enabled is false)
 		if deploymentSpec == nil {
 			continue
 		}
@@ -132,6 +153,11 @@ func buildDeploymentPlanSpec(master *v1alpha1.CDAPMaster, labels map[string]stri
 	}
 	// Build NodePort service
 	for name, targetService := range serviceGroups.networkService {
+		if enabled, err := isServiceEnabled(master, targetService); err != nil {
+			return nil, err
+		} else if !enabled {
+			continue
+		}
 		networkService, err := buildNetworkService(master, name, targetService, labels)
 		if err != nil {
 			return nil, err
@@ -143,6 +169,14 @@ func buildDeploymentPlanSpec(master *v1alpha1.CDAPMaster, labels map[string]stri
 
 // Return a single single-/multi- container StatefulSets containing a list of supplied services
 func buildStatefulSets(master *v1alpha1.CDAPMaster, name string, services ServiceGroup, labels map[string]string, cconf, hconf, sysappconf, dataDir string) (*StatefulSpec, error) {
+	// Disabled services are left out, so that they don't affect the pod-level settings either
+	services, err := getEnabledServices(master, services)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
 	objName := getObjName(master, name)
 	serviceAccount, err := getServiceAccount(master, services)
 	if err != nil {
@@ -160,7 +194,11 @@ func buildStatefulSets(master *v1alpha1.CDAPMaster, name string, services Servic
 	if err != nil {
 		return nil, err
 	}
-	securityContext, err := getSecurityContext(master, services)
+	podSecurityContext, err := getPodSecurityContext(master, services)
+	if err != nil {
+		return nil, err
+	}
+	containerSecurityContext, err := getContainerSecurityContext(master, services)
 	if err != nil {
 		return nil, err
 	}
@@ -172,43 +210,61 @@ func buildStatefulSets(master *v1alpha1.CDAPMaster, name string, services Servic
 	if err != nil {
 		return nil, err
 	}
+	tolerations, err := getTolerations(master, services)
+	if err != nil {
+		return nil, err
+	}
+	imagePullSecrets, err := getImagePullSecrets(master, services)
+	if err != nil {
+		return nil, err
+	}
+	topologySpreadConstraints, err := getTopologySpreadConstraints(master, services)
+	if err != nil {
+		return nil, err
+	}
 
 	defaultMode, err := getSecretMountDefaultMode(master)
 	if err != nil {
 		return nil, err
 	}
+	extraContainersMountConfig, err := getPodExtraContainersMountConfig(master, services)
+	if err != nil {
+		return nil, err
+	}
 
 	spec := newStatefulSpec(master, objName, labels, cconf, hconf, sysappconf).
 		setServiceAccountName(serviceAccount).
 		setNodeSelector(nodeSelector).
 		setRuntimeClassName(runtimeClass).
 		setPriorityClassName(priorityClass).
-		setSecurityContext(securityContext).
+		setPodSecurityContext(podSecurityContext).
+		setContainerSecurityContext(containerSecurityContext).
 		setReplicas(replicas).
 		setAffinity(affinity).
+		setTolerations(tolerations).
+		setImagePullSecrets(imagePullSecrets).
+		setTopologySpreadConstraints(topologySpreadConstraints).
 		setSecretMountDefaultMode(defaultMode)
+	spec = spec.addExtraContainers(master.Spec.ExtraContainers, master.Spec.ExtraInitContainers, extraContainersMountConfig)
 
-	// Add init container
-	spec = spec.withInitContainer(
-		newContainerSpec(master, "StorageInit", dataDir).setArgs(containerStorageMain))
+	// Add init container, which runs with the resources and heap settings of the first service
+	initContainer := newContainerSpec(master, "StorageInit", dataDir).setArgs(containerStorageMain)
+	spec = spec.withInitContainer(initContainer)
 
 	// Add each service as a container
-	for _, s := range services {
+	for i, s := range services {
 		ss, err := getCDAPServiceSpec(master, s)
 		if err != nil {
 			return nil, err
 		}
-		// This happens when the service is optional and disabled in CR
-		// (i.e. service spec is set to nil)
-		if ss == nil {
-			continue
-		}
-
 		c, err := serviceContainerSpec(ss, master, dataDir, s)
 		if err != nil {
 			return nil, err
 		}
 		spec = spec.withContainer(c)
+		if i == 0 {
+			initContainer.setJVMFrom(c)
+		}
 		if err := addSystemMetricsServiceIfEnabled(spec, master, ss, dataDir, c); err != nil {
 			return nil, err
 		}
@@ -229,12 +285,7 @@ func buildStatefulSets(master *v1alpha1.CDAPMaster, name string, services Servic
 		if _, err := spec.addAdditionalVolumeMounts(ss.AdditionalVolumeMounts); err != nil {
 			return nil, err
 		}
-	}
-
-	// All services are optional services and are disabled in CR.
-	// Return nil to indicate no statefulset is built.
-	if len(spec.Containers) == 0 {
-		return nil, nil
+		spec = spec.addExtraContainers(ss.ExtraContainers, ss.ExtraInitContainers, getExtraContainersMountConfig(master, ss))
 	}
 
 	// Get storage class and calculates total disk size required
@@ -254,9 +305,12 @@ func buildStatefulSets(master *v1alpha1.CDAPMaster, name string, services Servic
 // SystemMetricsExporterService if enabled in service spec.
 func addSystemMetricsServiceIfEnabled(stsSpec *StatefulSpec, master *v1alpha1.CDAPMaster,
 	service *v1alpha1.CDAPServiceSpec, dataDir string, mainContainer *ContainerSpec) error {
-	if master.Spec.SystemMetricsExporter == nil || service == nil {
+	if service == nil {
 		return nil
 	}
+	if enabled, err := isServiceEnabled(master, serviceSystemMetricsExporter); err != nil || !enabled {
+		return err
+	}
 	if service.EnableSystemMetrics == nil {
 		return nil
 	}
@@ -272,28 +326,149 @@ func addSystemMetricsServiceIfEnabled(stsSpec *StatefulSpec, master *v1alpha1.CD
 		return err
 	}
 	stsSpec = stsSpec.withContainer(c)
+	// the sidecar may come from a different registry
+	stsSpec.Base.addImagePullSecrets(ss.ImagePullSecrets)
 	// add env variable to start jmx server in the main container
-	varAdded := false
-	varName := javaOptsEnvVarName
-	varValue := fmt.Sprintf(jmxServerOptFormat, master.Spec.Config[confJMXServerPort])
-	// find existing EnvVar with same name
-	for idx, env := range mainContainer.Env {
-		if env.Name != varName {
-			continue
+	mainContainer.appendToEnv(javaOptsEnvVarName, getJMXServerOpts(master))
+
+	// mount the JMX credentials and keystores into all containers, which includes both the main and sidecar containers
+	jmx := master.Spec.SystemMetricsExporter.JMXSecurity
+	if jmx == nil {
+		return nil
+	}
+	if err := addJMXAuthVolume(stsSpec, master, mainContainer.Image); err != nil {
+		return err
+	}
+	if jmx.KeystoreSecret != "" {
+		if err := addJMXSecretVolume(stsSpec, jmxSSLVolumeName, jmx.KeystoreSecret, jmxSSLMountPath); err != nil {
+			return err
 		}
-		mainContainer.Env[idx].Value += " " + varValue
-		varAdded = true
+		// the sidecar connects to the JMX server over SSL
+		c.appendToEnv(javaOptsEnvVarName, fmt.Sprintf(jmxClientSSLOptFormat, jmxSSLMountPath+"/"+jmxTrustStoreKey))
 	}
-	// add new env variable if variable doesn't exist
-	if !varAdded {
-		mainContainer.addEnv(varName, varValue)
-		varAdded = true
+	return nil
+}
+
+// Return the JVM options to start the JMX server in the main container
+func getJMXServerOpts(master *v1alpha1.CDAPMaster) string {
+	port := master.Spec.Config[confJMXServerPort]
+	jmx := master.Spec.SystemMetricsExporter.JMXSecurity
+	if jmx == nil {
+		return fmt.Sprintf(jmxServerOptFormat, port)
+	}
+	opts := fmt.Sprintf(jmxServerSecureOptFormat, port, jmxAuthMountPath+"/"+jmxPasswordFileKey, jmxAuthMountPath+"/"+jmxAccessFileKey)
+	if jmx.KeystoreSecret == "" {
+		return opts + " " + jmxServerNoSSLOpt
+	}
+	return opts + " " + fmt.Sprintf(jmxServerSSLOptFormat, jmxSSLMountPath+"/"+jmxSSLConfigFileKey)
+}
+
+// Return the name of the secret containing the JMX password and access files, either provided in CR or
+// generated by the operator
+func getJMXPasswordSecret(master *v1alpha1.CDAPMaster) string {
+	if s := master.Spec.SystemMetricsExporter.JMXSecurity.PasswordSecret; s != "" {
+		return s
+	}
+	return getObjName(master, objectNameJMX)
+}
+
+// Add the JMX password and access files to the statefulset. The JVM refuses to read a password file that isn't
+// owned by its user or that is accessible by others, which can't be achieved with a secret volume, since secret
+// files are owned by root, or by the fsGroup with group read permission. Hence an init container copies the files
+// from the secret into an in-memory volume, which is owned by the user the containers run as, and restricts the
+// permissions of the copies. The volume is shared by all services in the statefulset with system metrics enabled,
+// so it is only added once.
+func addJMXAuthVolume(spec *StatefulSpec, master *v1alpha1.CDAPMaster, image string) error {
+	for _, v := range spec.Base.AdditionalVolumes {
+		if v.Name == jmxAuthVolumeName {
+			return nil
+		}
+	}
+	secretMode := int32(jmxSecretMountDefaultMode)
+	volumes := []corev1.Volume{
+		{
+			Name: jmxAuthSecretVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  getJMXPasswordSecret(master),
+					DefaultMode: &secretMode,
+				},
+			},
+		},
+		{
+			Name: jmxAuthVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+			},
+		},
+	}
+	if _, err := spec.addAdditionalVolumes(volumes); err != nil {
+		return err
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      jmxAuthVolumeName,
+		MountPath: jmxAuthMountPath,
+		ReadOnly:  true,
+	}
+	if _, err := spec.addAdditionalVolumeMounts([]corev1.VolumeMount{volumeMount}); err != nil {
+		return err
+	}
+	initContainer := corev1.Container{
+		Name:            jmxAuthInitContainerName,
+		Image:           image,
+		ImagePullPolicy: master.Spec.ImagePullPolicy,
+		Command:         []string{"sh", "-c", fmt.Sprintf(jmxAuthInitCommandFormat, jmxAuthSecretMountPath, jmxAuthMountPath)},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: jmxAuthSecretVolumeName, MountPath: jmxAuthSecretMountPath, ReadOnly: true},
+			{Name: jmxAuthVolumeName, MountPath: jmxAuthMountPath},
+		},
+	}
+	spec.Base.ExtraInitContainers = append(spec.Base.ExtraInitContainers, ExtraContainerSpec{Container: initContainer})
+	return nil
+}
+
+// Add a secret volume for JMX to the statefulset. The volume is shared by all services in the statefulset with system
+// metrics enabled, so it is only added once.
+func addJMXSecretVolume(spec *StatefulSpec, volumeName, secretName, mountPath string) error {
+	for _, v := range spec.Base.AdditionalVolumes {
+		if v.Name == volumeName {
+			return nil
+		}
+	}
+	defaultMode := spec.Base.SecretMountDefaultMode
+	volume := corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  secretName,
+				DefaultMode: &defaultMode,
+			},
+		},
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      volumeName,
+		MountPath: mountPath,
+		ReadOnly:  true,
+	}
+	if _, err := spec.addAdditionalVolumes([]corev1.Volume{volume}); err != nil {
+		return err
+	}
+	if _, err := spec.addAdditionalVolumeMounts([]corev1.VolumeMount{volumeMount}); err != nil {
+		return err
 	}
 	return nil
 }
 
 // Return a single single-/multi- container deployment containing a list of supplied services
 func buildDeployment(master *v1alpha1.CDAPMaster, name string, services ServiceGroup, labels map[string]string, cconf, hconf, sysappconf, dataDir string) (*DeploymentSpec, error) {
+	// Disabled services are left out, so that they don't affect the pod-level settings either
+	services, err := getEnabledServices(master, services)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
 	objName := getObjName(master, name)
 	serviceAccount, err := getServiceAccount(master, services)
 	if err != nil {
@@ -315,7 +490,11 @@ func buildDeployment(master *v1alpha1.CDAPMaster, name string, services ServiceG
 	if err != nil {
 		return nil, err
 	}
-	securityContext, err := getSecurityContext(master, services)
+	podSecurityContext, err := getPodSecurityContext(master, services)
+	if err != nil {
+		return nil, err
+	}
+	containerSecurityContext, err := getContainerSecurityContext(master, services)
 	if err != nil {
 		return nil, err
 	}
@@ -323,11 +502,27 @@ func buildDeployment(master *v1alpha1.CDAPMaster, name string, services ServiceG
 	if err != nil {
 		return nil, err
 	}
+	tolerations, err := getTolerations(master, services)
+	if err != nil {
+		return nil, err
+	}
+	imagePullSecrets, err := getImagePullSecrets(master, services)
+	if err != nil {
+		return nil, err
+	}
+	topologySpreadConstraints, err := getTopologySpreadConstraints(master, services)
+	if err != nil {
+		return nil, err
+	}
 
 	defaultMode, err := getSecretMountDefaultMode(master)
 	if err != nil {
 		return nil, err
 	}
+	extraContainersMountConfig, err := getPodExtraContainersMountConfig(master, services)
+	if err != nil {
+		return nil, err
+	}
 
 	spec := newDeploymentSpec(master, objName, labels, cconf, hconf, sysappconf).
 		setServiceAccountName(serviceAccount).
@@ -335,9 +530,14 @@ func buildDeployment(master *v1alpha1.CDAPMaster, name string, services ServiceG
 		setRuntimeClassName(runtimeClass).
 		setPriorityClassName(priorityClass).
 		setReplicas(replicas).
-		setSecurityContext(securityContext).
+		setPodSecurityContext(podSecurityContext).
+		setContainerSecurityContext(containerSecurityContext).
 		setAffinity(affinity).
+		setTolerations(tolerations).
+		setImagePullSecrets(imagePullSecrets).
+		setTopologySpreadConstraints(topologySpreadConstraints).
 		setSecretMountDefaultMode(defaultMode)
+	spec = spec.addExtraContainers(master.Spec.ExtraContainers, master.Spec.ExtraInitContainers, extraContainersMountConfig)
 
 	// Add each service as a container
 	for _, s := range services {
@@ -345,12 +545,6 @@ func buildDeployment(master *v1alpha1.CDAPMaster, name string, services ServiceG
 		if err != nil {
 			return nil, err
 		}
-		// This happens when the service is optional and disabled in CR
-		// (i.e. service spec is set to nil)
-		if ss == nil {
-			continue
-		}
-
 		c, err := serviceContainerSpec(ss, master, dataDir, s)
 		if err != nil {
 			return nil, err
@@ -367,17 +561,16 @@ func buildDeployment(master *v1alpha1.CDAPMaster, name string, services ServiceG
 		if _, err := spec.addSecretVolumes(ss.SecretVolumes); err != nil {
 			return nil, err
 		}
+		if _, err := spec.addSecretVolumes(getTLSSecretVolumes(master, s)); err != nil {
+			return nil, err
+		}
 		if _, err := spec.addAdditionalVolumes(ss.AdditionalVolumes); err != nil {
 			return nil, err
 		}
 		if _, err := spec.addAdditionalVolumeMounts(ss.AdditionalVolumeMounts); err != nil {
 			return nil, err
 		}
-	}
-	// All services are optional services and are disabled in CR.
-	// Return nil to indicate no deployment is built.
-	if len(spec.Containers) == 0 {
-		return nil, nil
+		spec = spec.addExtraContainers(ss.ExtraContainers, ss.ExtraInitContainers, getExtraContainersMountConfig(master, ss))
 	}
 	return spec, nil
 }
@@ -426,11 +619,23 @@ func serviceContainerSpec(ss *v1alpha1.CDAPServiceSpec,
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge env vars for service %q with error: %v", service, err)
 	}
-	env = addJavaMaxHeapEnvIfNotPresent(env, ss.Resources)
-	c := newContainerSpec(master, service, dataDir).setResources(ss.Resources).setEnv(env).setLifecycle(ss.Lifecycle)
+	// Sources in service spec come after the ones in master spec, so that they take precedence on duplicate keys
+	var envFrom []corev1.EnvFromSource
+	envFrom = append(envFrom, master.Spec.EnvFrom...)
+	envFrom = append(envFrom, ss.EnvFrom...)
+	c := newContainerSpec(master, service, dataDir).setResources(ss.Resources).setEnv(env).setEnvFrom(envFrom).setLifecycle(ss.Lifecycle)
+	if err := setJVMForContainer(c, master, ss, service); err != nil {
+		return nil, err
+	}
 	if service == serviceUserInterface {
 		c = updateSpecForUserInterface(master, c)
 	}
+	if probe := getHTTPSReadinessProbe(master, service); probe != nil {
+		c = c.setReadinessProbe(probe)
+	}
+	if service != serviceUserInterface && len(ss.LogLevels) > 0 {
+		c = c.appendToEnv(javaOptsEnvVarName, fmt.Sprintf(logbackConfigFileOptFormat, cconfMountPath+"/"+getServiceLogbackFile(service)))
+	}
 	return c, nil
 }
 
@@ -468,7 +673,7 @@ func buildStatefulSetsObject(spec *StatefulSpec) (*reconciler.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	// For container lifecycle hook, custom volumes, and custom volume mounts, we directly pass structs from the spec to bypass the YAML templating logic.
+	// For security context, container env, lifecycle hook, readiness probe, custom volumes, and custom volume mounts, we directly pass structs from the spec to bypass the YAML templating logic.
 	k8sObj, ok := obj.Obj.(*k8s.Object)
 	if !ok {
 		return nil, fmt.Errorf("failed to convert object to k8s object")
@@ -481,19 +686,29 @@ func buildStatefulSetsObject(spec *StatefulSpec) (*reconciler.Object, error) {
 		return nil, err
 	}
 
-	// Set Affinity for pod spec.
+	// Set Affinity, tolerations, image pull secrets and topology spread constraints for pod spec.
 	statefulSetObj.Spec.Template.Spec.Affinity = spec.Base.Affinity
+	statefulSetObj.Spec.Template.Spec.Tolerations = spec.Base.Tolerations
+	statefulSetObj.Spec.Template.Spec.ImagePullSecrets = spec.Base.ImagePullSecrets
+	statefulSetObj.Spec.Template.Spec.TopologySpreadConstraints = getPodTopologySpreadConstraints(spec.Base, statefulSetObj.Spec.Selector)
+	setSecurityContextForPod(&statefulSetObj.Spec.Template.Spec, spec.Base.PodSecurityContext, spec.Base.ContainerSecurityContext)
 
 	for index, _ := range statefulSetObj.Spec.Template.Spec.InitContainers {
 		if err := addVolumeMountToContainer(&statefulSetObj.Spec.Template.Spec.InitContainers[index], spec.Base.AdditionalVolumeMounts); err != nil {
 			return nil, err
 		}
+		setEnvForContainer(&statefulSetObj.Spec.Template.Spec.InitContainers[index], spec.InitContainers[index])
 	}
 	for index, _ := range statefulSetObj.Spec.Template.Spec.Containers {
 		if err := addVolumeMountToContainer(&statefulSetObj.Spec.Template.Spec.Containers[index], spec.Base.AdditionalVolumeMounts); err != nil {
 			return nil, err
 		}
+		setEnvForContainer(&statefulSetObj.Spec.Template.Spec.Containers[index], spec.Containers[index])
 		setLifecycleHookForContainer(&statefulSetObj.Spec.Template.Spec.Containers[index], spec.Containers[index].Lifecycle)
+		statefulSetObj.Spec.Template.Spec.Containers[index].ReadinessProbe = spec.Containers[index].ReadinessProbe
+	}
+	if err := addExtraContainersToPodSpec(&statefulSetObj.Spec.Template.Spec, spec.Base); err != nil {
+		return nil, err
 	}
 	return obj, nil
 }
@@ -504,7 +719,7 @@ func buildDeploymentObject(spec *DeploymentSpec) (*reconciler.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	// For container lifecycle hook, custom volumes, and volume mounts, we directly pass structs from the spec to bypass the YAML templating logic.
+	// For security context, container env, lifecycle hook, readiness probe, custom volumes, and volume mounts, we directly pass structs from the spec to bypass the YAML templating logic.
 	k8sObj, ok := obj.Obj.(*k8s.Object)
 	if !ok {
 		return nil, fmt.Errorf("failed to convert object to k8s object")
@@ -516,22 +731,127 @@ func buildDeploymentObject(spec *DeploymentSpec) (*reconciler.Object, error) {
 	if err := addVolumeToPodSpec(&deploymentObj.Spec.Template.Spec, spec.Base.AdditionalVolumes); err != nil {
 		return nil, err
 	}
-	// Set Affinity for pod spec.
+	// Set Affinity, tolerations, image pull secrets and topology spread constraints for pod spec.
 	deploymentObj.Spec.Template.Spec.Affinity = spec.Base.Affinity
+	deploymentObj.Spec.Template.Spec.Tolerations = spec.Base.Tolerations
+	deploymentObj.Spec.Template.Spec.ImagePullSecrets = spec.Base.ImagePullSecrets
+	deploymentObj.Spec.Template.Spec.TopologySpreadConstraints = getPodTopologySpreadConstraints(spec.Base, deploymentObj.Spec.Selector)
+	setSecurityContextForPod(&deploymentObj.Spec.Template.Spec, spec.Base.PodSecurityContext, spec.Base.ContainerSecurityContext)
 	for index, _ := range deploymentObj.Spec.Template.Spec.InitContainers {
 		if err := addVolumeMountToContainer(&deploymentObj.Spec.Template.Spec.InitContainers[index], spec.Base.AdditionalVolumeMounts); err != nil {
 			return nil, err
 		}
+		setEnvForContainer(&deploymentObj.Spec.Template.Spec.InitContainers[index], spec.InitContainers[index])
 	}
 	for index, _ := range deploymentObj.Spec.Template.Spec.Containers {
 		if err := addVolumeMountToContainer(&deploymentObj.Spec.Template.Spec.Containers[index], spec.Base.AdditionalVolumeMounts); err != nil {
 			return nil, err
 		}
+		setEnvForContainer(&deploymentObj.Spec.Template.Spec.Containers[index], spec.Containers[index])
 		setLifecycleHookForContainer(&deploymentObj.Spec.Template.Spec.Containers[index], spec.Containers[index].Lifecycle)
+		deploymentObj.Spec.Template.Spec.Containers[index].ReadinessProbe = spec.Containers[index].ReadinessProbe
+	}
+	if err := addExtraContainersToPodSpec(&deploymentObj.Spec.Template.Spec, spec.Base); err != nil {
+		return nil, err
 	}
 	return obj, nil
 }
 
+// configVolumes are the names of the CDAP configuration volumes in the pod templates, which are shared with the extra
+// containers on request
+var configVolumes = map[string]bool{
+	"podinfo":         true,
+	"cdap-conf":       true,
+	"hadoop-conf":     true,
+	"cdap-sysappconf": true,
+	"cdap-security":   true,
+}
+
+// Return whether the CDAP configuration volumes are mounted into the extra containers of the service
+func getExtraContainersMountConfig(master *v1alpha1.CDAPMaster, ss *v1alpha1.CDAPServiceSpec) bool {
+	if ss.ExtraContainersMountConfig != nil {
+		return *ss.ExtraContainersMountConfig
+	}
+	return master.Spec.ExtraContainersMountConfig
+}
+
+// Return whether the CDAP configuration volumes are mounted into the extra containers of CR, which are added once to
+// each pod. The override of the services in the pod takes precedence, it must be the same for all of them.
+func getPodExtraContainersMountConfig(master *v1alpha1.CDAPMaster, services ServiceGroup) (bool, error) {
+	val, err := getFieldValueIfUnique(master, services, "ExtraContainersMountConfig")
+	if err != nil {
+		return false, err
+	}
+	if mountConfig, ok := val.(bool); ok {
+		return mountConfig, nil
+	}
+	return master.Spec.ExtraContainersMountConfig, nil
+}
+
+// Append the extra containers and init containers to the pod spec. This is done after the volume mounts and security
+// context are set for the generated containers, so that the extra containers are added as supplied, except for:
+// - the CDAP configuration volumes, which are mounted at the same paths as in the service containers if requested
+// - the container security context, which is set for extra containers that don't have one
+func addExtraContainersToPodSpec(podSpec *corev1.PodSpec, spec *BaseSpec) error {
+	names := make(map[string]bool)
+	for _, c := range podSpec.InitContainers {
+		names[c.Name] = true
+	}
+	for _, c := range podSpec.Containers {
+		names[c.Name] = true
+	}
+	var configVolumeMounts []corev1.VolumeMount
+	if len(podSpec.Containers) > 0 {
+		for _, m := range podSpec.Containers[0].VolumeMounts {
+			if configVolumes[m.Name] {
+				configVolumeMounts = append(configVolumeMounts, m)
+			}
+		}
+	}
+	build := func(extra ExtraContainerSpec) (corev1.Container, error) {
+		c := *extra.Container.DeepCopy()
+		if names[c.Name] {
+			return c, fmt.Errorf("failed to add extra container %q: container name already exists in pod %q", c.Name, spec.Name)
+		}
+		names[c.Name] = true
+		if extra.MountConfig {
+			for _, m := range configVolumeMounts {
+				if !hasVolumeMount(&c, m.Name) {
+					c.VolumeMounts = append(c.VolumeMounts, m)
+				}
+			}
+		}
+		if c.SecurityContext == nil {
+			c.SecurityContext = spec.ContainerSecurityContext.DeepCopy()
+		}
+		return c, nil
+	}
+	for _, extra := range spec.ExtraInitContainers {
+		c, err := build(extra)
+		if err != nil {
+			return err
+		}
+		podSpec.InitContainers = append(podSpec.InitContainers, c)
+	}
+	for _, extra := range spec.ExtraContainers {
+		c, err := build(extra)
+		if err != nil {
+			return err
+		}
+		podSpec.Containers = append(podSpec.Containers, c)
+	}
+	return nil
+}
+
+func hasVolumeMount(container *corev1.Container, name string) bool {
+	for _, m := range container.VolumeMounts {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func addVolumeToPodSpec(podSpec *corev1.PodSpec, volumesToAdd []corev1.Volume) error {
 	for _, volumeToAdd := range volumesToAdd {
 		for _, volume := range podSpec.Volumes {
@@ -556,6 +876,12 @@ func addVolumeMountToContainer(container *corev1.Container, volumeMountsToAdd []
 	return nil
 }
 
+// Set env vars as structs, so that valueFrom and envFrom sources are preserved
+func setEnvForContainer(container *corev1.Container, spec *ContainerSpec) {
+	container.Env = spec.Env
+	container.EnvFrom = spec.EnvFrom
+}
+
 func setLifecycleHookForContainer(container *corev1.Container, lifecycle *corev1.Lifecycle) {
 	container.Lifecycle = lifecycle
 }
@@ -567,8 +893,12 @@ func buildNetworkService(master *v1alpha1.CDAPMaster, name NetworkServiceName, t
 		return nil, err
 	}
 	objName := getObjName(master, name)
-	return newNetworkServiceSpec(objName, labels, s.Annotations, s.ServiceType, s.LoadBalancerIP, s.ServicePort, master).
-		addSelector(labelContainerKeyPrefix+target, master.Name), nil
+	spec := newNetworkServiceSpec(objName, labels, s.Annotations, s.ServiceType, s.LoadBalancerIP, s.ServicePort, master).
+		addSelector(labelContainerKeyPrefix+target, master.Name)
+	if master.Spec.TLS != nil {
+		spec = spec.setPortName(tlsServicePortName).setAppProtocol(tlsServicePortName)
+	}
+	return spec, nil
 }
 
 // Return a reconciler NodePort service object for the given network service spec
@@ -716,6 +1046,69 @@ func getAffinity(master *v1alpha1.CDAPMaster, services ServiceGroup) (*corev1.Af
 	return nil, fmt.Errorf("unable to cast value of type %T into Affinity", val)
 }
 
+// Return the tolerations if all the supplied services have the same tolerations or nil. Otherwise return an error.
+func getTolerations(master *v1alpha1.CDAPMaster, services ServiceGroup) ([]corev1.Toleration, error) {
+	val, err := getFieldValueIfUnique(master, services, "Tolerations")
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+	if tolerations, ok := val.([]corev1.Toleration); ok {
+		return tolerations, nil
+	}
+	return nil, fmt.Errorf("unable to cast value of type %T into Tolerations", val)
+}
+
+// Return the image pull secrets if all the supplied services have the same setting, or the ones in master spec if none
+// of them is set. Otherwise return an error.
+func getImagePullSecrets(master *v1alpha1.CDAPMaster, services ServiceGroup) ([]corev1.LocalObjectReference, error) {
+	val, err := getFieldValueIfUnique(master, services, "ImagePullSecrets")
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return master.Spec.ImagePullSecrets, nil
+	}
+	if secrets, ok := val.([]corev1.LocalObjectReference); ok {
+		return secrets, nil
+	}
+	return nil, fmt.Errorf("unable to cast value of type %T into ImagePullSecrets", val)
+}
+
+// Return the topology spread constraints if all the supplied services have the same constraints or nil. Otherwise
+// return an error.
+func getTopologySpreadConstraints(master *v1alpha1.CDAPMaster, services ServiceGroup) ([]corev1.TopologySpreadConstraint, error) {
+	val, err := getFieldValueIfUnique(master, services, "TopologySpreadConstraints")
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+	if constraints, ok := val.([]corev1.TopologySpreadConstraint); ok {
+		return constraints, nil
+	}
+	return nil, fmt.Errorf("unable to cast value of type %T into TopologySpreadConstraints", val)
+}
+
+// Return the topology spread constraints for the pods selected by the given selector. Unless set in CR, pods with more
+// than one replica are spread across zones on a best effort basis, so that losing a zone doesn't take down a service.
+func getPodTopologySpreadConstraints(spec *BaseSpec, selector *metav1.LabelSelector) []corev1.TopologySpreadConstraint {
+	if len(spec.TopologySpreadConstraints) > 0 || spec.Replicas <= 1 {
+		return spec.TopologySpreadConstraints
+	}
+	return []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     selector.DeepCopy(),
+		},
+	}
+}
+
 // Return the default secret mount permissions.
 func getSecretMountDefaultMode(master *v1alpha1.CDAPMaster) (int32, error) {
 	val, ok := master.Spec.Config[confSecretMountDefaultMode]
@@ -814,7 +1207,7 @@ func getFieldValueIfUnique(master *v1alpha1.CDAPMaster, services ServiceGroup, f
 			fieldVal = fieldVal.Elem()
 		}
 		// Skip if empty
-		if fieldVal.Kind() == reflect.String && fieldVal.Len() == 0 {
+		if (fieldVal.Kind() == reflect.String || fieldVal.Kind() == reflect.Slice) && fieldVal.Len() == 0 {
 			continue
 		}
 		values = append(values, fieldVal.Interface())
@@ -841,32 +1234,3 @@ func updateSpecForUserInterface(master *v1alpha1.CDAPMaster, spec *ContainerSpec
 		setArgs("index.js", "start").
 		addEnv("NODE_ENV", "production")
 }
-
-// Derive from memory resource requirements and add java max heap size to the supplied env var array if not present
-func addJavaMaxHeapEnvIfNotPresent(env []corev1.EnvVar, resources *corev1.ResourceRequirements) []corev1.EnvVar {
-	if resources == nil {
-		return env
-	}
-
-	// Nothing to set if already present
-	hasMaxHeap := false
-	for _, e := range env {
-		if e.Name == javaMaxHeapSizeEnvVarName {
-			hasMaxHeap = true
-		}
-	}
-	if hasMaxHeap {
-		return env
-	}
-
-	// Derive from memory resource requirement
-	memory := max(resources.Requests.Memory().Value(), resources.Limits.Memory().Value())
-	if memory > 0 {
-		xmx := max(memory-javaReservedNonHeap, int64(float64(memory)*javaMinHeapRatio))
-		env = append(env, corev1.EnvVar{
-			Name:  javaMaxHeapSizeEnvVarName,
-			Value: fmt.Sprintf("-Xmx%v", xmx),
-		})
-	}
-	return env
-}
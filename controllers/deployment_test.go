@@ -1,21 +1,25 @@
 package controllers
 
 import (
+	"context"
+
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"testing"
 
 	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/nsf/jsondiff"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler/manager/k8s"
 )
 
 func fromJson(filename string, obj interface{}) error {
@@ -154,6 +158,688 @@ var _ = Describe("Controller Suite", func() {
 		})
 	})
 
+	Describe("TLS for router and UI", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.TLS = &v1alpha1.TLSSpec{
+				CertManager: &v1alpha1.CertManagerSpec{
+					IssuerRef: v1alpha1.CertManagerIssuerRef{Name: "issuer", Kind: "ClusterIssuer"},
+					DNSNames:  []string{"cdap.example.com"},
+				},
+			}
+			ApplyDefaults(master)
+		})
+		It("cdap-site properties set for TLS", func() {
+			Expect(master.Spec.TLS.SecretName).To(Equal("cdap-test-tls"))
+			config := getSiteConfig(master)
+			Expect(config[confSSLExternalEnabled]).To(Equal("true"))
+			Expect(config[confRouterSSLServerPort]).To(Equal(fmt.Sprint(*master.Spec.Router.ServicePort)))
+			Expect(config[confUserInterfaceSSLBindPort]).To(Equal(fmt.Sprint(*master.Spec.UserInterface.ServicePort)))
+			Expect(config[confRouterSSLServerCertPath]).To(Equal("/etc/cdap/tls/tls-combined.pem"))
+			Expect(config[confUserInterfaceSSLCert]).To(Equal("/etc/cdap/tls/tls.crt"))
+			Expect(config[confUserInterfaceSSLKey]).To(Equal("/etc/cdap/tls/tls.key"))
+		})
+		It("cdap-site properties for TLS removed when TLS is turned off", func() {
+			master.Spec.TLS = nil
+			ApplyDefaults(master)
+			config := getSiteConfig(master)
+			for _, key := range []string{confSSLExternalEnabled, confRouterSSLServerPort, confUserInterfaceSSLBindPort,
+				confRouterSSLServerCertPath, confUserInterfaceSSLCert, confUserInterfaceSSLKey} {
+				Expect(config).NotTo(HaveKey(key))
+				Expect(master.Spec.Config).NotTo(HaveKey(key))
+			}
+		})
+		It("key pair mounted and HTTPS used by router and UI only", func() {
+			emptyLabels := make(map[string]string)
+			spec, err := buildDeploymentPlanSpec(master, emptyLabels)
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+
+			tlsVolume := "cdap-se-vol-" + master.Spec.TLS.SecretName
+			hasTLSVolume := func(podSpec corev1.PodSpec) bool {
+				for _, v := range podSpec.Volumes {
+					if v.Name == tlsVolume {
+						return true
+					}
+				}
+				return false
+			}
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					tlsEnabled := o.Name == getObjName(master, "router") || o.Name == getObjName(master, "userinterface")
+					Expect(hasTLSVolume(o.Spec.Template.Spec)).To(Equal(tlsEnabled), o.Name)
+					probe := o.Spec.Template.Spec.Containers[0].ReadinessProbe
+					if tlsEnabled {
+						Expect(probe).NotTo(BeNil())
+						Expect(probe.HTTPGet.Scheme).To(Equal(corev1.URISchemeHTTPS))
+					} else {
+						Expect(probe).To(BeNil())
+					}
+				case *appsv1.StatefulSet:
+					Expect(hasTLSVolume(o.Spec.Template.Spec)).To(BeFalse(), o.Name)
+				case *corev1.Service:
+					Expect(o.Spec.Ports[0].Name).To(Equal(tlsServicePortName))
+					Expect(*o.Spec.Ports[0].AppProtocol).To(Equal(tlsServicePortName))
+				}
+			}
+		})
+		It("cert-manager certificate requested", func() {
+			obj := buildCertificateObject(master, map[string]string{})
+			certificate := obj.Obj.(*k8s.Object).Obj
+			Expect(certificate.GetName()).To(Equal("cdap-test-tls"))
+			spec, ok := getCertificateSpec(certificate).(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(spec["secretName"]).To(Equal("cdap-test-tls"))
+			Expect(spec["issuerRef"]).To(Equal(map[string]interface{}{
+				"name": "issuer", "kind": "ClusterIssuer", "group": certManagerGroup,
+			}))
+			Expect(spec["dnsNames"]).To(ContainElements("cdap-test-router.default.svc", "cdap-test-userinterface", "cdap.example.com"))
+		})
+	})
+
+	Describe("Service enablement", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+		})
+		// Return the names of the statefulsets, deployments and k8s services built for the CR
+		getObjNames := func() []string {
+			spec, err := buildDeploymentPlanSpec(master, map[string]string{})
+			Expect(err).To(BeNil())
+			var names []string
+			for _, s := range spec.Stateful {
+				names = append(names, s.Base.Name)
+			}
+			for _, s := range spec.Deployment {
+				names = append(names, s.Base.Name)
+			}
+			for _, s := range spec.NetworkServices {
+				names = append(names, "service/"+s.Name)
+			}
+			return names
+		}
+		It("Core services deployed by default", func() {
+			ApplyDefaults(master)
+			Expect(getObjNames()).To(ContainElements("cdap-test-preview", "cdap-test-router", "service/cdap-test-router"))
+			Expect(getSiteConfig(master)[confPreviewEnabled]).To(Equal("true"))
+		})
+		It("Disabled core service not deployed and cdap-site toggle synced", func() {
+			master.Spec.Preview.Enabled = boolPtr(false)
+			ApplyDefaults(master)
+			Expect(getObjNames()).NotTo(ContainElement("cdap-test-preview"))
+			Expect(getSiteConfig(master)[confPreviewEnabled]).To(Equal("false"))
+			Expect(master.Spec.Config[confPreviewEnabled]).To(Equal("true"))
+
+			// Deployed again once enabled is unset
+			master.Spec.Preview.Enabled = nil
+			ApplyDefaults(master)
+			Expect(getObjNames()).To(ContainElement("cdap-test-preview"))
+			Expect(getSiteConfig(master)[confPreviewEnabled]).To(Equal("true"))
+		})
+		It("cdap-site toggle disables service when enabled is not set", func() {
+			master.Spec.Config[confPreviewEnabled] = "false"
+			ApplyDefaults(master)
+			Expect(getObjNames()).NotTo(ContainElement("cdap-test-preview"))
+			Expect(getSiteConfig(master)[confPreviewEnabled]).To(Equal("false"))
+		})
+		It("Enabled takes precedence over cdap-site toggle", func() {
+			master.Spec.Config[confPreviewEnabled] = "false"
+			master.Spec.Preview.Enabled = boolPtr(true)
+			ApplyDefaults(master)
+			Expect(getObjNames()).To(ContainElement("cdap-test-preview"))
+			Expect(getSiteConfig(master)[confPreviewEnabled]).To(Equal("true"))
+		})
+		It("Disabled router has neither deployment nor k8s service", func() {
+			master.Spec.Router.Enabled = boolPtr(false)
+			names := getObjNames()
+			Expect(names).NotTo(ContainElement("cdap-test-router"))
+			Expect(names).NotTo(ContainElement("service/cdap-test-router"))
+			Expect(names).To(ContainElement("service/cdap-test-userinterface"))
+		})
+		It("Optional service disabled with its specification set", func() {
+			Expect(master.Spec.Runtime).NotTo(BeNil())
+			master.Spec.Runtime.Enabled = boolPtr(false)
+			Expect(getObjNames()).NotTo(ContainElement("cdap-test-runtime"))
+		})
+		It("System metrics sidecar not added when exporter is disabled", func() {
+			master.Spec.SystemMetricsExporter.Enabled = boolPtr(false)
+			spec, err := buildStatefulSets(master, "runtime", ServiceGroup{serviceRuntime}, map[string]string{}, "cconf", "hconf", "sysappconf", "/data")
+			Expect(err).To(BeNil())
+			Expect(spec.Containers).To(HaveLen(1))
+		})
+		It("Init containers of deployments get the env of their spec like statefulset ones", func() {
+			env := []corev1.EnvVar{
+				{Name: "PLAIN", Value: "value"},
+				{Name: "FROM_SECRET", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "secret"}, Key: "key"}}},
+			}
+			envFrom := []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env"}}}}
+			spec, err := buildDeployment(master, "router", ServiceGroup{serviceRouter}, map[string]string{}, "cconf", "hconf", "sysappconf", "/data")
+			Expect(err).To(BeNil())
+			spec = spec.withInitContainer(newContainerSpec(master, "Init", "/data").setEnv(env).setEnvFrom(envFrom))
+			obj, err := buildDeploymentObject(spec)
+			Expect(err).To(BeNil())
+			initContainers := obj.Obj.(*k8s.Object).Obj.(*appsv1.Deployment).Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Env).To(Equal(env))
+			Expect(initContainers[0].EnvFrom).To(Equal(envFrom))
+		})
+	})
+
+	Describe("Secure JMX for system metrics exporter", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+		})
+		getRuntimeStatefulSet := func() *appsv1.StatefulSet {
+			spec, err := buildStatefulSets(master, "runtime", ServiceGroup{serviceRuntime}, map[string]string{}, "cconf", "hconf", "sysappconf", "/data")
+			Expect(err).To(BeNil())
+			obj, err := buildStatefulSetsObject(spec)
+			Expect(err).To(BeNil())
+			return obj.Obj.(*k8s.Object).Obj.(*appsv1.StatefulSet)
+		}
+		getEnvValue := func(c corev1.Container, name string) string {
+			for _, e := range c.Env {
+				if e.Name == name {
+					return e.Value
+				}
+			}
+			return ""
+		}
+		hasVolumeMount := func(c corev1.Container, name string) bool {
+			for _, m := range c.VolumeMounts {
+				if m.Name == name {
+					return true
+				}
+			}
+			return false
+		}
+		It("JMX without authentication and SSL by default", func() {
+			sts := getRuntimeStatefulSet()
+			Expect(getEnvValue(sts.Spec.Template.Spec.Containers[0], javaOptsEnvVarName)).To(ContainSubstring("jmxremote.authenticate=false"))
+			Expect(getEnvValue(sts.Spec.Template.Spec.Containers[1], javaOptsEnvVarName)).To(BeEmpty())
+		})
+		It("JMX with generated password and SSL", func() {
+			master.Spec.SystemMetricsExporter.JMXSecurity = &v1alpha1.JMXSecuritySpec{KeystoreSecret: "jmx-keystore"}
+			ApplyDefaults(master)
+			config := getSiteConfig(master)
+			Expect(config[confJMXServerSSLEnabled]).To(Equal("true"))
+			Expect(config[confJMXServerPasswordFile]).To(Equal("/etc/cdap/jmx/auth/jmxremote.password"))
+
+			// Removed from cdap-site.xml with the JMX security
+			master.Spec.SystemMetricsExporter.JMXSecurity = nil
+			ApplyDefaults(master)
+			config = getSiteConfig(master)
+			Expect(config).NotTo(HaveKey(confJMXServerSSLEnabled))
+			Expect(config).NotTo(HaveKey(confJMXServerPasswordFile))
+			master.Spec.SystemMetricsExporter.JMXSecurity = &v1alpha1.JMXSecuritySpec{KeystoreSecret: "jmx-keystore"}
+
+			sts := getRuntimeStatefulSet()
+			mainContainer, sidecar := sts.Spec.Template.Spec.Containers[0], sts.Spec.Template.Spec.Containers[1]
+			opts := getEnvValue(mainContainer, javaOptsEnvVarName)
+			Expect(opts).To(ContainSubstring("-Dcom.sun.management.jmxremote.authenticate=true"))
+			Expect(opts).To(ContainSubstring("-Dcom.sun.management.jmxremote.password.file=/etc/cdap/jmx/auth/jmxremote.password"))
+			Expect(opts).To(ContainSubstring("-Dcom.sun.management.jmxremote.ssl=true"))
+			Expect(opts).To(ContainSubstring("-Dcom.sun.management.jmxremote.ssl.config.file=/etc/cdap/jmx/ssl/jmxremote.ssl.properties"))
+			Expect(getEnvValue(sidecar, javaOptsEnvVarName)).To(Equal("-Djavax.net.ssl.trustStore=/etc/cdap/jmx/ssl/truststore.jks"))
+			for _, c := range []corev1.Container{mainContainer, sidecar} {
+				Expect(hasVolumeMount(c, jmxAuthVolumeName)).To(BeTrue())
+				Expect(hasVolumeMount(c, jmxSSLVolumeName)).To(BeTrue())
+			}
+			// The password file is copied from the secret by an init container, so it is owned by the user of the JVM
+			for _, v := range sts.Spec.Template.Spec.Volumes {
+				switch v.Name {
+				case jmxAuthSecretVolumeName:
+					Expect(v.Secret.SecretName).To(Equal("cdap-test-jmx"))
+				case jmxAuthVolumeName:
+					Expect(v.EmptyDir).NotTo(BeNil())
+				}
+			}
+			Expect(hasVolumeMount(mainContainer, jmxAuthSecretVolumeName)).To(BeFalse())
+			initContainers := sts.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(2))
+			initContainer := initContainers[1]
+			Expect(initContainer.Name).To(Equal(jmxAuthInitContainerName))
+			Expect(initContainer.Image).To(Equal(mainContainer.Image))
+			Expect(initContainer.Command[2]).To(Equal("cp /etc/cdap/jmx/auth-secret/jmxremote.password /etc/cdap/jmx/auth-secret/jmxremote.access /etc/cdap/jmx/auth/ && " +
+				"chmod 0600 /etc/cdap/jmx/auth/jmxremote.password /etc/cdap/jmx/auth/jmxremote.access"))
+			Expect(initContainer.VolumeMounts).To(ConsistOf(
+				corev1.VolumeMount{Name: jmxAuthSecretVolumeName, MountPath: "/etc/cdap/jmx/auth-secret", ReadOnly: true},
+				corev1.VolumeMount{Name: jmxAuthVolumeName, MountPath: "/etc/cdap/jmx/auth"},
+			))
+			Expect(initContainer.SecurityContext).To(Equal(mainContainer.SecurityContext))
+
+			handler := &SecretHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].Lifecycle).To(Equal(reconciler.LifecycleNoUpdate))
+			secret := objs[0].Obj.(*k8s.Object).Obj.(*corev1.Secret)
+			Expect(secret.Name).To(Equal("cdap-test-jmx"))
+			Expect(string(secret.Data[jmxPasswordFileKey])).To(HavePrefix(jmxGeneratedRole + " "))
+			Expect(string(secret.Data[jmxAccessFileKey])).To(Equal(jmxGeneratedRole + " readonly\n"))
+		})
+		It("JMX with provided password secret and without SSL", func() {
+			master.Spec.SystemMetricsExporter.JMXSecurity = &v1alpha1.JMXSecuritySpec{PasswordSecret: "jmx-password"}
+			ApplyDefaults(master)
+			Expect(getSiteConfig(master)[confJMXServerSSLEnabled]).To(Equal("false"))
+
+			sts := getRuntimeStatefulSet()
+			opts := getEnvValue(sts.Spec.Template.Spec.Containers[0], javaOptsEnvVarName)
+			Expect(opts).To(ContainSubstring("-Dcom.sun.management.jmxremote.authenticate=true"))
+			Expect(opts).To(HaveSuffix("-Dcom.sun.management.jmxremote.ssl=false"))
+			Expect(hasVolumeMount(sts.Spec.Template.Spec.Containers[1], jmxSSLVolumeName)).To(BeFalse())
+
+			handler := &SecretHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(objs).To(BeEmpty())
+		})
+	})
+
+	Describe("Env vars from sources", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.Env = append(master.Spec.Env, corev1.EnvVar{
+				Name: "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"},
+				},
+			})
+			master.Spec.EnvFrom = []corev1.EnvFromSource{
+				{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "common"}}},
+			}
+			master.Spec.AppFabric.Env = append(master.Spec.AppFabric.Env, corev1.EnvVar{
+				Name: "DB_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+						Key:                  "password",
+					},
+				},
+			})
+			master.Spec.AppFabric.EnvFrom = []corev1.EnvFromSource{
+				{Prefix: "APP_", SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "appfabric"}}},
+			}
+		})
+		It("valueFrom and envFrom preserved in containers", func() {
+			emptyLabels := make(map[string]string)
+			spec, err := buildDeploymentPlanSpec(master, emptyLabels)
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+
+			podIP := corev1.EnvVar{
+				Name:      "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}},
+			}
+			checkContainer := func(name string, container corev1.Container) {
+				Expect(container.Env).To(ContainElement(podIP), name)
+				Expect(container.EnvFrom[0].ConfigMapRef.Name).To(Equal("common"), name)
+				if name != getObjName(master, "appfabric") {
+					Expect(container.EnvFrom).To(HaveLen(1), name)
+					return
+				}
+				Expect(container.EnvFrom).To(HaveLen(2))
+				Expect(container.EnvFrom[1].Prefix).To(Equal("APP_"))
+				Expect(container.EnvFrom[1].SecretRef.Name).To(Equal("appfabric"))
+				found := false
+				for _, e := range container.Env {
+					if e.Name == "DB_PASSWORD" {
+						found = true
+						Expect(e.ValueFrom.SecretKeyRef.Name).To(Equal("db"))
+						Expect(e.ValueFrom.SecretKeyRef.Key).To(Equal("password"))
+					}
+				}
+				Expect(found).To(BeTrue())
+			}
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					checkContainer(o.Name, o.Spec.Template.Spec.Containers[0])
+				case *appsv1.StatefulSet:
+					checkContainer(o.Name, o.Spec.Template.Spec.Containers[0])
+				}
+			}
+		})
+	})
+	Describe("Tolerations and topology spread constraints", func() {
+		var (
+			master     *v1alpha1.CDAPMaster
+			toleration corev1.Toleration
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			toleration = corev1.Toleration{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "cdap",
+				Effect:   corev1.TaintEffectNoSchedule,
+			}
+		})
+		getPodSpecs := func() map[string]corev1.PodSpec {
+			spec, err := buildDeploymentPlanSpec(master, make(map[string]string))
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+			podSpecs := make(map[string]corev1.PodSpec)
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					podSpecs[o.Name] = o.Spec.Template.Spec
+				case *appsv1.StatefulSet:
+					podSpecs[o.Name] = o.Spec.Template.Spec
+				}
+			}
+			return podSpecs
+		}
+		It("Pods with more than one replica spread across zones by default", func() {
+			podSpecs := getPodSpecs()
+			constraints := podSpecs[getObjName(master, "router")].TopologySpreadConstraints
+			Expect(constraints).To(HaveLen(1))
+			Expect(constraints[0].TopologyKey).To(Equal(corev1.LabelTopologyZone))
+			Expect(constraints[0].WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+			Expect(constraints[0].LabelSelector.MatchLabels).To(HaveKeyWithValue("cdap.container.Router", "test"))
+			Expect(podSpecs[getObjName(master, "runtime")].TopologySpreadConstraints).To(HaveLen(1))
+			Expect(podSpecs[getObjName(master, "appfabric")].TopologySpreadConstraints).To(BeEmpty())
+		})
+		It("Tolerations and topology spread constraints in CR applied", func() {
+			constraint := corev1.TopologySpreadConstraint{
+				MaxSkew:           2,
+				TopologyKey:       corev1.LabelHostname,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+			}
+			master.Spec.Router.Tolerations = []corev1.Toleration{toleration}
+			master.Spec.Router.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{constraint}
+			podSpecs := getPodSpecs()
+			router := podSpecs[getObjName(master, "router")]
+			Expect(router.Tolerations).To(Equal([]corev1.Toleration{toleration}))
+			Expect(router.TopologySpreadConstraints).To(Equal([]corev1.TopologySpreadConstraint{constraint}))
+			Expect(podSpecs[getObjName(master, "appfabric")].Tolerations).To(BeEmpty())
+		})
+		It("Tolerations merged across services in the same pod", func() {
+			services := ServiceGroup{serviceLogs, serviceMetrics, serviceAppFabric}
+			master.Spec.Logs.Tolerations = []corev1.Toleration{toleration}
+			master.Spec.Metrics.Tolerations = []corev1.Toleration{toleration}
+			tolerations, err := getTolerations(master, services)
+			Expect(err).To(BeNil())
+			Expect(tolerations).To(Equal([]corev1.Toleration{toleration}))
+
+			master.Spec.Metrics.Tolerations[0].Value = "other"
+			_, err = getTolerations(master, services)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Describe("Pod and container security context", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+		})
+		getPodSpecs := func() map[string]corev1.PodSpec {
+			spec, err := buildDeploymentPlanSpec(master, make(map[string]string))
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+			podSpecs := make(map[string]corev1.PodSpec)
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					podSpecs[o.Name] = o.Spec.Template.Spec
+				case *appsv1.StatefulSet:
+					podSpecs[o.Name] = o.Spec.Template.Spec
+				}
+			}
+			return podSpecs
+		}
+		It("Legacy security context applied to pods and all containers", func() {
+			podSpecs := getPodSpecs()
+			messaging := podSpecs[getObjName(master, "messaging")]
+			Expect(*messaging.SecurityContext.RunAsUser).To(Equal(int64(1000)))
+			Expect(*messaging.SecurityContext.RunAsNonRoot).To(BeTrue())
+			Expect(messaging.InitContainers).NotTo(BeEmpty())
+			for _, c := range append(messaging.InitContainers, messaging.Containers...) {
+				Expect(*c.SecurityContext.AllowPrivilegeEscalation).To(BeFalse(), c.Name)
+			}
+			Expect(*podSpecs[getObjName(master, "appfabric")].SecurityContext.RunAsUser).To(Equal(int64(3000)))
+		})
+		It("Native security context takes precedence over legacy one", func() {
+			master.Spec.PodSecurityContext = &corev1.PodSecurityContext{
+				RunAsUser:           int64Ptr(5000),
+				SupplementalGroups:  []int64{6000},
+				FSGroupChangePolicy: func() *corev1.PodFSGroupChangePolicy { p := corev1.FSGroupChangeOnRootMismatch; return &p }(),
+			}
+			master.Spec.ContainerSecurityContext = &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW"}},
+			}
+			master.Spec.Router.ContainerSecurityContext = &corev1.SecurityContext{ReadOnlyRootFilesystem: boolPtr(true)}
+			podSpecs := getPodSpecs()
+			messaging := podSpecs[getObjName(master, "messaging")]
+			Expect(*messaging.SecurityContext.RunAsUser).To(Equal(int64(5000)))
+			Expect(messaging.SecurityContext.RunAsNonRoot).To(BeNil())
+			Expect(messaging.SecurityContext.SupplementalGroups).To(Equal([]int64{6000}))
+			Expect(*messaging.SecurityContext.FSGroupChangePolicy).To(Equal(corev1.FSGroupChangeOnRootMismatch))
+			Expect(messaging.Containers[0].SecurityContext.Capabilities.Drop).To(Equal([]corev1.Capability{"NET_RAW"}))
+			Expect(messaging.Containers[0].SecurityContext.AllowPrivilegeEscalation).To(BeNil())
+			router := podSpecs[getObjName(master, "router")]
+			Expect(*router.Containers[0].SecurityContext.ReadOnlyRootFilesystem).To(BeTrue())
+			Expect(router.Containers[0].SecurityContext.Capabilities).To(BeNil())
+		})
+		It("Restricted preset fills in unset fields", func() {
+			master.Spec.SecurityContextPreset = v1alpha1.SecurityContextPresetRestricted
+			podSpecs := getPodSpecs()
+			for name, podSpec := range podSpecs {
+				Expect(podSpec.SecurityContext.RunAsNonRoot).NotTo(BeNil(), name)
+				Expect(podSpec.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault), name)
+				for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+					Expect(*c.SecurityContext.AllowPrivilegeEscalation).To(BeFalse(), c.Name)
+					Expect(*c.SecurityContext.Privileged).To(BeFalse(), c.Name)
+					Expect(c.SecurityContext.Capabilities.Drop).To(Equal([]corev1.Capability{"ALL"}), c.Name)
+				}
+			}
+			// Values set in CR are kept
+			appfabric := podSpecs[getObjName(master, "appfabric")]
+			Expect(*appfabric.SecurityContext.RunAsUser).To(Equal(int64(3000)))
+			Expect(*appfabric.SecurityContext.RunAsNonRoot).To(BeFalse())
+			Expect(*podSpecs[getObjName(master, "router")].SecurityContext.RunAsUser).To(Equal(int64(1000)))
+			Expect(*podSpecs[getObjName(master, "router")].SecurityContext.RunAsNonRoot).To(BeTrue())
+		})
+		It("Upgrade job uses master security context", func() {
+			master.Spec.SecurityContextPreset = v1alpha1.SecurityContextPresetRestricted
+			spec := buildPreUpgradeJobSpec(getPreUpgradeJobName(master.Status.UpgradeStartTimeMillis), master, map[string]string{})
+			obj, err := buildUpgradeJobObject(spec)
+			Expect(err).To(BeNil())
+			podSpec := obj.Obj.(*k8s.Object).Obj.(*batchv1.Job).Spec.Template.Spec
+			Expect(*podSpec.SecurityContext.RunAsUser).To(Equal(int64(1000)))
+			Expect(podSpec.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+			Expect(podSpec.Containers[0].SecurityContext.Capabilities.Drop).To(Equal([]corev1.Capability{"ALL"}))
+		})
+	})
+	Describe("Image pull secrets", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}}
+		})
+		It("Secrets set for all pods with per service overrides", func() {
+			master.Spec.Router.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "router-registry"}}
+			master.Spec.SystemMetricsExporter = &v1alpha1.SystemMetricExporterSpec{
+				CDAPServiceSpec: v1alpha1.CDAPServiceSpec{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}, {Name: "sidecar-registry"}},
+				},
+			}
+			spec, err := buildDeploymentPlanSpec(master, make(map[string]string))
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					expected := []corev1.LocalObjectReference{{Name: "registry"}}
+					if o.Name == getObjName(master, "router") {
+						expected = []corev1.LocalObjectReference{{Name: "router-registry"}}
+					}
+					Expect(o.Spec.Template.Spec.ImagePullSecrets).To(Equal(expected), o.Name)
+				case *appsv1.StatefulSet:
+					expected := []corev1.LocalObjectReference{{Name: "registry"}}
+					// runtime has system metrics enabled
+					if o.Name == getObjName(master, "runtime") {
+						expected = append(expected, corev1.LocalObjectReference{Name: "sidecar-registry"})
+					}
+					Expect(o.Spec.Template.Spec.ImagePullSecrets).To(Equal(expected), o.Name)
+				}
+			}
+		})
+		It("Secrets set for upgrade job", func() {
+			spec := buildPostUpgradeJobSpec(getPostUpgradeJobName(master.Status.UpgradeStartTimeMillis), master, map[string]string{})
+			obj, err := buildUpgradeJobObject(spec)
+			Expect(err).To(BeNil())
+			job := obj.Obj.(*k8s.Object).Obj.(*batchv1.Job)
+			Expect(job.Spec.Template.Spec.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{{Name: "registry"}}))
+		})
+	})
+	Describe("Extra containers", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.ExtraContainers = []corev1.Container{{Name: "fluent-bit", Image: "fluent/fluent-bit:2.0"}}
+			master.Spec.ExtraInitContainers = []corev1.Container{{Name: "fetch-secrets", Image: "busybox"}}
+		})
+		getPodSpecs := func() (map[string]corev1.PodSpec, error) {
+			spec, err := buildDeploymentPlanSpec(master, make(map[string]string))
+			if err != nil {
+				return nil, err
+			}
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			if err != nil {
+				return nil, err
+			}
+			podSpecs := make(map[string]corev1.PodSpec)
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					podSpecs[o.Name] = o.Spec.Template.Spec
+				case *appsv1.StatefulSet:
+					podSpecs[o.Name] = o.Spec.Template.Spec
+				}
+			}
+			return podSpecs, nil
+		}
+		findContainer := func(containers []corev1.Container, name string) *corev1.Container {
+			for i := range containers {
+				if containers[i].Name == name {
+					return &containers[i]
+				}
+			}
+			return nil
+		}
+		It("Master extra containers appended to all pods", func() {
+			podSpecs, err := getPodSpecs()
+			Expect(err).To(BeNil())
+			Expect(podSpecs).NotTo(BeEmpty())
+			for name, podSpec := range podSpecs {
+				Expect(podSpec.Containers[len(podSpec.Containers)-1].Name).To(Equal("fluent-bit"), name)
+				Expect(podSpec.InitContainers[len(podSpec.InitContainers)-1].Name).To(Equal("fetch-secrets"), name)
+				Expect(podSpec.Containers[len(podSpec.Containers)-1].VolumeMounts).To(BeEmpty(), name)
+			}
+			// The storage init container still runs first
+			messaging := podSpecs[getObjName(master, "messaging")]
+			Expect(messaging.InitContainers).To(HaveLen(2))
+			Expect(messaging.InitContainers[0].Name).To(Equal("storageinit"))
+			// Container security context applied to the extra containers without their own
+			Expect(*findContainer(messaging.Containers, "fluent-bit").SecurityContext.AllowPrivilegeEscalation).To(BeFalse())
+		})
+		It("Service extra containers only added to the service pod", func() {
+			master.Spec.ExtraContainers = nil
+			master.Spec.ExtraInitContainers = nil
+			master.Spec.Router.ExtraContainers = []corev1.Container{{
+				Name:            "proxy",
+				Image:           "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.0.0",
+				SecurityContext: &corev1.SecurityContext{RunAsUser: int64Ptr(65532)},
+			}}
+			podSpecs, err := getPodSpecs()
+			Expect(err).To(BeNil())
+			router := podSpecs[getObjName(master, "router")]
+			proxy := findContainer(router.Containers, "proxy")
+			Expect(proxy).NotTo(BeNil())
+			Expect(*proxy.SecurityContext.RunAsUser).To(Equal(int64(65532)))
+			Expect(proxy.SecurityContext.AllowPrivilegeEscalation).To(BeNil())
+			Expect(findContainer(podSpecs[getObjName(master, "userinterface")].Containers, "proxy")).To(BeNil())
+		})
+		It("Config volumes mounted into extra containers on request", func() {
+			master.Spec.SecuritySecret = "cdap-security"
+			master.Spec.ExtraContainersMountConfig = true
+			master.Spec.Router.ExtraContainersMountConfig = boolPtr(false)
+			master.Spec.ExtraInitContainers[0].VolumeMounts = []corev1.VolumeMount{{Name: "cdap-conf", MountPath: "/conf"}}
+			podSpecs, err := getPodSpecs()
+			Expect(err).To(BeNil())
+			messaging := podSpecs[getObjName(master, "messaging")]
+			mounts := make(map[string]string)
+			for _, m := range findContainer(messaging.Containers, "fluent-bit").VolumeMounts {
+				mounts[m.Name] = m.MountPath
+			}
+			Expect(mounts).To(Equal(map[string]string{
+				"podinfo":         "/etc/podinfo",
+				"cdap-conf":       "/etc/cdap/conf",
+				"hadoop-conf":     "/etc/hadoop/conf",
+				"cdap-sysappconf": "/opt/cdap/master/system-app-config",
+				"cdap-security":   defaultSecuritySecretPath,
+			}))
+			// Mounts set in CR are kept
+			fetch := findContainer(messaging.InitContainers, "fetch-secrets")
+			Expect(fetch.VolumeMounts).To(HaveLen(5))
+			Expect(fetch.VolumeMounts[0]).To(Equal(corev1.VolumeMount{Name: "cdap-conf", MountPath: "/conf"}))
+			// The router overrides the master setting for all extra containers in its pod
+			router := podSpecs[getObjName(master, "router")]
+			Expect(findContainer(router.Containers, "fluent-bit").VolumeMounts).To(BeEmpty())
+			master.Spec.ExtraContainers = nil
+			master.Spec.Router.ExtraContainers = []corev1.Container{{Name: "proxy", Image: "envoyproxy/envoy:v1.24.0"}}
+			podSpecs, err = getPodSpecs()
+			Expect(err).To(BeNil())
+			Expect(findContainer(podSpecs[getObjName(master, "router")].Containers, "proxy").VolumeMounts).To(BeEmpty())
+		})
+		It("Fail on duplicate container names", func() {
+			master.Spec.Router.ExtraContainers = []corev1.Container{{Name: "fluent-bit", Image: "fluent/fluent-bit:2.0"}}
+			_, err := getPodSpecs()
+			Expect(err).NotTo(BeNil())
+			master.Spec.Router.ExtraContainers = []corev1.Container{{Name: "router", Image: "busybox"}}
+			_, err = getPodSpecs()
+			Expect(err).NotTo(BeNil())
+		})
+	})
 	Describe("Set java max heap size env var", func() {
 		var (
 			envVar    []corev1.EnvVar
@@ -178,11 +864,13 @@ var _ = Describe("Controller Suite", func() {
 				Name:  javaMaxHeapSizeEnvVarName,
 				Value: "-Xmx1024m",
 			})
-			envNew := addJavaMaxHeapEnvIfNotPresent(envOld, resources)
+			envNew, err := addJavaMaxHeapEnvIfNotPresent(envOld, resources, &v1alpha1.JVMSpec{})
+			Expect(err).To(BeNil())
 			Expect(envNew).To(Equal(envOld))
 		})
 		It("java max heap size added", func() {
-			envNew := addJavaMaxHeapEnvIfNotPresent(envVar, resources)
+			envNew, err := addJavaMaxHeapEnvIfNotPresent(envVar, resources, &v1alpha1.JVMSpec{})
+			Expect(err).To(BeNil())
 			Expect(envNew).To(Equal(envVar))
 		})
 	})
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Return the Hadoop configuration files, keyed by file name, with their properties. core-site.xml is always
+// present, with fs.defaultFS set to the location URI, followed by the "hadoop:" prefixed properties of cdap-site.xml
+// for backward compatibility and the properties set in hadoopConfig, in the order of precedence.
+func getHadoopConfig(master *v1alpha1.CDAPMaster) (map[string]map[string]string, error) {
+	files := map[string]map[string]string{
+		hadoopCoreSite: {confFSDefaultFS: master.Spec.LocationURI},
+	}
+	for k, v := range master.Spec.Config {
+		if strings.HasPrefix(k, confHadoopPrefix) {
+			files[hadoopCoreSite][strings.TrimPrefix(k, confHadoopPrefix)] = v
+		}
+	}
+	for file, properties := range master.Spec.HadoopConfig {
+		if errs := validation.IsConfigMapKey(file); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid hadoop configuration file name %q: %s", file, strings.Join(errs, ", "))
+		}
+		if files[file] == nil {
+			files[file] = make(map[string]string)
+		}
+		for k, v := range properties {
+			files[file][k] = v
+		}
+	}
+	return files, nil
+}
+
+// Render the Hadoop configuration files, keyed by file name
+func renderHadoopConfig(master *v1alpha1.CDAPMaster) (map[string]string, error) {
+	files, err := getHadoopConfig(master)
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[string]string)
+	for name, properties := range files {
+		templateData := struct {
+			Properties map[string]string
+		}{
+			Properties: properties,
+		}
+		data, err := fillTemplate(templateHadoopSite, templateData)
+		if err != nil {
+			return nil, err
+		}
+		rendered[name] = data
+	}
+	return rendered, nil
+}
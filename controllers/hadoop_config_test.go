@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Hadoop config", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.LocationURI = "hdfs://namenode:8020"
+		})
+		// Return the data of the ConfigMaps generated by the config map handler, keyed by ConfigMap name
+		getConfigMaps := func() (map[string]map[string]string, error) {
+			handler := &ConfigMapHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			configMaps := make(map[string]map[string]string)
+			for _, obj := range objs {
+				configMap := obj.Obj.(*k8s.Object).Obj.(*corev1.ConfigMap)
+				configMaps[configMap.Name] = configMap.Data
+			}
+			return configMaps, nil
+		}
+		It("core-site.xml rendered with the hadoop: prefixed configs", func() {
+			master.Spec.Config["hadoop:fs.gs.project.id"] = "my-project"
+			configMaps, err := getConfigMaps()
+			Expect(err).To(BeNil())
+			hconf := configMaps["cdap-test-hconf"]
+			Expect(hconf).To(HaveLen(1))
+			Expect(hconf[hadoopCoreSite]).To(ContainSubstring("<name>fs.defaultFS</name>\n    <value>hdfs://namenode:8020</value>"))
+			Expect(hconf[hadoopCoreSite]).To(ContainSubstring("<name>fs.gs.project.id</name>\n    <value>my-project</value>"))
+			Expect(configMaps["cdap-test-cconf"]["cdap-site.xml"]).NotTo(ContainSubstring("fs.gs.project.id"))
+		})
+		It("Every hadoop config file rendered into the hconf ConfigMap", func() {
+			master.Spec.Config["hadoop:hadoop.security.authentication"] = "simple"
+			master.Spec.HadoopConfig = map[string]map[string]string{
+				hadoopCoreSite:    {"hadoop.security.authentication": "kerberos"},
+				"hdfs-site.xml":   {"dfs.replication": "2"},
+				"yarn-site.xml":   {"yarn.resourcemanager.hostname": "rm"},
+				"mapred-site.xml": {"mapreduce.framework.name": "yarn", "mapreduce.map.java.opts": "-Xmx1g -Da=<b>"},
+			}
+			configMaps, err := getConfigMaps()
+			Expect(err).To(BeNil())
+			hconf := configMaps["cdap-test-hconf"]
+			Expect(hconf).To(HaveLen(4))
+			// hadoopConfig takes precedence over the hadoop: prefix
+			Expect(hconf[hadoopCoreSite]).To(ContainSubstring("<value>kerberos</value>"))
+			Expect(hconf[hadoopCoreSite]).NotTo(ContainSubstring("<value>simple</value>"))
+			Expect(hconf[hadoopCoreSite]).To(ContainSubstring("<name>fs.defaultFS</name>"))
+			Expect(hconf["hdfs-site.xml"]).To(ContainSubstring("<name>dfs.replication</name>\n    <value>2</value>"))
+			Expect(hconf["hdfs-site.xml"]).NotTo(ContainSubstring("fs.defaultFS"))
+			Expect(hconf["yarn-site.xml"]).To(ContainSubstring("<name>yarn.resourcemanager.hostname</name>"))
+			Expect(hconf["mapred-site.xml"]).To(ContainSubstring("<value>-Xmx1g -Da=&lt;b&gt;</value>"))
+		})
+		It("Fail on invalid file name", func() {
+			master.Spec.HadoopConfig = map[string]map[string]string{"conf/hdfs-site.xml": {"dfs.replication": "2"}}
+			_, err := getConfigMaps()
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Return the JVM spec of the service, which overrides the one in CR
+func getJVMSpec(master *v1alpha1.CDAPMaster, ss *v1alpha1.CDAPServiceSpec) *v1alpha1.JVMSpec {
+	if ss.JVM != nil {
+		return ss.JVM
+	}
+	if master.Spec.JVM != nil {
+		return master.Spec.JVM
+	}
+	return &v1alpha1.JVMSpec{}
+}
+
+// Return the memory of the container, which is the larger of its memory request and limit
+func getContainerMemory(resources *corev1.ResourceRequirements) int64 {
+	if resources == nil {
+		return 0
+	}
+	return max(resources.Requests.Memory().Value(), resources.Limits.Memory().Value())
+}
+
+// Return the heap percentage of the JVM spec, or the default one of its heap policy
+func getHeapPercentage(jvm *v1alpha1.JVMSpec, defaultPercentage int32) int64 {
+	if jvm.HeapPercentage != nil {
+		return int64(*jvm.HeapPercentage)
+	}
+	return int64(defaultPercentage)
+}
+
+// Derive the max heap size in bytes from the memory of the container with the heap policy of the JVM spec
+func getMaxHeapSize(jvm *v1alpha1.JVMSpec, memory int64) (int64, error) {
+	switch jvm.HeapPolicy {
+	case v1alpha1.HeapPolicyRatio:
+		return memory * getHeapPercentage(jvm, javaDefaultHeapPercentage) / 100, nil
+	case v1alpha1.HeapPolicyReserved:
+		reserved := javaReservedNonHeap
+		if jvm.ReservedNonHeap != nil {
+			reserved = jvm.ReservedNonHeap.Value()
+		}
+		if memory <= reserved {
+			return 0, fmt.Errorf("memory %d is not larger than the reserved non heap memory %d", memory, reserved)
+		}
+		return memory - reserved, nil
+	case v1alpha1.HeapPolicyContainerAware:
+		return memory * getHeapPercentage(jvm, javaContainerAwareHeapPercentage) / 100, nil
+	case v1alpha1.HeapPolicyDefault, "":
+		return max(memory-javaReservedNonHeap, int64(float64(memory)*javaMinHeapRatio)), nil
+	}
+	return 0, fmt.Errorf("unknown heap policy %q", jvm.HeapPolicy)
+}
+
+// Return the value of the env var, and whether it is present
+func getEnvValue(env []corev1.EnvVar, name string) (string, bool) {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// Derive from memory resource requirements and add java max heap size to the supplied env var array if not present
+func addJavaMaxHeapEnvIfNotPresent(env []corev1.EnvVar, resources *corev1.ResourceRequirements, jvm *v1alpha1.JVMSpec) ([]corev1.EnvVar, error) {
+	// Nothing to set if already present
+	if _, ok := getEnvValue(env, javaMaxHeapSizeEnvVarName); ok {
+		return env, nil
+	}
+
+	// The JVM sizes the heap from the memory limit of the container
+	if jvm.HeapPolicy == v1alpha1.HeapPolicyContainerAware {
+		return append(env, corev1.EnvVar{
+			Name:  javaMaxHeapSizeEnvVarName,
+			Value: fmt.Sprintf(javaMaxRAMPercentageOptFormat, getHeapPercentage(jvm, javaContainerAwareHeapPercentage)),
+		}), nil
+	}
+
+	// Derive from memory resource requirement
+	memory := getContainerMemory(resources)
+	if memory <= 0 {
+		return env, nil
+	}
+	xmx, err := getMaxHeapSize(jvm, memory)
+	if err != nil {
+		return nil, err
+	}
+	return append(env, corev1.EnvVar{
+		Name:  javaMaxHeapSizeEnvVarName,
+		Value: fmt.Sprintf("-Xmx%v", xmx),
+	}), nil
+}
+
+// Derive from memory resource requirements and add the max heap size of Node.js, which runs the UI, to the supplied
+// env var array if not present
+func addNodeMaxHeapEnvIfNotPresent(env []corev1.EnvVar, resources *corev1.ResourceRequirements, jvm *v1alpha1.JVMSpec) ([]corev1.EnvVar, error) {
+	// Nothing to set if already present
+	if _, ok := getEnvValue(env, nodeOptionsEnvVarName); ok {
+		return env, nil
+	}
+	memory := getContainerMemory(resources)
+	if memory <= 0 {
+		return env, nil
+	}
+	heap, err := getMaxHeapSize(jvm, memory)
+	if err != nil {
+		return nil, err
+	}
+	return append(env, corev1.EnvVar{
+		Name:  nodeOptionsEnvVarName,
+		Value: fmt.Sprintf(nodeMaxOldSpaceSizeOptFormat, heap/megaBytes),
+	}), nil
+}
+
+// Return the GC and heap dump options of the JVM spec
+func getJVMOptions(jvm *v1alpha1.JVMSpec) []string {
+	var opts []string
+	opts = append(opts, jvm.GCOptions...)
+	if jvm.HeapDumpPath != "" {
+		opts = append(opts, javaHeapDumpOnOOMOpt, fmt.Sprintf(javaHeapDumpPathOptFormat, jvm.HeapDumpPath))
+	}
+	return opts
+}
+
+// Set the max heap size and JVM options of the service container. They are derived from the resources and the JVM
+// spec of the service, unless the max heap size is set in the env of the service. The UI only has the max heap size.
+func setJVMForContainer(c *ContainerSpec, master *v1alpha1.CDAPMaster, ss *v1alpha1.CDAPServiceSpec, service ServiceName) error {
+	jvm := getJVMSpec(master, ss)
+	var err error
+	if service == serviceUserInterface {
+		if c.Env, err = addNodeMaxHeapEnvIfNotPresent(c.Env, ss.Resources, jvm); err != nil {
+			return fmt.Errorf("failed to set max heap size for service %q: %w", service, err)
+		}
+		maxHeap, _ := getEnvValue(c.Env, nodeOptionsEnvVarName)
+		c.JVM = &v1alpha1.JVMStatus{MaxHeap: maxHeap}
+		return nil
+	}
+	if c.Env, err = addJavaMaxHeapEnvIfNotPresent(c.Env, ss.Resources, jvm); err != nil {
+		return fmt.Errorf("failed to set max heap size for service %q: %w", service, err)
+	}
+	maxHeap, _ := getEnvValue(c.Env, javaMaxHeapSizeEnvVarName)
+	c.JVM = &v1alpha1.JVMStatus{MaxHeap: maxHeap, Options: getJVMOptions(jvm)}
+	if len(c.JVM.Options) > 0 {
+		c.appendToEnv(javaOptsEnvVarName, strings.Join(c.JVM.Options, " "))
+	}
+	return nil
+}
+
+// Return the max heap size and JVM options applied to the service containers of the deployment plan, keyed by
+// container name
+func getJVMStatus(spec *DeploymentPlanSpec) map[string]v1alpha1.JVMStatus {
+	var containers []*ContainerSpec
+	for _, s := range spec.Stateful {
+		containers = append(containers, s.Containers...)
+	}
+	for _, s := range spec.Deployment {
+		containers = append(containers, s.Containers...)
+	}
+	status := make(map[string]v1alpha1.JVMStatus)
+	for _, c := range containers {
+		if c.JVM != nil {
+			status[c.Name] = *c.JVM
+		}
+	}
+	if len(status) == 0 {
+		return nil
+	}
+	return status
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Controller Suite", func() {
+	Describe("JVM heap tuning", func() {
+		var (
+			master    *v1alpha1.CDAPMaster
+			resources *corev1.ResourceRequirements
+		)
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			resources = &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+			}
+		})
+		// Return the pod spec of the statefulset or deployment with the given name
+		getPodSpec := func(name string) corev1.PodSpec {
+			spec, err := buildDeploymentPlanSpec(master, map[string]string{})
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.StatefulSet:
+					if o.Name == getObjName(master, name) {
+						return o.Spec.Template.Spec
+					}
+				case *appsv1.Deployment:
+					if o.Name == getObjName(master, name) {
+						return o.Spec.Template.Spec
+					}
+				}
+			}
+			Fail("pod spec not found for " + name)
+			return corev1.PodSpec{}
+		}
+		getEnv := func(c corev1.Container, name string) string {
+			value, _ := getEnvValue(c.Env, name)
+			return value
+		}
+		It("Max heap size derived with heap policies", func() {
+			policies := []struct {
+				jvm      v1alpha1.JVMSpec
+				expected string
+			}{
+				{v1alpha1.JVMSpec{}, "-Xmx3489660928"},
+				{v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyRatio}, "-Xmx2576980377"},
+				{v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyRatio, HeapPercentage: int32Ptr(50)}, "-Xmx2147483648"},
+				{v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyReserved}, "-Xmx3489660928"},
+				{v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyReserved, ReservedNonHeap: resource.NewQuantity(gigaBytes, resource.BinarySI)}, "-Xmx3221225472"},
+				{v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyContainerAware}, "-XX:MaxRAMPercentage=75.0"},
+				{v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyContainerAware, HeapPercentage: int32Ptr(80)}, "-XX:MaxRAMPercentage=80.0"},
+			}
+			for _, p := range policies {
+				env, err := addJavaMaxHeapEnvIfNotPresent(nil, resources, &p.jvm)
+				Expect(err).To(BeNil())
+				Expect(env).To(Equal([]corev1.EnvVar{{Name: javaMaxHeapSizeEnvVarName, Value: p.expected}}), string(p.jvm.HeapPolicy))
+			}
+		})
+		It("Fail when reserved non heap memory exceeds memory", func() {
+			jvm := &v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyReserved, ReservedNonHeap: resource.NewQuantity(8*gigaBytes, resource.BinarySI)}
+			_, err := addJavaMaxHeapEnvIfNotPresent(nil, resources, jvm)
+			Expect(err).NotTo(BeNil())
+
+			master.Spec.AppFabric.JVM = jvm
+			_, err = buildDeploymentPlanSpec(master, map[string]string{})
+			Expect(err).NotTo(BeNil())
+		})
+		It("GC and heap dump options added with per service overrides", func() {
+			master.Spec.JVM = &v1alpha1.JVMSpec{
+				HeapPolicy:   v1alpha1.HeapPolicyContainerAware,
+				GCOptions:    []string{"-XX:+UseG1GC", "-XX:MaxGCPauseMillis=200"},
+				HeapDumpPath: "/data/heapdump",
+			}
+			master.Spec.Metrics.JVM = &v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyRatio}
+
+			logs := getPodSpec("logs")
+			Expect(getEnv(logs.Containers[0], javaMaxHeapSizeEnvVarName)).To(Equal("-XX:MaxRAMPercentage=75.0"))
+			Expect(getEnv(logs.Containers[0], javaOptsEnvVarName)).To(Equal("-XX:+UseG1GC -XX:MaxGCPauseMillis=200 -XX:+HeapDumpOnOutOfMemoryError -XX:HeapDumpPath=/data/heapdump"))
+
+			metrics := getPodSpec("metrics")
+			Expect(getEnv(metrics.Containers[0], javaMaxHeapSizeEnvVarName)).To(Equal("-Xmx62914560"))
+			Expect(getEnv(metrics.Containers[0], javaOptsEnvVarName)).To(BeEmpty())
+		})
+		It("Max heap size in env takes precedence over heap policy", func() {
+			master.Spec.JVM = &v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyContainerAware}
+			master.Spec.Router.Env = []corev1.EnvVar{{Name: javaMaxHeapSizeEnvVarName, Value: "-Xmx1g"}}
+			router := getPodSpec("router")
+			Expect(getEnv(router.Containers[0], javaMaxHeapSizeEnvVarName)).To(Equal("-Xmx1g"))
+		})
+		It("Node.js max heap size set for UI", func() {
+			master.Spec.UserInterface.Resources = resources
+			master.Spec.UserInterface.JVM = &v1alpha1.JVMSpec{HeapPolicy: v1alpha1.HeapPolicyContainerAware, GCOptions: []string{"-XX:+UseG1GC"}}
+			ui := getPodSpec("userinterface")
+			Expect(getEnv(ui.Containers[0], nodeOptionsEnvVarName)).To(Equal("--max-old-space-size=3072"))
+			_, ok := getEnvValue(ui.Containers[0].Env, javaMaxHeapSizeEnvVarName)
+			Expect(ok).To(BeFalse())
+			_, ok = getEnvValue(ui.Containers[0].Env, javaOptsEnvVarName)
+			Expect(ok).To(BeFalse())
+		})
+		It("Init container runs with resources and heap settings of the service", func() {
+			master.Spec.AppFabric.Resources = resources
+			master.Spec.AppFabric.JVM = &v1alpha1.JVMSpec{GCOptions: []string{"-XX:+UseG1GC"}}
+			appfabric := getPodSpec("appfabric")
+			Expect(appfabric.InitContainers).To(HaveLen(1))
+			Expect(appfabric.InitContainers[0].Resources).To(Equal(*resources))
+			Expect(appfabric.InitContainers[0].Env).To(Equal([]corev1.EnvVar{
+				{Name: javaMaxHeapSizeEnvVarName, Value: "-Xmx3489660928"},
+				{Name: javaOptsEnvVarName, Value: "-XX:+UseG1GC"},
+			}))
+		})
+		It("Applied settings reported in status", func() {
+			master.Spec.JVM = &v1alpha1.JVMSpec{HeapDumpPath: "/data/heapdump"}
+			spec, err := buildDeploymentPlanSpec(master, map[string]string{})
+			Expect(err).To(BeNil())
+			status := getJVMStatus(spec)
+			Expect(status).To(HaveKeyWithValue("appfabric", v1alpha1.JVMStatus{
+				MaxHeap: "-Xmx62914560",
+				Options: []string{"-XX:+HeapDumpOnOutOfMemoryError", "-XX:HeapDumpPath=/data/heapdump"},
+			}))
+			Expect(status).To(HaveKeyWithValue("userinterface", v1alpha1.JVMStatus{MaxHeap: "--max-old-space-size=60"}))
+			Expect(status).To(HaveKey("systemmetricsexporter"))
+			Expect(status).NotTo(HaveKey("storageinit"))
+		})
+	})
+})
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// logbackServices are the services that log with logback, for which a logback file is rendered when they have their
+// own log levels
+var logbackServices = ServiceGroup{
+	serviceAppFabric,
+	serviceLogs,
+	serviceMessaging,
+	serviceMetadata,
+	serviceMetrics,
+	servicePreview,
+	serviceRouter,
+	serviceRuntime,
+	serviceAuthentication,
+	serviceSupportBundle,
+	serviceTetheringAgent,
+	serviceArtifactCache,
+	serviceSystemMetricsExporter,
+}
+
+// logbackAppenders are the appenders of the ConfigMap referenced by logging.appendersConfigMap in CR
+type logbackAppenders struct {
+	// Names are the appender names, which are attached to the root logger
+	Names []string
+	// XML are the appender definitions, in the order of the ConfigMap keys
+	XML []string
+}
+
+// logbackData is the data of the logback templates
+type logbackData struct {
+	Master    *v1alpha1.CDAPMaster
+	LogLevels map[string]string
+	// JSONPattern is the pattern of the JSON log format, empty for the plain text pattern layout
+	JSONPattern string
+	Appenders   *logbackAppenders
+}
+
+// Return the ConfigMap referenced by logging.appendersConfigMap as a referred object, so that it is fetched by the
+// reconciler and supplied to handlers as a dependent resource
+func getLoggingDependents(master *v1alpha1.CDAPMaster) []reconciler.Object {
+	if master.Spec.Logging == nil || master.Spec.Logging.AppendersConfigMap == "" {
+		return nil
+	}
+	return []reconciler.Object{k8s.ReferredItem(&corev1.ConfigMap{}, master.Spec.Logging.AppendersConfigMap, master.Namespace)}
+}
+
+// Parse the appenders of the ConfigMap referenced by logging.appendersConfigMap from the supplied dependent objects
+func getLogbackAppenders(master *v1alpha1.CDAPMaster, dependent []reconciler.Object) (*logbackAppenders, error) {
+	appenders := &logbackAppenders{}
+	if master.Spec.Logging == nil || master.Spec.Logging.AppendersConfigMap == "" {
+		return appenders, nil
+	}
+	name := master.Spec.Logging.AppendersConfigMap
+	var configMap *corev1.ConfigMap
+	for _, item := range reconciler.ObjectsByType(dependent, k8s.Type) {
+		if c, ok := item.Obj.(*k8s.Object).Obj.(*corev1.ConfigMap); ok && c.Name == name {
+			configMap = c
+		}
+	}
+	if configMap == nil {
+		return nil, fmt.Errorf("failed to find log appenders ConfigMap %q", name)
+	}
+
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// The console appender is defined in logback.xml and the file appender of programs in logback-container.xml
+	names := map[string]bool{"Console": true, "Rolling": true}
+	for _, key := range keys {
+		// Entries are fragments, which are parsed within a root element to allow multiple appenders
+		var fragment struct {
+			Elements []struct {
+				XMLName xml.Name
+				Name    string `xml:"name,attr"`
+			} `xml:",any"`
+		}
+		if err := xml.Unmarshal([]byte("<appenders>"+configMap.Data[key]+"</appenders>"), &fragment); err != nil {
+			return nil, fmt.Errorf("failed to parse log appenders %q: %w", key, err)
+		}
+		if len(fragment.Elements) == 0 {
+			return nil, fmt.Errorf("log appenders %q has no appender", key)
+		}
+		for _, e := range fragment.Elements {
+			if e.XMLName.Local != "appender" {
+				return nil, fmt.Errorf("log appenders %q has unexpected element <%s>, expecting <appender>", key, e.XMLName.Local)
+			}
+			if e.Name == "" {
+				return nil, fmt.Errorf("log appenders %q has an appender without name", key)
+			}
+			if names[e.Name] {
+				return nil, fmt.Errorf("log appenders %q has duplicate appender %q", key, e.Name)
+			}
+			names[e.Name] = true
+			appenders.Names = append(appenders.Names, e.Name)
+		}
+		appenders.XML = append(appenders.XML, strings.TrimSpace(configMap.Data[key]))
+	}
+	return appenders, nil
+}
+
+// Return the name of the logback file of the service
+func getServiceLogbackFile(service ServiceName) string {
+	return "logback-" + strings.ToLower(service) + ".xml"
+}
+
+// Render logback.xml, logback-container.xml and the logback files of the services with their own log levels,
+// keyed by file name
+func renderLogback(master *v1alpha1.CDAPMaster, dependent []reconciler.Object) (map[string]string, error) {
+	appenders, err := getLogbackAppenders(master, dependent)
+	if err != nil {
+		return nil, err
+	}
+	data := logbackData{
+		Master:    master,
+		LogLevels: master.Spec.LogLevels,
+		Appenders: appenders,
+	}
+	if master.Spec.Logging != nil && master.Spec.Logging.Format == v1alpha1.LogFormatJSON {
+		data.JSONPattern = logbackJSONPattern
+	}
+	rendered := make(map[string]string)
+	for _, file := range []string{templateLogback, templateLogbackCont} {
+		if rendered[file], err = fillTemplate(file, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range logbackServices {
+		ss, err := getCDAPServiceSpec(master, s)
+		if err != nil {
+			return nil, err
+		}
+		if ss == nil || len(ss.LogLevels) == 0 {
+			continue
+		}
+		data.LogLevels = mergeMaps(master.Spec.LogLevels, ss.LogLevels)
+		file := getServiceLogbackFile(s)
+		if rendered[file], err = fillTemplate(templateLogback, data); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Logging", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		newConfigMap := func(data map[string]string) []reconciler.Object {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "appenders", Namespace: "default"},
+				Data:       data,
+			}
+			return []reconciler.Object{{Type: k8s.Type, Obj: &k8s.Object{Obj: configMap}}}
+		}
+		// Return the data of the cconf ConfigMap generated with the given appenders ConfigMap data
+		getCConf := func(appenders map[string]string) (map[string]string, error) {
+			handler := &ConfigMapHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, newConfigMap(appenders), nil)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objs {
+				if configMap := obj.Obj.(*k8s.Object).Obj.(*corev1.ConfigMap); configMap.Name == "cdap-test-cconf" {
+					return configMap.Data, nil
+				}
+			}
+			return nil, nil
+		}
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.LogLevels = map[string]string{"io.cdap.cdap": "DEBUG"}
+		})
+		It("Pattern layout by default", func() {
+			cconf, err := getCConf(nil)
+			Expect(err).To(BeNil())
+			Expect(cconf).To(HaveKey("cdap-site.xml"))
+			Expect(cconf).To(HaveKey("logback-container.xml"))
+			Expect(cconf["logback.xml"]).To(ContainSubstring("<pattern>%d{ISO8601} - %-5p [%t:%C{1}@%L] - %m%n</pattern>"))
+			Expect(cconf["logback.xml"]).To(ContainSubstring(`<logger name="io.cdap.cdap" level="DEBUG"/>`))
+			Expect(cconf["logback-container.xml"]).To(ContainSubstring(`<logger name="io.cdap.cdap" level="DEBUG"/>`))
+			Expect(getLoggingDependents(master)).To(BeEmpty())
+		})
+		It("JSON console logs with appenders from ConfigMap", func() {
+			master.Spec.Logging = &v1alpha1.LoggingSpec{Format: v1alpha1.LogFormatJSON, AppendersConfigMap: "appenders"}
+			objs := getLoggingDependents(master)
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].Lifecycle).To(Equal(reconciler.LifecycleReferred))
+			cconf, err := getCConf(map[string]string{
+				"b-http": `<appender name="Http" class="com.example.HttpAppender"><url>http://collector:8080</url></appender>`,
+				"a-syslog": `
+<appender name="Syslog" class="ch.qos.logback.classic.net.SyslogAppender">
+  <syslogHost>syslog</syslogHost>
+  <facility>USER</facility>
+</appender>`,
+			})
+			Expect(err).To(BeNil())
+			logback := cconf["logback.xml"]
+			Expect(logback).To(ContainSubstring(`<pattern>{"timestamp":"%d{"yyyy-MM-dd'T'HH:mm:ss.SSSXXX"}","level":"%level"`))
+			Expect(logback).NotTo(ContainSubstring("%-5p"))
+			Expect(logback).To(ContainSubstring("<syslogHost>syslog</syslogHost>"))
+			syslog := strings.Index(logback, `<appender-ref ref="Syslog"/>`)
+			http := strings.Index(logback, `<appender-ref ref="Http"/>`)
+			Expect(syslog).To(BeNumerically(">", 0))
+			Expect(http).To(BeNumerically(">", syslog))
+			// Program containers log to files in the same format and to the same appenders
+			container := cconf["logback-container.xml"]
+			Expect(container).To(ContainSubstring(`<pattern>{"timestamp":"%d{"yyyy-MM-dd'T'HH:mm:ss.SSSXXX"}","level":"%level"`))
+			Expect(container).NotTo(ContainSubstring("%-5p"))
+			Expect(container).To(ContainSubstring("<syslogHost>syslog</syslogHost>"))
+			Expect(container).To(ContainSubstring(`<appender-ref ref="Rolling"/>`))
+			Expect(container).To(ContainSubstring(`<appender-ref ref="Http"/>`))
+		})
+		It("Fail on missing or invalid appenders", func() {
+			master.Spec.Logging = &v1alpha1.LoggingSpec{AppendersConfigMap: "appenders"}
+			handler := &ConfigMapHandler{}
+			_, err := handler.Objects(context.Background(), master, map[string]string{}, nil, nil, nil)
+			Expect(err).NotTo(BeNil())
+			invalid := []string{
+				`<appender name="Syslog"`,
+				`<logger name="io.cdap" level="INFO"/>`,
+				`<appender class="ch.qos.logback.core.ConsoleAppender"/>`,
+				`<appender name="Console" class="ch.qos.logback.core.ConsoleAppender"/>`,
+				`<appender name="Rolling" class="ch.qos.logback.core.ConsoleAppender"/>`,
+				``,
+			}
+			for _, appenders := range invalid {
+				_, err := getCConf(map[string]string{"appenders": appenders})
+				Expect(err).NotTo(BeNil(), appenders)
+			}
+		})
+		It("Per service log levels", func() {
+			master.Spec.AppFabric.LogLevels = map[string]string{"io.cdap.cdap": "TRACE", "io.cdap.cdap.internal.app": "DEBUG"}
+			master.Spec.UserInterface.LogLevels = map[string]string{"io.cdap.cdap": "TRACE"}
+			cconf, err := getCConf(nil)
+			Expect(err).To(BeNil())
+			Expect(cconf).To(HaveLen(4))
+			appfabric := cconf["logback-appfabric.xml"]
+			Expect(appfabric).To(ContainSubstring(`<logger name="io.cdap.cdap" level="TRACE"/>`))
+			Expect(appfabric).To(ContainSubstring(`<logger name="io.cdap.cdap.internal.app" level="DEBUG"/>`))
+			Expect(cconf["logback.xml"]).To(ContainSubstring(`<logger name="io.cdap.cdap" level="DEBUG"/>`))
+
+			spec, err := buildDeploymentPlanSpec(master, map[string]string{})
+			Expect(err).To(BeNil())
+			objs, err := buildObjectsForDeploymentPlan(spec)
+			Expect(err).To(BeNil())
+			getOpts := func(podSpec corev1.PodSpec) string {
+				for _, env := range podSpec.Containers[0].Env {
+					if env.Name == javaOptsEnvVarName {
+						return env.Value
+					}
+				}
+				return ""
+			}
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.StatefulSet:
+					if o.Name == getObjName(master, "appfabric") {
+						Expect(getOpts(o.Spec.Template.Spec)).To(ContainSubstring("-Dlogback.configurationFile=/etc/cdap/conf/logback-appfabric.xml"))
+					} else {
+						Expect(getOpts(o.Spec.Template.Spec)).NotTo(ContainSubstring("logback"), o.Name)
+					}
+				case *appsv1.Deployment:
+					Expect(getOpts(o.Spec.Template.Spec)).NotTo(ContainSubstring("logback"), o.Name)
+				}
+			}
+		})
+	})
+})
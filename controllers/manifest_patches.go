@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Types of the patches in the ConfigMap referenced by manifestPatchesConfigMap in CR
+const (
+	manifestPatchStrategicMerge = "StrategicMerge"
+	manifestPatchJSON6902       = "JSON6902"
+)
+
+// manifestPatch is an entry of the ConfigMap referenced by manifestPatchesConfigMap in CR
+type manifestPatch struct {
+	// key is the key of the entry in the ConfigMap
+	key    string
+	Target struct {
+		// Kind is the kind of the generated objects to patch, e.g. StatefulSet
+		Kind string `json:"kind"`
+		// Name is the name of the generated object to patch. All objects of the kind are patched when empty.
+		Name string `json:"name,omitempty"`
+	} `json:"target"`
+	// Type is either StrategicMerge, the default, or JSON6902
+	Type string `json:"type,omitempty"`
+	// Patch is the strategic merge patch or the list of JSON6902 operations
+	Patch json.RawMessage `json:"patch"`
+}
+
+// Return the ConfigMap referenced by manifestPatchesConfigMap as a referred object, so that it is fetched by the
+// reconciler and supplied to handlers as a dependent resource
+func getManifestPatchesDependents(master *v1alpha1.CDAPMaster) []reconciler.Object {
+	name := master.Spec.ManifestPatchesConfigMap
+	if name == "" {
+		return nil
+	}
+	return []reconciler.Object{k8s.ReferredItem(&corev1.ConfigMap{}, name, master.Namespace)}
+}
+
+// Parse the patches of the ConfigMap referenced by manifestPatchesConfigMap from the supplied dependent objects.
+// They are returned in the order of their keys.
+func getManifestPatches(master *v1alpha1.CDAPMaster, dependent []reconciler.Object) ([]manifestPatch, error) {
+	name := master.Spec.ManifestPatchesConfigMap
+	if name == "" {
+		return nil, nil
+	}
+	var configMap *corev1.ConfigMap
+	for _, item := range reconciler.ObjectsByType(dependent, k8s.Type) {
+		if c, ok := item.Obj.(*k8s.Object).Obj.(*corev1.ConfigMap); ok && c.Name == name {
+			configMap = c
+		}
+	}
+	if configMap == nil {
+		return nil, fmt.Errorf("failed to find manifest patches ConfigMap %q", name)
+	}
+
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var patches []manifestPatch
+	for _, key := range keys {
+		doc, err := yaml.ToJSON([]byte(configMap.Data[key]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest patch %q: %w", key, err)
+		}
+		p := manifestPatch{key: key}
+		if err := json.Unmarshal(doc, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest patch %q: %w", key, err)
+		}
+		if p.Target.Kind == "" {
+			return nil, fmt.Errorf("manifest patch %q has no target kind", key)
+		}
+		if len(p.Patch) == 0 || string(p.Patch) == "null" {
+			return nil, fmt.Errorf("manifest patch %q has no patch", key)
+		}
+		switch p.Type {
+		case "":
+			p.Type = manifestPatchStrategicMerge
+		case manifestPatchStrategicMerge, manifestPatchJSON6902:
+		default:
+			return nil, fmt.Errorf("manifest patch %q has unsupported type %q, expecting %s or %s",
+				key, p.Type, manifestPatchStrategicMerge, manifestPatchJSON6902)
+		}
+		patches = append(patches, p)
+	}
+	return patches, nil
+}
+
+// Apply the patches to the matching objects in place. Patches without matching objects are ignored, since the
+// objects of disabled services are not generated.
+func applyManifestPatches(objs []reconciler.Object, patches []manifestPatch) error {
+	for _, p := range patches {
+		for i := range objs {
+			o, ok := objs[i].Obj.(*k8s.Object)
+			if !ok || o.Kind() != p.Target.Kind || (p.Target.Name != "" && o.Obj.GetName() != p.Target.Name) {
+				continue
+			}
+			patched, err := applyManifestPatch(o.Obj, p)
+			if err != nil {
+				return fmt.Errorf("failed to apply manifest patch %q to %s %s: %w", p.key, o.Kind(), o.Obj.GetName(), err)
+			}
+			o.Obj = patched
+		}
+	}
+	return nil
+}
+
+// Return a patched copy of the object. The patch can't change the name or namespace of the object.
+func applyManifestPatch(obj metav1.Object, p manifestPatch) (metav1.Object, error) {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	switch p.Type {
+	case manifestPatchJSON6902:
+		patch, err := jsonpatch.DecodePatch(p.Patch)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = patch.Apply(original); err != nil {
+			return nil, err
+		}
+	default:
+		if data, err = strategicpatch.StrategicMergePatch(original, p.Patch, obj); err != nil {
+			return nil, err
+		}
+	}
+	patched := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(metav1.Object)
+	if err := json.Unmarshal(data, patched); err != nil {
+		return nil, err
+	}
+	if patched.GetName() != obj.GetName() || patched.GetNamespace() != obj.GetNamespace() {
+		return nil, fmt.Errorf("the name and namespace can't be patched")
+	}
+	return patched, nil
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Manifest patches", func() {
+		var (
+			master *v1alpha1.CDAPMaster
+		)
+		newConfigMap := func(data map[string]string) []reconciler.Object {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "cdap-patches", Namespace: "default"},
+				Data:       data,
+			}
+			return []reconciler.Object{{Type: k8s.Type, Obj: &k8s.Object{Obj: configMap}}}
+		}
+		// Return the generated objects of the service handler with the patches in the given ConfigMap data
+		getObjs := func(data map[string]string) ([]reconciler.Object, error) {
+			handler := &ServiceHandler{}
+			return handler.Objects(context.Background(), master, map[string]string{}, nil, newConfigMap(data), nil)
+		}
+		findStatefulSet := func(objs []reconciler.Object, name string) *appsv1.StatefulSet {
+			for _, obj := range objs {
+				if s, ok := obj.Obj.(*k8s.Object).Obj.(*appsv1.StatefulSet); ok && s.Name == name {
+					return s
+				}
+			}
+			return nil
+		}
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.ManifestPatchesConfigMap = "cdap-patches"
+			ApplyDefaults(master)
+		})
+		It("Referenced ConfigMap is a dependent resource", func() {
+			objs := getManifestPatchesDependents(master)
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].Lifecycle).To(Equal(reconciler.LifecycleReferred))
+			master.Spec.ManifestPatchesConfigMap = ""
+			Expect(getManifestPatchesDependents(master)).To(BeEmpty())
+		})
+		It("Strategic merge patch applied to the named object only", func() {
+			objs, err := getObjs(map[string]string{
+				"host-aliases": `
+target:
+  kind: StatefulSet
+  name: cdap-test-logs
+patch:
+  spec:
+    template:
+      spec:
+        hostAliases:
+        - ip: 10.0.0.1
+          hostnames: [metadata.internal]
+`,
+			})
+			Expect(err).To(BeNil())
+			logs := findStatefulSet(objs, "cdap-test-logs")
+			Expect(logs).NotTo(BeNil())
+			Expect(logs.Spec.Template.Spec.HostAliases).To(ConsistOf(
+				corev1.HostAlias{IP: "10.0.0.1", Hostnames: []string{"metadata.internal"}}))
+			Expect(logs.Spec.Template.Spec.Containers).NotTo(BeEmpty())
+			messaging := findStatefulSet(objs, "cdap-test-messaging")
+			Expect(messaging).NotTo(BeNil())
+			Expect(messaging.Spec.Template.Spec.HostAliases).To(BeEmpty())
+		})
+		It("Strategic merge patch adds a sidecar container", func() {
+			objs, err := getObjs(map[string]string{
+				"sidecar": `
+target:
+  kind: StatefulSet
+  name: cdap-test-logs
+patch:
+  spec:
+    template:
+      spec:
+        containers:
+        - name: proxy
+          image: envoyproxy/envoy:v1.24.0
+`,
+			})
+			Expect(err).To(BeNil())
+			logs := findStatefulSet(objs, "cdap-test-logs")
+			Expect(logs).NotTo(BeNil())
+			var names []string
+			for _, c := range logs.Spec.Template.Spec.Containers {
+				names = append(names, c.Name)
+			}
+			Expect(names).To(ContainElements("logs", "proxy"))
+		})
+		It("JSON6902 patch applied to all objects of the kind in key order", func() {
+			objs, err := getObjs(map[string]string{
+				"a-dns": `
+target:
+  kind: Deployment
+type: JSON6902
+patch:
+- op: add
+  path: /spec/template/spec/dnsConfig
+  value:
+    options:
+    - name: ndots
+      value: "2"
+`,
+				"b-dns": `
+target:
+  kind: Deployment
+type: JSON6902
+patch:
+- op: replace
+  path: /spec/template/spec/dnsConfig/options/0/value
+  value: "3"
+`,
+			})
+			Expect(err).To(BeNil())
+			deployments := 0
+			for _, obj := range objs {
+				d, ok := obj.Obj.(*k8s.Object).Obj.(*appsv1.Deployment)
+				if !ok {
+					continue
+				}
+				deployments++
+				Expect(d.Spec.Template.Spec.DNSConfig).NotTo(BeNil())
+				Expect(*d.Spec.Template.Spec.DNSConfig.Options[0].Value).To(Equal("3"))
+			}
+			Expect(deployments).NotTo(BeZero())
+		})
+		It("Fail on missing ConfigMap", func() {
+			handler := &ServiceHandler{}
+			_, err := handler.Objects(context.Background(), master, map[string]string{}, nil, nil, nil)
+			Expect(err).NotTo(BeNil())
+		})
+		It("Fail on invalid patches", func() {
+			invalid := []string{
+				"target: {kind: StatefulSet}\ntype: Merge\npatch: {metadata: {labels: {a: b}}}",
+				"target: {name: cdap-test-logs}\npatch: {metadata: {labels: {a: b}}}",
+				"target: {kind: StatefulSet}",
+			}
+			for _, patch := range invalid {
+				_, err := getObjs(map[string]string{"patch": patch})
+				Expect(err).NotTo(BeNil(), patch)
+			}
+		})
+		It("Fail on patches changing the name", func() {
+			_, err := getObjs(map[string]string{
+				"rename": "target: {kind: StatefulSet, name: cdap-test-logs}\npatch: {metadata: {name: other}}",
+			})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Labels added to the objects of each handler. They identify the objects observed by the handler, so their
+// values must stay the same across versions of the operator.
+const (
+	labelResource          = "custom-resource"
+	labelResourceName      = "custom-resource-name"
+	labelResourceNamespace = "custom-resource-namespace"
+	labelUsing             = "using"
+)
+
+const (
+	// finalizerCleanup is added to CDAPMaster and removed once all handlers have finalized it.
+	finalizerCleanup = "sigapps.k8s.io/cleanup"
+
+	defaultReconcilePeriod  = 3 * time.Minute
+	finalizeReconcilePeriod = 30 * time.Second
+	// getFailureReconcilePeriod is the requeue delay when CDAPMaster can't be fetched
+	getFailureReconcilePeriod = 30 * time.Second
+)
+
+// Handler reconciles a logical component of CDAPMaster. Observables returns what to observe among the objects
+// labeled for the handler, and Objects returns the expected objects given the observed ones. Observed objects that
+// are not expected are deleted.
+type Handler interface {
+	Objects(ctx context.Context, api interface{}, labels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error)
+	Observables(api interface{}, labels map[string]string, dependent []reconciler.Object) []reconciler.Observable
+}
+
+// dependentResourcesHandler is implemented by handlers that read objects they don't manage, e.g. secrets.
+type dependentResourcesHandler interface {
+	DependentResources(api interface{}) []reconciler.Object
+}
+
+// differsHandler is implemented by handlers that decide when an observed object needs to be updated.
+type differsHandler interface {
+	Differs(expected reconciler.Object, observed reconciler.Object) bool
+}
+
+// finalizeHandler is implemented by handlers that clean up when CDAPMaster is deleted. Observed objects
+// marked with Delete are deleted after Finalize returns.
+type finalizeHandler interface {
+	Finalize(api interface{}, observed, dependent []reconciler.Object) error
+}
+
+// statusHandler is implemented by handlers that report the status of the reconciled objects. The returned
+// period, if shorter, replaces the default reconcile period.
+type statusHandler interface {
+	UpdateStatus(api interface{}, reconciled []reconciler.Object, err error) time.Duration
+}
+
+// Reconciler reconciles CDAPMaster by running each handler in order. CDAPMaster objects may be reconciled
+// concurrently, so handlers must not keep state between calls.
+type Reconciler struct {
+	client   client.Client
+	rm       *k8s.RsrcManager
+	handlers []Handler
+	// shard, if set, selects the CDAPMaster objects reconciled by this operator replica
+	shard labels.Selector
+	// recommender, if set, recommends the resources of the services from their observed usage
+	recommender *resourceRecommender
+}
+
+// WithShardSelector limits reconciliation to the CDAPMaster objects matching the selector
+func (r *Reconciler) WithShardSelector(selector labels.Selector) *Reconciler {
+	r.shard = selector
+	return r
+}
+
+// WithResourceRecommender recommends the resources of the services of the CDAPMaster objects enabling it, from the
+// usage listed by metrics
+func (r *Reconciler) WithResourceRecommender(metrics podMetricsLister) *Reconciler {
+	r.recommender = newResourceRecommender(metrics)
+	return r
+}
+
+var _ reconcile.Reconciler = &Reconciler{}
+
+// forgetSamples drops the usage samples of the CDAPMaster, if resources are recommended
+func (r *Reconciler) forgetSamples(key types.NamespacedName) {
+	if r.recommender != nil {
+		r.recommender.forget(key)
+	}
+}
+
+// Reconcile applies the defaults to CDAPMaster, reconciles the objects of all handlers and updates CDAPMaster.
+// Handler errors are reported in the status rather than returned, so that the reconciliation is retried after
+// the reconcile period.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := log.FromContext(ctx)
+	master := &v1alpha1.CDAPMaster{}
+	if err := r.client.Get(ctx, req.NamespacedName, master); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forgetSamples(req.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{RequeueAfter: getFailureReconcilePeriod}, err
+	}
+	// Events of owned objects are not filtered by shard, since their labels don't carry the ones of CDAPMaster
+	if !inShard(r.shard, master) {
+		logger.V(1).Info("Skipping CDAPMaster of another shard")
+		// The CDAPMaster may have moved to another shard, whose replica samples its usage from now on
+		r.forgetSamples(req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	ApplyDefaults(master)
+	if master.DeletionTimestamp != nil {
+		r.forgetSamples(req.NamespacedName)
+	} else if r.recommender != nil {
+		if err := r.recommender.recommend(ctx, master); err != nil {
+			// Failures, e.g. when the metrics API isn't served, don't affect the deployment of CDAP
+			logger.Error(err, "Failed to recommend resources")
+		}
+	}
+	period := defaultReconcilePeriod
+	var err error
+	for _, h := range r.handlers {
+		var p time.Duration
+		hctx := log.IntoContext(ctx, logger.WithValues("handler", handlerName(h)))
+		if master.DeletionTimestamp == nil {
+			p, err = r.reconcileUsing(hctx, h, master)
+		} else {
+			err = r.finalizeUsing(hctx, h, master)
+			p = finalizeReconcilePeriod
+		}
+		if p != 0 && p < period {
+			period = p
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile")
+		HandleError(master, err, "")
+	}
+
+	if err := r.client.Update(ctx, master); err != nil {
+		return reconcile.Result{RequeueAfter: period}, err
+	}
+	return reconcile.Result{RequeueAfter: period}, nil
+}
+
+// observeAndMutate returns the expected, observed and dependent objects of the handler, and the stage that
+// failed on error.
+func (r *Reconciler) observeAndMutate(ctx context.Context, h Handler, master *v1alpha1.CDAPMaster) ([]reconciler.Object, []reconciler.Object, []reconciler.Object, string, error) {
+	labels := handlerLabels(master, h)
+	var deps []reconciler.Object
+	if d, ok := h.(dependentResourcesHandler); ok {
+		deps = d.DependentResources(master)
+	}
+	dependent, err := r.rm.Observe(ctx, r.rm.ObservablesFromObjects(deps, labels)...)
+	if err != nil {
+		return nil, nil, nil, "dependent resources", err
+	}
+	observed, err := r.rm.Observe(ctx, h.Observables(master, labels, dependent)...)
+	if err != nil {
+		return nil, nil, dependent, "observing resources", err
+	}
+	expected, err := h.Objects(ctx, master, labels, observed, dependent, []reconciler.Object{})
+	if err != nil {
+		return nil, nil, dependent, "gathering expected resources", err
+	}
+	return expected, observed, dependent, "", nil
+}
+
+// reconcileUsing creates the expected objects missing from the observed ones, updates the ones that differ
+// and deletes the observed ones that are not expected. Only managed objects are created, updated and deleted.
+// Missing referred objects are reported as errors.
+func (r *Reconciler) reconcileUsing(ctx context.Context, h Handler, master *v1alpha1.CDAPMaster) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+	var errs []error
+	var reconciled []reconciler.Object
+
+	expected, observed, _, stage, err := r.observeAndMutate(ctx, h, master)
+	if err != nil {
+		logger.Error(err, "Failed to reconcile", "stage", stage)
+		errs = append(errs, err)
+	}
+
+	ownerRef := metav1.NewControllerRef(master, v1alpha1.GroupVersion.WithKind("CDAPMaster"))
+	for _, e := range expected {
+		name := e.Obj.GetName()
+		var o *reconciler.Object
+		for i := range observed {
+			if e.Type == observed[i].Type && e.Obj.IsSameAs(observed[i].Obj) {
+				o = &observed[i]
+				break
+			}
+		}
+
+		// Object is expected but not observed - create
+		if o == nil {
+			if e.Lifecycle == reconciler.LifecycleReferred {
+				errs = appendError(logger, errs, "Missing resource", e, fmt.Errorf("missing resource not managed by %s: %s", handlerName(h), name))
+				continue
+			}
+			e.Obj.SetOwnerReferences(ownerRef)
+			if err := r.itemCheck(e); err != nil {
+				errs = appendError(logger, errs, "Failed to create", e, err)
+			} else if err := r.rm.Create(ctx, e); err != nil {
+				errs = appendError(logger, errs, "Failed to create", e, err)
+			} else {
+				logger.Info("Created", objectLogValues(e)...)
+				reconciled = append(reconciled, e)
+			}
+			continue
+		}
+
+		// Object is both expected and observed - update it if needed
+		reconciled = append(reconciled, *o)
+		if e.Lifecycle == reconciler.LifecycleReferred {
+			continue
+		}
+		if err := r.itemCheck(e); err != nil {
+			errs = appendError(logger, errs, "Failed to update", e, err)
+			continue
+		}
+		canUpdate := e.Lifecycle != reconciler.LifecycleNoUpdate
+		// The handler is not expected to mutate e based on o
+		handlerDiffers := true
+		if d, ok := h.(differsHandler); ok {
+			handlerDiffers = d.Differs(e, *o)
+		}
+		// The resource manager mutates e based on o. Objects that are never updated aren't compared, so that
+		// e.g. a password generated on each reconciliation isn't reported as drift.
+		specDiffers := false
+		if canUpdate {
+			specDiffers = r.rm.SpecDiffers(ctx, &e, o)
+		} else {
+			k8s.CopyMutatedSpecFields(&e, o)
+		}
+		refChanged := e.Obj.SetOwnerReferences(ownerRef)
+		if canUpdate && specDiffers && handlerDiffers || refChanged {
+			if err := r.rm.Update(ctx, e); err != nil {
+				errs = appendError(logger, errs, "Failed to update", e, err)
+			} else {
+				logger.Info("Updated", objectLogValues(e)...)
+			}
+		} else {
+			logger.V(1).Info("Unchanged", objectLogValues(e)...)
+		}
+	}
+
+	for _, o := range observed {
+		if o.Lifecycle == reconciler.LifecycleDecorate {
+			if !o.Update {
+				continue
+			}
+			if err := r.itemCheck(o); err != nil {
+				errs = appendError(logger, errs, "Failed to decorate", o, err)
+			} else if err := r.rm.Update(ctx, o); err != nil {
+				errs = appendError(logger, errs, "Failed to decorate", o, err)
+			} else {
+				logger.Info("Decorated", objectLogValues(o)...)
+			}
+			continue
+		}
+		seen := false
+		for _, e := range expected {
+			if e.Type == o.Type && e.Obj.IsSameAs(o.Obj) {
+				seen = true
+				break
+			}
+		}
+		// Object is observed but not expected - delete
+		if seen {
+			continue
+		}
+		if err := r.itemCheck(o); err != nil {
+			errs = appendError(logger, errs, "Failed to delete", o, err)
+		} else if err := r.rm.Delete(ctx, o); err != nil {
+			errs = appendError(logger, errs, "Failed to delete", o, err)
+		} else {
+			logger.Info("Deleted", objectLogValues(o)...)
+		}
+	}
+
+	err = utilerrors.NewAggregate(errs)
+	if s, ok := h.(statusHandler); ok {
+		return s.UpdateStatus(master, reconciled, err), err
+	}
+	return 0, err
+}
+
+// finalizeUsing finalizes CDAPMaster with the handler. Handlers without Finalize remove the cleanup finalizer,
+// leaving the deletion of their objects to the garbage collector.
+func (r *Reconciler) finalizeUsing(ctx context.Context, h Handler, master *v1alpha1.CDAPMaster) error {
+	logger := log.FromContext(ctx)
+	_, observed, dependent, stage, err := r.observeAndMutate(ctx, h, master)
+	if err != nil {
+		logger.Error(err, "Failed to finalize", "stage", stage)
+	}
+
+	f, ok := h.(finalizeHandler)
+	if !ok {
+		controllerutil.RemoveFinalizer(master, finalizerCleanup)
+		return nil
+	}
+	if err := f.Finalize(master, observed, dependent); err != nil {
+		logger.Error(err, "Failed to finalize")
+		return err
+	}
+	for _, o := range observed {
+		if !o.Delete {
+			continue
+		}
+		if err := r.itemCheck(o); err != nil {
+			return err
+		}
+		if err := r.rm.Delete(ctx, o); err != nil {
+			logger.Error(err, "Failed to delete", objectLogValues(o)...)
+			return err
+		}
+		logger.Info("Deleted", objectLogValues(o)...)
+	}
+	return nil
+}
+
+// itemCheck returns an error if the object is not handled by the resource manager
+func (r *Reconciler) itemCheck(o reconciler.Object) error {
+	if o.Type != k8s.Type {
+		return fmt.Errorf("resource manager not registered for type: %s", o.Type)
+	}
+	return nil
+}
+
+func appendError(logger logr.Logger, errs []error, msg string, o reconciler.Object, err error) []error {
+	logger.Error(err, msg, objectLogValues(o)...)
+	return append(errs, err)
+}
+
+// objectLogValues returns the log keys identifying the object
+func objectLogValues(o reconciler.Object) []interface{} {
+	if ko, ok := o.Obj.(*k8s.Object); ok {
+		return []interface{}{"kind", ko.Kind(), "object", ko.Obj.GetName()}
+	}
+	return []interface{}{"object", o.Obj.GetName()}
+}
+
+// handlerName returns the package qualified type name of the handler, e.g. controllers.ServiceHandler
+func handlerName(h Handler) string {
+	return strings.Trim(reflect.TypeOf(h).String(), "*")
+}
+
+// handlerLabels returns the labels identifying the objects of the handler for CDAPMaster
+func handlerLabels(master *v1alpha1.CDAPMaster, h Handler) map[string]string {
+	return map[string]string{
+		labelResource:          strings.Trim(reflect.TypeOf(master).String(), "*"),
+		labelResourceName:      master.Name,
+		labelResourceNamespace: master.Namespace,
+		labelUsing:             handlerName(h),
+	}
+}
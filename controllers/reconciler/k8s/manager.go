@@ -0,0 +1,410 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"cdap.io/cdap-operator/controllers/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// RsrcManager observes, creates, updates and deletes k8s objects
+type RsrcManager struct {
+	client client.Client
+	scheme *runtime.Scheme
+	// fieldManager enables server-side apply when set. Objects are applied with this field manager and
+	// only updated when the fields it owns differ from the observed ones.
+	fieldManager string
+	// legacyFieldManagers updated the objects before server-side apply was enabled. Their fields are moved to
+	// fieldManager, so that fields which are no longer applied are removed.
+	legacyFieldManagers []string
+	// recorder, if set, records an event on objects whose applied fields were changed by someone else.
+	recorder record.EventRecorder
+}
+
+// NewRsrcManager returns a manager of the k8s objects known to the scheme
+func NewRsrcManager(c client.Client, s *runtime.Scheme) *RsrcManager {
+	return &RsrcManager{
+		client: c,
+		scheme: s,
+	}
+}
+
+// WithFieldManager enables server-side apply with the field manager
+func (rm *RsrcManager) WithFieldManager(v string) *RsrcManager {
+	rm.fieldManager = v
+	return rm
+}
+
+// WithLegacyFieldManagers sets the field managers that updated the objects before server-side apply
+func (rm *RsrcManager) WithLegacyFieldManagers(v ...string) *RsrcManager {
+	rm.legacyFieldManagers = v
+	return rm
+}
+
+// WithEventRecorder adds the recorder for drift events
+func (rm *RsrcManager) WithEventRecorder(v record.EventRecorder) *RsrcManager {
+	rm.recorder = v
+	return rm
+}
+
+// ObservablesFromObjects returns the observables of the objects in the bag. Objects with a list kind are
+// observed by listing all the objects of that kind with the labels, other objects are fetched by name.
+func (rm *RsrcManager) ObservablesFromObjects(bag []reconciler.Object, labels map[string]string) []reconciler.Observable {
+	var observables []reconciler.Observable
+	gkmap := map[schema.GroupKind]struct{}{}
+	for _, item := range bag {
+		if item.Type != Type {
+			continue
+		}
+		obj, ok := item.Obj.(*Object)
+		if !ok {
+			continue
+		}
+		if obj.ObjList == nil {
+			observables = append(observables, reconciler.Observable{Type: Type, Obj: Observable{Obj: obj.Obj}})
+			continue
+		}
+		ro := obj.Obj.(client.Object)
+		gk := ro.GetObjectKind().GroupVersionKind().GroupKind()
+		// Expect only 1 kind. If there is more than one kind this is probably an edge case such as ListOptions.
+		if kinds, _, err := rm.scheme.ObjectKinds(ro); err == nil && len(kinds) == 1 {
+			gk = kinds[0].GroupKind()
+		}
+		if _, ok := gkmap[gk]; !ok {
+			gkmap[gk] = struct{}{}
+			observables = append(observables, NewObservable(obj.ObjList.(client.ObjectList), labels))
+		}
+	}
+	return observables
+}
+
+// Observe returns the objects matching the observables
+func (rm *RsrcManager) Observe(ctx context.Context, observables ...reconciler.Observable) ([]reconciler.Object, error) {
+	var observed []reconciler.Object
+	for _, item := range observables {
+		obs, ok := item.Obj.(Observable)
+		if !ok {
+			continue
+		}
+		if obs.Labels != nil {
+			if err := rm.client.List(ctx, obs.ObjList, client.MatchingLabels(obs.Labels)); err != nil {
+				return nil, err
+			}
+			items, err := meta.ExtractList(obs.ObjList)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				observed = append(observed, reconciler.Object{Type: Type, Obj: &Object{Obj: item.(metav1.Object)}})
+			}
+			continue
+		}
+		obj := obs.Obj.(client.Object)
+		if err := rm.client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", (&Object{Obj: obj}).GetName(), err)
+		}
+		observed = append(observed, reconciler.Object{Type: Type, Obj: &Object{Obj: obj}})
+	}
+	return observed, nil
+}
+
+// SpecDiffers - check if the spec part differs
+func (rm *RsrcManager) SpecDiffers(ctx context.Context, expected, observed *reconciler.Object) bool {
+	e := expected.Obj.(*Object)
+	o := observed.Obj.(*Object)
+
+	if rm.fieldManager != "" {
+		if err := rm.upgradeManagedFields(ctx, o.Obj.(client.Object)); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to upgrade managed fields", "kind", o.Kind(), "object", o.Obj.GetName())
+		}
+	}
+	CopyMutatedSpecFields(expected, observed)
+
+	if rm.fieldManager != "" {
+		return rm.appliedFieldsDiffer(ctx, e, o)
+	}
+
+	// Not all k8s objects have Spec, e.g. ConfigMap
+	espec := reflect.Indirect(reflect.ValueOf(e.Obj)).FieldByName("Spec")
+	ospec := reflect.Indirect(reflect.ValueOf(o.Obj)).FieldByName("Spec")
+	if !espec.IsValid() {
+		espec = reflect.Indirect(reflect.ValueOf(e.Obj)).FieldByName("Data")
+		ospec = reflect.Indirect(reflect.ValueOf(o.Obj)).FieldByName("Data")
+	}
+	if espec.IsValid() && ospec.IsValid() {
+		if reflect.DeepEqual(espec.Interface(), ospec.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// appliedFieldsDiffer returns whether applying expected would change observed. The apply is dry-run on the
+// server, so that defaulted fields and fields owned by other managers are not reported as differences.
+func (rm *RsrcManager) appliedFieldsDiffer(ctx context.Context, e, o *Object) bool {
+	logger := log.FromContext(ctx).WithValues("kind", e.Kind(), "object", e.Obj.GetName())
+	applied, err := rm.applyConfiguration(e.Obj.(client.Object))
+	if err == nil {
+		err = rm.client.Patch(ctx, applied, client.Apply, client.FieldOwner(rm.fieldManager), client.ForceOwnership, client.DryRunAll)
+	}
+	if err != nil {
+		// Let the actual apply surface the error
+		logger.Error(err, "Failed to dry-run apply")
+		return true
+	}
+	// Compare typed objects, so that both have the same representation, e.g. of quantities
+	var result runtime.Object = applied
+	if _, ok := o.Obj.(*unstructured.Unstructured); !ok {
+		result = reflect.New(reflect.TypeOf(o.Obj).Elem()).Interface().(runtime.Object)
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, result)
+	}
+	var drifted []string
+	if err == nil {
+		drifted, err = DriftedFields(result, o.Obj.(runtime.Object))
+	}
+	if err != nil {
+		logger.Error(err, "Failed to compare applied fields")
+		return true
+	}
+	if len(drifted) == 0 {
+		return false
+	}
+	logger.Info("Drift detected", "fields", drifted)
+	if rm.recorder != nil {
+		rm.recorder.Eventf(o.Obj.(runtime.Object), corev1.EventTypeNormal, "DriftDetected",
+			"Fields managed by %s differ from the desired state: %s", rm.fieldManager, strings.Join(drifted, ", "))
+	}
+	return true
+}
+
+// applyConfiguration returns the object as an apply configuration, which only holds the desired state
+func (rm *RsrcManager) applyConfiguration(obj client.Object) (*unstructured.Unstructured, error) {
+	gvk, err := apiutil.GVKForObject(obj, rm.scheme)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	applied := &unstructured.Unstructured{Object: content}
+	applied.SetGroupVersionKind(gvk)
+	applied.SetResourceVersion("")
+	applied.SetManagedFields(nil)
+	applied.SetCreationTimestamp(metav1.Time{})
+	delete(applied.Object, "status")
+	return applied, nil
+}
+
+// upgradeManagedFields moves the fields owned by the legacy field managers to the field manager. Fields set with
+// an update stay owned by its manager when they are applied with the same value, so they would never be removed
+// once they are no longer applied. The object is updated with the patched managed fields.
+func (rm *RsrcManager) upgradeManagedFields(ctx context.Context, obj client.Object) error {
+	managedFields, changed, err := UpgradeManagedFields(obj.GetManagedFields(), rm.fieldManager, rm.legacyFieldManagers)
+	if err != nil || !changed {
+		return err
+	}
+	// The test fails the patch if the object changed since it was observed, it is then upgraded on the next reconcile
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": obj.GetResourceVersion()},
+		{"op": "replace", "path": "/metadata/managedFields", "value": managedFields},
+	})
+	if err != nil {
+		return err
+	}
+	return rm.client.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, patch))
+}
+
+// UpgradeManagedFields returns the managed fields with the fields of the update operations of the legacy managers
+// merged into the apply operation of the manager, and whether any fields were moved.
+func UpgradeManagedFields(managedFields []metav1.ManagedFieldsEntry, manager string, legacyManagers []string) ([]metav1.ManagedFieldsEntry, bool, error) {
+	isLegacy := func(entry metav1.ManagedFieldsEntry) bool {
+		if entry.Operation != metav1.ManagedFieldsOperationUpdate || entry.Subresource != "" {
+			return false
+		}
+		for _, m := range legacyManagers {
+			if entry.Manager == m {
+				return true
+			}
+		}
+		return false
+	}
+	var upgraded []metav1.ManagedFieldsEntry
+	var legacy []metav1.ManagedFieldsEntry
+	applied := -1
+	for _, entry := range managedFields {
+		if isLegacy(entry) {
+			legacy = append(legacy, entry)
+			continue
+		}
+		if entry.Manager == manager && entry.Operation == metav1.ManagedFieldsOperationApply && entry.Subresource == "" {
+			applied = len(upgraded)
+		}
+		upgraded = append(upgraded, entry)
+	}
+	if len(legacy) == 0 {
+		return managedFields, false, nil
+	}
+	if applied < 0 {
+		upgraded = append(upgraded, metav1.ManagedFieldsEntry{
+			Manager:    manager,
+			Operation:  metav1.ManagedFieldsOperationApply,
+			APIVersion: legacy[0].APIVersion,
+			Time:       legacy[0].Time,
+			FieldsType: "FieldsV1",
+		})
+		applied = len(upgraded) - 1
+	}
+	fields := &fieldpath.Set{}
+	for _, entry := range append([]metav1.ManagedFieldsEntry{upgraded[applied]}, legacy...) {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		set := &fieldpath.Set{}
+		if err := set.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+			return nil, false, fmt.Errorf("failed to parse fields of manager %s: %w", entry.Manager, err)
+		}
+		fields = fields.Union(set)
+	}
+	raw, err := fields.ToJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	upgraded[applied].FieldsV1 = &metav1.FieldsV1{Raw: raw}
+	return upgraded, true, nil
+}
+
+// apply creates or updates the object with server-side apply
+func (rm *RsrcManager) apply(ctx context.Context, obj client.Object) error {
+	applied, err := rm.applyConfiguration(obj)
+	if err != nil {
+		return err
+	}
+	if err := rm.client.Patch(ctx, applied, client.Apply, client.FieldOwner(rm.fieldManager), client.ForceOwnership); err != nil {
+		return err
+	}
+	// Reflect the server state, e.g. the resource version, in the object
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.Object = applied.Object
+		return nil
+	}
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, obj)
+}
+
+// DriftedFields returns the paths of the fields that differ between the objects, ignoring status and the
+// metadata maintained by the server.
+func DriftedFields(expected, observed runtime.Object) ([]string, error) {
+	e, err := comparableContent(expected)
+	if err != nil {
+		return nil, err
+	}
+	o, err := comparableContent(observed)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	diffPaths("", e, o, &paths)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func comparableContent(obj runtime.Object) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	delete(content, "apiVersion")
+	delete(content, "kind")
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"resourceVersion", "generation", "managedFields", "creationTimestamp", "uid", "selfLink"} {
+			delete(metadata, field)
+		}
+	}
+	return content, nil
+}
+
+// diffPaths appends the paths under prefix whose values differ
+func diffPaths(prefix string, a, b interface{}, paths *[]string) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]struct{}{}
+		for k := range av {
+			keys[k] = struct{}{}
+		}
+		for k := range bv {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			diffPaths(path, av[k], bv[k], paths)
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			break
+		}
+		for i := range av {
+			diffPaths(fmt.Sprintf("%s[%d]", prefix, i), av[i], bv[i], paths)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*paths = append(*paths, prefix)
+	}
+}
+
+// Create creates the object, or applies it when a field manager is set
+func (rm *RsrcManager) Create(ctx context.Context, item reconciler.Object) error {
+	obj := item.Obj.(*Object).Obj.(client.Object)
+	if rm.fieldManager != "" {
+		return rm.apply(ctx, obj)
+	}
+	return rm.client.Create(ctx, obj)
+}
+
+// Update updates the object, or applies it when a field manager is set
+func (rm *RsrcManager) Update(ctx context.Context, item reconciler.Object) error {
+	obj := item.Obj.(*Object).Obj.(client.Object)
+	if rm.fieldManager != "" {
+		return rm.apply(ctx, obj)
+	}
+	return rm.client.Update(ctx, obj)
+}
+
+// Delete deletes the object along with its dependents
+func (rm *RsrcManager) Delete(ctx context.Context, item reconciler.Object) error {
+	return rm.client.Delete(ctx, item.Obj.(*Object).Obj.(client.Object), client.PropagationPolicy(metav1.DeletePropagationForeground))
+}
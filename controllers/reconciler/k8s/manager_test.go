@@ -0,0 +1,203 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cdap.io/cdap-operator/controllers/reconciler"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testFieldManager       = "cdap-operator"
+	testLegacyFieldManager = "manager"
+)
+
+func fieldsV1(raw string) *metav1.FieldsV1 {
+	return &metav1.FieldsV1{Raw: []byte(raw)}
+}
+
+func newTestStatefulSet() *appsv1.StatefulSet {
+	replicas := int32(1)
+	return &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cdap-test-appfabric",
+			Namespace: "default",
+			Labels:    map[string]string{"cdap.instance": "test"},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "appfabric", Image: "cdap:latest"}},
+				},
+			},
+		},
+	}
+}
+
+func TestDriftedFields(t *testing.T) {
+	testCases := []struct {
+		description string
+		// drift modifies the observed object.
+		drift func(observed *appsv1.StatefulSet)
+		// unstructured compares the expected object in its unstructured form.
+		unstructured bool
+		want         []string
+	}{
+		{
+			description: "server_maintained_fields_and_status_ignored",
+			drift: func(observed *appsv1.StatefulSet) {
+				observed.ResourceVersion = "42"
+				observed.Generation = 3
+				observed.UID = "uid"
+				observed.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: testFieldManager}}
+				observed.Status.ReadyReplicas = 1
+			},
+		},
+		{
+			description: "changed_fields_reported",
+			drift: func(observed *appsv1.StatefulSet) {
+				replicas := *observed.Spec.Replicas + 1
+				observed.Spec.Replicas = &replicas
+				observed.Spec.Template.Spec.Containers[0].Image = "cdap:drifted"
+				observed.Labels["drifted"] = "true"
+			},
+			want: []string{
+				"metadata.labels.drifted",
+				"spec.replicas",
+				"spec.template.spec.containers[0].image",
+			},
+		},
+		{
+			description: "typed_and_unstructured_objects_equal",
+			drift: func(observed *appsv1.StatefulSet) {
+				observed.Status.ReadyReplicas = 1
+			},
+			unstructured: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var expected runtime.Object = newTestStatefulSet()
+			if tc.unstructured {
+				content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+				if err != nil {
+					t.Fatalf("Failed to convert to unstructured: %v", err)
+				}
+				expected = &unstructured.Unstructured{Object: content}
+			}
+			observed := newTestStatefulSet()
+			tc.drift(observed)
+
+			got, err := DriftedFields(expected, observed)
+			if err != nil {
+				t.Fatalf("DriftedFields() returned unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("DriftedFields() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeManagedFields(t *testing.T) {
+	legacy := metav1.ManagedFieldsEntry{Manager: testLegacyFieldManager, Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:data":{".":{},"f:removed":{},"f:value":{}}}`)}
+	applied := metav1.ManagedFieldsEntry{Manager: testFieldManager, Operation: metav1.ManagedFieldsOperationApply, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:data":{"f:value":{}}}`)}
+	edited := metav1.ManagedFieldsEntry{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:metadata":{"f:labels":{"f:edited":{}}}}`)}
+	upgraded := metav1.ManagedFieldsEntry{Manager: testFieldManager, Operation: metav1.ManagedFieldsOperationApply, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:data":{".":{},"f:removed":{},"f:value":{}}}`)}
+	status := metav1.ManagedFieldsEntry{Manager: testLegacyFieldManager, Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:status":{}}`), Subresource: "status"}
+
+	testCases := []struct {
+		description   string
+		managedFields []metav1.ManagedFieldsEntry
+		want          []metav1.ManagedFieldsEntry
+		wantChanged   bool
+	}{
+		{
+			description:   "legacy_fields_merged_into_apply",
+			managedFields: []metav1.ManagedFieldsEntry{legacy, applied, edited},
+			want:          []metav1.ManagedFieldsEntry{upgraded, edited},
+			wantChanged:   true,
+		},
+		{
+			description:   "apply_entry_added",
+			managedFields: []metav1.ManagedFieldsEntry{legacy, edited},
+			want:          []metav1.ManagedFieldsEntry{edited, upgraded},
+			wantChanged:   true,
+		},
+		{
+			description:   "nothing_to_move_once_upgraded",
+			managedFields: []metav1.ManagedFieldsEntry{upgraded, edited},
+			want:          []metav1.ManagedFieldsEntry{upgraded, edited},
+		},
+		{
+			description:   "subresource_fields_kept",
+			managedFields: []metav1.ManagedFieldsEntry{applied, status},
+			want:          []metav1.ManagedFieldsEntry{applied, status},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, changed, err := UpgradeManagedFields(tc.managedFields, testFieldManager, []string{testLegacyFieldManager})
+			if err != nil {
+				t.Fatalf("UpgradeManagedFields() returned unexpected error: %v", err)
+			}
+			if changed != tc.wantChanged {
+				t.Errorf("UpgradeManagedFields() changed = %v, want %v", changed, tc.wantChanged)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("UpgradeManagedFields() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSpecDiffersUpgradesManagedFields(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cdap-test-a",
+			Namespace: "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: testLegacyFieldManager, Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:data":{".":{},"f:removed":{},"f:value":{}}}`)},
+				{Manager: testFieldManager, Operation: metav1.ManagedFieldsOperationApply, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: fieldsV1(`{"f:data":{"f:value":{}}}`)},
+			},
+		},
+		Data: map[string]string{"value": "a", "removed": "b"},
+	}
+	if err := c.Create(ctx, configMap); err != nil {
+		t.Fatalf("Failed to create ConfigMap: %v", err)
+	}
+	rm := NewRsrcManager(c, c.Scheme()).WithFieldManager(testFieldManager).WithLegacyFieldManagers(testLegacyFieldManager)
+	expected := reconciler.Object{Type: Type, Obj: &Object{Obj: &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMap.Name, Namespace: configMap.Namespace},
+		Data:       map[string]string{"value": "a"},
+	}}}
+	observed := reconciler.Object{Type: Type, Obj: &Object{Obj: configMap}}
+	rm.SpecDiffers(ctx, &expected, &observed)
+
+	// Only the field manager owns the removed field, so it is removed by the next apply, which doesn't set it
+	if err := c.Get(ctx, client.ObjectKeyFromObject(configMap), configMap); err != nil {
+		t.Fatalf("Failed to get ConfigMap: %v", err)
+	}
+	managedFields := configMap.GetManagedFields()
+	if len(managedFields) != 1 || managedFields[0].Manager != testFieldManager || managedFields[0].Operation != metav1.ManagedFieldsOperationApply {
+		t.Fatalf("SpecDiffers() didn't move the legacy fields to the field manager: %+v", managedFields)
+	}
+	if got, want := string(managedFields[0].FieldsV1.Raw), `{"f:data":{".":{},"f:removed":{},"f:value":{}}}`; got != want {
+		t.Errorf("SpecDiffers() upgraded the managed fields to %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,280 @@
+// Package k8s holds the Kubernetes objects handled by the CDAPMaster reconciler and the manager that creates,
+// updates and deletes them.
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"cdap.io/cdap-operator/controllers/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Type is the type of the objects and observables handled by RsrcManager
+const Type = "k8s"
+
+// Object - K8s object
+type Object struct {
+	// Obj refers to the resource object  can be: sts, service, secret, pvc, ..
+	Obj metav1.Object
+	// ObjList refers to the list of resource objects
+	ObjList metav1.ListInterface
+}
+
+// Observable captures the k8s resource info and selector to fetch child resources
+type Observable struct {
+	// ObjList refers to the list of resource objects
+	ObjList client.ObjectList
+	// Obj refers to the resource object  can be: sts, service, secret, pvc, ..
+	Obj metav1.Object
+	// Labels list of labels
+	Labels map[string]string
+}
+
+func isReferringSameObject(a, b metav1.OwnerReference) bool {
+	aGV, err := schema.ParseGroupVersion(a.APIVersion)
+	if err != nil {
+		return false
+	}
+	bGV, err := schema.ParseGroupVersion(b.APIVersion)
+	if err != nil {
+		return false
+	}
+	return aGV == bGV && a.Kind == b.Kind && a.Name == b.Name
+}
+
+// needUpdateOwnerRefKindFormat returns whether 2 owner refs refer to
+// the same Object, but with owner Kind in different formats.
+func needUpdateOwnerRefKindFormat(a, b metav1.OwnerReference) bool {
+	// Check for referrences to different Objects
+	if a.APIVersion != b.APIVersion || a.Name != b.Name {
+		return false
+	}
+	// Check if Kinds are equivalent.
+	verNumA := versionNumber(a.APIVersion)
+	verNumB := versionNumber(b.APIVersion)
+	if !areKindsEquivalent(a.Kind, verNumA, b.Kind, verNumB) {
+		return false
+	}
+	// If Kinds already in same format, no need to update
+	return a.Kind != b.Kind
+}
+
+// versionNumber returns the version number for api
+// version name of format <api-name>/<version-number>, eg: v1alpha1/Abc
+func versionNumber(apiVersion string) string {
+	parts := strings.Split(apiVersion, "/")
+	return parts[len(parts)-1]
+}
+
+// areKindsEquivalent returns whether two kinds are equivalent,
+// but in possibly different formats.
+// Possible formats:
+// 1) *<version>.<kind>, eg: *v1alpha1.CronJob
+// 2) <kind>, eg: CronJob
+func areKindsEquivalent(a, versionA, b, versionB string) bool {
+	if versionA != versionB {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	if len(a) < len(b) {
+		return areKindsEquivalent(b, versionB, a, versionA)
+	}
+	// a is longer, possibly in format 1.
+	return a == fmt.Sprintf("*%s.%s", versionB, b)
+}
+
+// SetLabels - set labels
+func (o *Object) SetLabels(labels map[string]string) {
+	o.Obj.SetLabels(labels)
+}
+
+// SetOwnerReferences adds the owner reference, and returns whether the owner references changed
+func (o *Object) SetOwnerReferences(ref *metav1.OwnerReference) bool {
+	if ref == nil {
+		return false
+	}
+	objRefs := o.Obj.GetOwnerReferences()
+	for idx, r := range objRefs {
+		if isReferringSameObject(*ref, r) {
+			return false
+		}
+		// If the owner refs point to the same object,
+		// but with different Kind format,  we need to replace
+		// the existing owner ref as K8s doesn't allow multiple owners
+		//  with controller field set to true.
+		// Replacement of owner refs is required since
+		// kind format *<api-version>.<kind> (eg: *v1aplha1.Abc)
+		// is no longer identified by K8s version 1.20 onwards.
+		if needUpdateOwnerRefKindFormat(*ref, r) {
+			objRefs[idx] = *ref
+			o.Obj.SetOwnerReferences(objRefs)
+			return true
+		}
+	}
+	objRefs = append(objRefs, *ref)
+	o.Obj.SetOwnerReferences(objRefs)
+	return true
+}
+
+// IsSameAs returns whether both objects have the same kind, namespace and name
+func (o *Object) IsSameAs(a interface{}) bool {
+	e := a.(*Object)
+	return e.Obj.GetName() == o.Obj.GetName() &&
+		e.Obj.GetNamespace() == o.Obj.GetNamespace() &&
+		reflect.TypeOf(e.Obj).String() == reflect.TypeOf(o.Obj).String()
+}
+
+// GetName returns the namespace, kind and name of the object
+func (o *Object) GetName() string {
+	return o.Obj.GetNamespace() + "/" + reflect.TypeOf(o.Obj).String() + "/" + o.Obj.GetName()
+}
+
+// itemFromReader reads Object from []byte spec
+func itemFromReader(name string, b *bufio.Reader, data interface{}, list metav1.ListInterface) (*reconciler.Object, error) {
+	doc, err := yaml.NewYAMLReader(b).Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", name, err)
+	}
+	tmpl, err := template.New("tmpl").Parse(string(doc))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", name, err)
+	}
+	var exdoc bytes.Buffer
+	if err := tmpl.Execute(&exdoc, data); err != nil {
+		return nil, fmt.Errorf("%s:%v", name, err)
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(exdoc.Bytes(), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", name, err)
+	}
+	return &reconciler.Object{
+		Type:      Type,
+		Lifecycle: reconciler.LifecycleManaged,
+		Obj: &Object{
+			Obj:     obj.(metav1.Object),
+			ObjList: list,
+		},
+	}, nil
+}
+
+// ObjectFromFile populates Object from the template file executed with values
+func ObjectFromFile(path string, values interface{}, list metav1.ListInterface) (*reconciler.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return itemFromReader(path, bufio.NewReader(f), values, list)
+}
+
+// ReferredItem returns a reffered object
+func ReferredItem(obj metav1.Object, name, namespace string) reconciler.Object {
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	return reconciler.Object{
+		Lifecycle: reconciler.LifecycleReferred,
+		Type:      Type,
+		Obj:       &Object{Obj: obj},
+	}
+}
+
+// GetItem returns an item which matched the kind and name
+func GetItem(b []reconciler.Object, inobj metav1.Object, name, namespace string) metav1.Object {
+	inobj.SetName(name)
+	inobj.SetNamespace(namespace)
+	intype := reflect.TypeOf(inobj).String()
+	for _, item := range reconciler.ObjectsByType(b, Type) {
+		obj := item.Obj.(*Object)
+		if reflect.TypeOf(obj.Obj).String() == intype && obj.Obj.GetName() == name && obj.Obj.GetNamespace() == namespace {
+			return obj.Obj
+		}
+	}
+	return nil
+}
+
+// CopyMutatedSpecFields - copy known mutated fields from observed to expected
+func CopyMutatedSpecFields(to *reconciler.Object, from *reconciler.Object) {
+	e := to.Obj.(*Object)
+	o := from.Obj.(*Object)
+	e.Obj.SetOwnerReferences(o.Obj.GetOwnerReferences())
+	e.Obj.SetResourceVersion(o.Obj.GetResourceVersion())
+	switch eobj := e.Obj.(type) {
+	case *corev1.Service:
+		eobj.Spec.ClusterIP = o.Obj.(*corev1.Service).Spec.ClusterIP
+	case *corev1.PersistentVolumeClaim:
+		eobj.Spec.StorageClassName = o.Obj.(*corev1.PersistentVolumeClaim).Spec.StorageClassName
+	}
+}
+
+// --------------------- Observables -------------------------------
+
+// Observables builds the observables of a handler
+type Observables struct {
+	observables []reconciler.Observable
+	labels      reconciler.KVMap
+}
+
+// NewObservables - observables
+func NewObservables() *Observables {
+	return &Observables{
+		observables: []reconciler.Observable{},
+	}
+}
+
+// WithLabels - inject labels
+func (o *Observables) WithLabels(labels reconciler.KVMap) *Observables {
+	o.labels = labels
+	return o
+}
+
+// For - add the objects of the list kind with the labels
+func (o *Observables) For(list client.ObjectList) *Observables {
+	o.observables = append(o.observables, NewObservable(list, o.labels))
+	return o
+}
+
+// Add - add
+func (o *Observables) Add(obs reconciler.Observable) *Observables {
+	o.observables = append(o.observables, obs)
+	return o
+}
+
+// Get - return observable array
+func (o *Observables) Get() []reconciler.Observable {
+	return o.observables
+}
+
+// NewObservable returns an observable for the objects of the list kind with the labels
+func NewObservable(list client.ObjectList, labels map[string]string) reconciler.Observable {
+	return reconciler.Observable{
+		Type: Type,
+		Obj: Observable{
+			ObjList: list,
+			Labels:  labels,
+		},
+	}
+}
+
+// Kind returns the kind of the object, falling back to its Go type name when the type meta is not set
+func (o *Object) Kind() string {
+	if ro, ok := o.Obj.(runtime.Object); ok {
+		if kind := ro.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+			return kind
+		}
+	}
+	return reflect.Indirect(reflect.ValueOf(o.Obj)).Type().Name()
+}
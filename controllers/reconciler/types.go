@@ -0,0 +1,107 @@
+// Package reconciler defines the objects exchanged between the CDAPMaster reconciler and its handlers.
+package reconciler
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Object lifecycles
+const (
+	LifecycleManaged  = "managed"  // CRUD
+	LifecycleReferred = "referred" // R
+	LifecycleNoUpdate = "noupdate" // CRD
+	LifecycleDecorate = "decorate" // RU
+)
+
+// Password char space
+const (
+	PasswordCharNumSpace = "abcdefghijklmnopqrstuvwxyz0123456789"
+	PasswordCharSpace    = "abcdefghijklmnopqrstuvwxyz"
+)
+
+// ObjectInterface is implemented by the wrappers of the objects held by Object
+type ObjectInterface interface {
+	GetName() string
+	IsSameAs(interface{}) bool
+	SetOwnerReferences(*metav1.OwnerReference) bool
+	SetLabels(labels map[string]string)
+}
+
+// Object is a container to capture the k8s resource info to be used by controller
+type Object struct {
+	// Lifecycle can be: managed, referred, noupdate, decorate
+	Lifecycle string
+	// Type - object type
+	Type string
+	// Obj -  object
+	Obj ObjectInterface
+	// Delete - marker for deletion
+	Delete bool
+	// Update - marker for update
+	Update bool
+}
+
+// Observable captures the k8s resource info and selector to fetch child resources
+type Observable struct {
+	// Type - object type
+	Type string
+	// Obj - object
+	Obj interface{}
+}
+
+// KVMap is a map[string]string
+type KVMap map[string]string
+
+// Merge is used to merge multiple maps into the target map
+func (out KVMap) Merge(kvmaps ...KVMap) {
+	for _, kvmap := range kvmaps {
+		for k, v := range kvmap {
+			out[k] = v
+		}
+	}
+}
+
+// RandomAlphanumericString generates a random password of some fixed length, which starts with a letter. The
+// characters are drawn uniformly from a cryptographically secure source, so that the password can't be predicted.
+func RandomAlphanumericString(strlen int) (string, error) {
+	result := make([]byte, strlen)
+	for i := range result {
+		space := PasswordCharNumSpace
+		if i == 0 {
+			space = PasswordCharSpace
+		}
+		// rand.Int rejects the samples beyond the largest multiple of the space, so all characters are equally likely
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(space))))
+		if err != nil {
+			return "", err
+		}
+		result[i] = space[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// NoUpdate - set lifecycle to noupdate
+// Manage by Create Only, Delete.
+func NoUpdate(o *Object, v interface{}) {
+	o.Lifecycle = LifecycleNoUpdate
+}
+
+// DecorateOnly - set lifecycle to decorate
+// Manage by update only. Dont create or delete
+func DecorateOnly(o *Object, v interface{}) {
+	o.Lifecycle = LifecycleDecorate
+}
+
+// ObjectsByType get items from the Object bag
+func ObjectsByType(in []Object, t string) []Object {
+	var out []Object
+	for _, item := range in {
+		if item.Type == t {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package reconciler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandomAlphanumericString(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		s, err := RandomAlphanumericString(32)
+		if err != nil {
+			t.Fatalf("RandomAlphanumericString() failed: %v", err)
+		}
+		if len(s) != 32 {
+			t.Errorf("RandomAlphanumericString() = %q, want 32 characters", s)
+		}
+		if !strings.ContainsRune(PasswordCharSpace, rune(s[0])) {
+			t.Errorf("RandomAlphanumericString() = %q, want a letter first", s)
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(PasswordCharNumSpace, c) {
+				t.Errorf("RandomAlphanumericString() = %q, has unexpected character %q", s, c)
+			}
+		}
+		if seen[s] {
+			t.Errorf("RandomAlphanumericString() = %q, generated twice", s)
+		}
+		seen[s] = true
+	}
+}
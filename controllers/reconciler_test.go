@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// testConfigMapHandler expects a ConfigMap for each entry of data, and refers to the secrets in referred.
+type testConfigMapHandler struct {
+	data     map[string]string
+	referred []string
+}
+
+func (h *testConfigMapHandler) Observables(rsrc interface{}, labels map[string]string, dependent []reconciler.Object) []reconciler.Observable {
+	return k8s.NewObservables().WithLabels(labels).For(&corev1.ConfigMapList{}).Get()
+}
+
+func (h *testConfigMapHandler) Objects(ctx context.Context, rsrc interface{}, labels map[string]string, observed, dependent, aggregated []reconciler.Object) ([]reconciler.Object, error) {
+	m := rsrc.(*v1alpha1.CDAPMaster)
+	var expected []reconciler.Object
+	for name, value := range h.data {
+		spec := newConfigMapSpec(m, name, labels).AddData("value", value)
+		expected = append(expected, buildConfigMapObject(spec))
+	}
+	for _, name := range h.referred {
+		expected = append(expected, k8s.ReferredItem(&corev1.Secret{}, name, m.Namespace))
+	}
+	return expected, nil
+}
+
+var _ = Describe("Controller Suite", func() {
+	Describe("CDAPMaster reconciler", func() {
+		var (
+			ctx     context.Context
+			c       client.Client
+			master  *v1alpha1.CDAPMaster
+			handler *testConfigMapHandler
+			r       *Reconciler
+		)
+		BeforeEach(func() {
+			ctx = context.Background()
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+			Expect(v1alpha1.AddToScheme(s)).To(Succeed())
+			master = &v1alpha1.CDAPMaster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "uid"}}
+			c = fake.NewClientBuilder().WithScheme(s).WithObjects(master).Build()
+			handler = &testConfigMapHandler{data: map[string]string{"cdap-test-a": "a", "cdap-test-b": "b"}}
+			r = &Reconciler{client: c, rm: k8s.NewRsrcManager(c, s), handlers: []Handler{handler}}
+		})
+		reconcileMaster := func() reconcile.Result {
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(master)})
+			Expect(err).To(BeNil())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(master), master)).To(Succeed())
+			return result
+		}
+
+		It("Creates, updates and deletes the objects of the handler", func() {
+			Expect(reconcileMaster().RequeueAfter).To(Equal(defaultReconcilePeriod))
+			Expect(master.Finalizers).To(ContainElement(finalizerCleanup))
+			Expect(master.Status.IsConditionTrue(v1alpha1.ConditionError)).To(BeFalse())
+			configMap := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cdap-test-a"}, configMap)).To(Succeed())
+			Expect(configMap.Labels).To(Equal(map[string]string{
+				labelResource:          "v1alpha1.CDAPMaster",
+				labelResourceName:      "test",
+				labelResourceNamespace: "default",
+				labelUsing:             "controllers.testConfigMapHandler",
+			}))
+			Expect(configMap.OwnerReferences).To(HaveLen(1))
+			Expect(configMap.OwnerReferences[0].Kind).To(Equal("CDAPMaster"))
+			Expect(*configMap.OwnerReferences[0].Controller).To(BeTrue())
+
+			handler.data = map[string]string{"cdap-test-a": "updated"}
+			reconcileMaster()
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cdap-test-a"}, configMap)).To(Succeed())
+			Expect(configMap.Data).To(Equal(map[string]string{"value": "updated"}))
+			err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cdap-test-b"}, configMap)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+		It("Objects of other handlers are left alone", func() {
+			other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      "cdap-test-other",
+				Namespace: "default",
+				Labels:    handlerLabels(master, &ConfigMapHandler{}),
+			}}
+			Expect(c.Create(ctx, other)).To(Succeed())
+			reconcileMaster()
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+		})
+		It("Missing referred objects are reported in status", func() {
+			handler.referred = []string{"missing"}
+			reconcileMaster()
+			Expect(master.Status.IsConditionTrue(v1alpha1.ConditionError)).To(BeTrue())
+			Expect(master.Status.GetCondition(v1alpha1.ConditionError).Message).To(ContainSubstring("missing resource"))
+		})
+		It("Finalizer is removed on deletion", func() {
+			reconcileMaster()
+			Expect(c.Delete(ctx, master)).To(Succeed())
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(master)})
+			Expect(err).To(BeNil())
+			Expect(result.RequeueAfter).To(Equal(finalizeReconcilePeriod))
+			// CDAPMaster is gone once its last finalizer is removed
+			err = c.Get(ctx, client.ObjectKeyFromObject(master), master)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+		It("CDAPMaster objects of other shards are skipped", func() {
+			r.WithShardSelector(labels.SelectorFromSet(labels.Set{"shard": "a"}))
+			reconcileMaster()
+			Expect(master.Finalizers).To(BeEmpty())
+			configMap := &corev1.ConfigMap{}
+			err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cdap-test-a"}, configMap)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			master.Labels = map[string]string{"shard": "a"}
+			Expect(c.Update(ctx, master)).To(Succeed())
+			reconcileMaster()
+			Expect(master.Finalizers).To(ContainElement(finalizerCleanup))
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cdap-test-a"}, configMap)).To(Succeed())
+		})
+		It("CDAPMaster objects are reconciled concurrently", func() {
+			var masters []*v1alpha1.CDAPMaster
+			for i := 0; i < 8; i++ {
+				m := &v1alpha1.CDAPMaster{ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: fmt.Sprintf("ns-%d", i),
+					UID:       types.UID(fmt.Sprintf("uid-%d", i)),
+				}}
+				Expect(c.Create(ctx, m)).To(Succeed())
+				masters = append(masters, m)
+			}
+			var wg sync.WaitGroup
+			errs := make(chan error, len(masters))
+			for _, m := range masters {
+				wg.Add(1)
+				go func(m *v1alpha1.CDAPMaster) {
+					defer wg.Done()
+					_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(m)})
+					errs <- err
+				}(m)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				Expect(err).To(BeNil())
+			}
+			for _, m := range masters {
+				for _, name := range []string{"cdap-test-a", "cdap-test-b"} {
+					configMap := &corev1.ConfigMap{}
+					Expect(c.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: name}, configMap)).To(Succeed())
+					Expect(configMap.OwnerReferences[0].UID).To(Equal(m.UID))
+				}
+			}
+		})
+		It("Objects are logged with their kind", func() {
+			configMap := &k8s.Object{Obj: &corev1.ConfigMap{}}
+			Expect(configMap.Kind()).To(Equal("ConfigMap"))
+			configMap.Obj.(*corev1.ConfigMap).Kind = "Other"
+			Expect(configMap.Kind()).To(Equal("Other"))
+		})
+	})
+})
@@ -0,0 +1,384 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recommendedServices are the services whose resources are recommended. Their containers are named after the
+// service in lower case, e.g. appfabric.
+var recommendedServices = ServiceGroup{
+	serviceAppFabric, serviceLogs, serviceMessaging, serviceMetadata, serviceMetrics, servicePreview, serviceRuntime,
+	serviceAuthentication, serviceRouter, serviceSupportBundle, serviceTetheringAgent, serviceArtifactCache,
+	serviceUserInterface, serviceSystemMetricsExporter,
+}
+
+// podMetricsListGVK is the kind of the pod metrics list served by the metrics.k8s.io API, e.g. by metrics-server
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+// podMetrics is the CPU and memory usage of the containers of a pod, keyed by container name
+type podMetrics struct {
+	Pod        string
+	Timestamp  time.Time
+	Containers map[string]corev1.ResourceList
+}
+
+// podMetricsLister lists the usage of the pods matching the labels
+type podMetricsLister interface {
+	ListPodMetrics(ctx context.Context, namespace string, labels map[string]string) ([]podMetrics, error)
+}
+
+// metricsAPILister lists pod usage from the metrics.k8s.io API. Pod metrics are read as unstructured objects, so that
+// the operator doesn't depend on the types of the metrics API, which may not be served by the cluster.
+type metricsAPILister struct {
+	reader client.Reader
+}
+
+var _ podMetricsLister = &metricsAPILister{}
+
+func (l *metricsAPILister) ListPodMetrics(ctx context.Context, namespace string, labels map[string]string) ([]podMetrics, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(podMetricsListGVK)
+	if err := l.reader.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels(labels)); err != nil {
+		return nil, err
+	}
+	var metrics []podMetrics
+	for i := range list.Items {
+		m, err := parsePodMetrics(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// parsePodMetrics returns the usage of the containers of a metrics.k8s.io PodMetrics object
+func parsePodMetrics(obj *unstructured.Unstructured) (podMetrics, error) {
+	var pm struct {
+		Timestamp  metav1.Time `json:"timestamp"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pm); err != nil {
+		return podMetrics{}, fmt.Errorf("failed to parse pod metrics %s: %w", obj.GetName(), err)
+	}
+	m := podMetrics{Pod: obj.GetName(), Timestamp: pm.Timestamp.Time, Containers: make(map[string]corev1.ResourceList)}
+	for _, c := range pm.Containers {
+		m.Containers[c.Name] = c.Usage
+	}
+	return m, nil
+}
+
+// usageSample is the usage of a container at a point in time, with CPU in millicores and memory in bytes
+type usageSample struct {
+	time   time.Time
+	cpu    int64
+	memory int64
+}
+
+// containerSamples are the usage samples of a container, along with the time of the latest sample of each pod
+// running it, so that metrics already sampled are skipped
+type containerSamples struct {
+	samples []usageSample
+	latest  map[string]time.Time
+}
+
+// resourceRecommender samples the usage of the service containers of CDAPMaster objects on each reconciliation and
+// recommends their resources from the percentiles of the samples. Samples are kept in memory across reconciliations,
+// so it is safe for concurrent use.
+type resourceRecommender struct {
+	metrics podMetricsLister
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mu sync.Mutex
+	// samples of each CDAPMaster, keyed by container name
+	samples map[types.NamespacedName]map[string]*containerSamples
+}
+
+func newResourceRecommender(metrics podMetricsLister) *resourceRecommender {
+	return &resourceRecommender{
+		metrics: metrics,
+		now:     time.Now,
+		samples: make(map[types.NamespacedName]map[string]*containerSamples),
+	}
+}
+
+// forget drops the samples of CDAPMaster
+func (r *resourceRecommender) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.samples, key)
+}
+
+// recommend samples the usage of the service containers of CDAPMaster and reports the recommended resources in its
+// status. With auto apply, the recommendations are set as the resources of the services during the maintenance
+// window.
+func (r *resourceRecommender) recommend(ctx context.Context, master *v1alpha1.CDAPMaster) error {
+	key := client.ObjectKeyFromObject(master)
+	spec := master.Spec.ResourceRecommendations
+	if spec == nil {
+		r.forget(key)
+		master.Status.ResourceRecommendations = nil
+		return nil
+	}
+
+	// Service pods carry the labels of the handler deploying them
+	metrics, err := r.metrics.ListPodMetrics(ctx, master.Namespace, handlerLabels(master, &ServiceHandler{}))
+	if err != nil {
+		return fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+	window := defaultRecommendationWindow
+	if spec.Window != nil {
+		window = spec.Window.Duration
+	}
+	minSamples := defaultRecommendationMinSamples
+	if spec.MinSamples > 0 {
+		minSamples = spec.MinSamples
+	}
+
+	now := r.now()
+	recommendations := make(map[string]v1alpha1.ResourceRecommendation)
+	for container, samples := range r.addSamples(key, metrics, now.Add(-window)) {
+		if len(samples) >= int(minSamples) {
+			recommendations[container] = recommendResources(spec, samples)
+		}
+	}
+	if len(recommendations) == 0 {
+		recommendations = nil
+	}
+	master.Status.ResourceRecommendations = recommendations
+
+	if spec.AutoApply == nil {
+		return nil
+	}
+	return applyRecommendations(master, now)
+}
+
+// addSamples adds the usage of the service containers to the samples of CDAPMaster, drops the samples taken before
+// since and returns a copy of the remaining ones, keyed by container name
+func (r *resourceRecommender) addSamples(key types.NamespacedName, metrics []podMetrics, since time.Time) map[string][]usageSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	containers, ok := r.samples[key]
+	if !ok {
+		containers = make(map[string]*containerSamples)
+		r.samples[key] = containers
+	}
+	for _, m := range metrics {
+		for name, usage := range m.Containers {
+			// Skip containers not run by the operator, e.g. extra containers
+			if _, ok := getRecommendedService(name); !ok {
+				continue
+			}
+			c, ok := containers[name]
+			if !ok {
+				c = &containerSamples{latest: make(map[string]time.Time)}
+				containers[name] = c
+			}
+			if latest, ok := c.latest[m.Pod]; ok && !m.Timestamp.After(latest) {
+				continue
+			}
+			c.latest[m.Pod] = m.Timestamp
+			c.samples = append(c.samples, usageSample{
+				time:   m.Timestamp,
+				cpu:    usage.Cpu().MilliValue(),
+				memory: usage.Memory().Value(),
+			})
+		}
+	}
+
+	result := make(map[string][]usageSample)
+	for name, c := range containers {
+		c.trim(since)
+		if len(c.samples) == 0 {
+			delete(containers, name)
+			continue
+		}
+		result[name] = append([]usageSample(nil), c.samples...)
+	}
+	return result
+}
+
+// trim drops the samples taken before since, and the oldest ones beyond maxUsageSamples
+func (c *containerSamples) trim(since time.Time) {
+	i := 0
+	for i < len(c.samples) && c.samples[i].time.Before(since) {
+		i++
+	}
+	if n := len(c.samples) - maxUsageSamples; i < n {
+		i = n
+	}
+	c.samples = c.samples[i:]
+	for pod, t := range c.latest {
+		if t.Before(since) {
+			delete(c.latest, pod)
+		}
+	}
+}
+
+// getRecommendedService returns the service running in the container
+func getRecommendedService(container string) (ServiceName, bool) {
+	for _, s := range recommendedServices {
+		if strings.ToLower(s) == container {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// recommendResources returns the request and limit percentiles of the samples, increased by the margin
+func recommendResources(spec *v1alpha1.ResourceRecommendationSpec, samples []usageSample) v1alpha1.ResourceRecommendation {
+	requestPercentile := defaultRecommendationRequestPercentile
+	if spec.RequestPercentile != nil {
+		requestPercentile = *spec.RequestPercentile
+	}
+	limitPercentile := defaultRecommendationLimitPercentile
+	if spec.LimitPercentile != nil {
+		limitPercentile = *spec.LimitPercentile
+	}
+	// Limits are never recommended below requests
+	if limitPercentile < requestPercentile {
+		limitPercentile = requestPercentile
+	}
+	margin := defaultRecommendationMarginPercentage
+	if spec.MarginPercentage != nil {
+		margin = *spec.MarginPercentage
+	}
+
+	cpu := make([]int64, len(samples))
+	memory := make([]int64, len(samples))
+	for i, s := range samples {
+		cpu[i] = s.cpu
+		memory[i] = s.memory
+	}
+	sort.Slice(cpu, func(i, j int) bool { return cpu[i] < cpu[j] })
+	sort.Slice(memory, func(i, j int) bool { return memory[i] < memory[j] })
+	return v1alpha1.ResourceRecommendation{
+		Requests: getUsageResourceList(getPercentile(cpu, requestPercentile), getPercentile(memory, requestPercentile), margin),
+		Limits:   getUsageResourceList(getPercentile(cpu, limitPercentile), getPercentile(memory, limitPercentile), margin),
+		Samples:  int32(len(samples)),
+	}
+}
+
+// getPercentile returns the nearest-rank percentile of the sorted values
+func getPercentile(sorted []int64, percentile int32) int64 {
+	i := int(math.Ceil(float64(percentile)/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// getUsageResourceList returns the CPU in millicores and the memory in bytes increased by the margin. CPU is rounded
+// up to the millicore and memory to the MiB.
+func getUsageResourceList(cpu, memory int64, margin int32) corev1.ResourceList {
+	cpu = (cpu*int64(100+margin) + 99) / 100
+	memory = (memory*int64(100+margin)/100 + megaBytes - 1) / megaBytes * megaBytes
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(max(cpu, 1), resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(max(memory, megaBytes), resource.BinarySI),
+	}
+}
+
+// applyRecommendations sets the recommendations in the status of CDAPMaster as the resources of the services, within
+// the allowed bounds. They are applied once per maintenance window, as updating the resources restarts the pods.
+func applyRecommendations(master *v1alpha1.CDAPMaster, now time.Time) error {
+	autoApply := master.Spec.ResourceRecommendations.AutoApply
+	start, ok, err := getMaintenanceWindowStart(autoApply.MaintenanceWindow, now)
+	if err != nil || !ok {
+		return err
+	}
+	if applied := master.Status.ResourceRecommendationsAppliedTime; applied != nil && !applied.Time.Before(start) {
+		return nil
+	}
+	if len(master.Status.ResourceRecommendations) == 0 {
+		return nil
+	}
+	for container, recommendation := range master.Status.ResourceRecommendations {
+		service, ok := getRecommendedService(container)
+		if !ok {
+			continue
+		}
+		ss, err := getCDAPServiceSpec(master, service)
+		if err != nil {
+			return err
+		}
+		// Skip disabled optional services
+		if ss == nil {
+			continue
+		}
+		ss.Resources = getBoundedResources(ss.Resources, recommendation, autoApply)
+	}
+	master.Status.ResourceRecommendationsAppliedTime = &metav1.Time{Time: now}
+	return nil
+}
+
+// getBoundedResources returns the resources with the recommended requests and limits, bounded by the allowed ones.
+// Other resources, e.g. ephemeral storage, are kept.
+func getBoundedResources(current *corev1.ResourceRequirements, recommendation v1alpha1.ResourceRecommendation, autoApply *v1alpha1.ResourceAutoApplySpec) *corev1.ResourceRequirements {
+	resources := &corev1.ResourceRequirements{}
+	if current != nil {
+		resources = current.DeepCopy()
+	}
+	if resources.Requests == nil {
+		resources.Requests = make(corev1.ResourceList)
+	}
+	if resources.Limits == nil {
+		resources.Limits = make(corev1.ResourceList)
+	}
+	for name, q := range recommendation.Requests {
+		resources.Requests[name] = getBoundedQuantity(name, q, autoApply)
+	}
+	for name, q := range recommendation.Limits {
+		resources.Limits[name] = getBoundedQuantity(name, q, autoApply)
+	}
+	return resources
+}
+
+func getBoundedQuantity(name corev1.ResourceName, q resource.Quantity, autoApply *v1alpha1.ResourceAutoApplySpec) resource.Quantity {
+	if lower, ok := autoApply.MinAllowed[name]; ok && q.Cmp(lower) < 0 {
+		return lower.DeepCopy()
+	}
+	if upper, ok := autoApply.MaxAllowed[name]; ok && q.Cmp(upper) > 0 {
+		return upper.DeepCopy()
+	}
+	return q
+}
+
+// getMaintenanceWindowStart returns the start of the latest daily maintenance window, and whether now is in it
+func getMaintenanceWindowStart(window v1alpha1.MaintenanceWindow, now time.Time) (time.Time, bool, error) {
+	t, err := time.Parse(maintenanceWindowStartFormat, window.Start)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid maintenance window start %q: %w", window.Start, err)
+	}
+	duration := defaultMaintenanceWindowDuration
+	if window.Duration != nil {
+		duration = window.Duration.Duration
+	}
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	// The window may have started the day before and span midnight
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start, now.Before(start.Add(duration)), nil
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakePodMetricsLister returns the metrics set by the test, in place of the metrics.k8s.io API
+type fakePodMetricsLister struct {
+	metrics []podMetrics
+	err     error
+	labels  map[string]string
+}
+
+func (l *fakePodMetricsLister) ListPodMetrics(ctx context.Context, namespace string, labels map[string]string) ([]podMetrics, error) {
+	l.labels = labels
+	return l.metrics, l.err
+}
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Resource recommendations", func() {
+		var (
+			ctx         context.Context
+			master      *v1alpha1.CDAPMaster
+			lister      *fakePodMetricsLister
+			recommender *resourceRecommender
+			now         time.Time
+		)
+		BeforeEach(func() {
+			ctx = context.Background()
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.ResourceRecommendations = &v1alpha1.ResourceRecommendationSpec{}
+			lister = &fakePodMetricsLister{}
+			recommender = newResourceRecommender(lister)
+			now = time.Date(2026, 1, 1, 2, 30, 0, 0, time.UTC)
+			recommender.now = func() time.Time { return now }
+		})
+		usage := func(cpu, memory string) corev1.ResourceList {
+			return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+		}
+		// Sample the appfabric container with 100m and 100Mi up to 1000m and 1000Mi, one minute apart
+		sampleAppFabric := func(count int) {
+			for i := 1; i <= count; i++ {
+				lister.metrics = []podMetrics{{
+					Pod:       "cdap-test-appfabric-0",
+					Timestamp: now.Add(time.Duration(i-count) * time.Minute),
+					Containers: map[string]corev1.ResourceList{
+						"appfabric":  usage(fmt.Sprintf("%dm", i*100), fmt.Sprintf("%dMi", i*100)),
+						"fluent-bit": usage("10m", "10Mi"),
+					},
+				}}
+				Expect(recommender.recommend(ctx, master)).To(Succeed())
+			}
+		}
+		resourceString := func(resources corev1.ResourceList) map[corev1.ResourceName]string {
+			s := make(map[corev1.ResourceName]string)
+			for name, q := range resources {
+				s[name] = q.String()
+			}
+			return s
+		}
+		It("Percentiles of service container usage recommended with margin", func() {
+			sampleAppFabric(9)
+			Expect(master.Status.ResourceRecommendations).To(BeNil())
+			Expect(lister.labels).To(Equal(handlerLabels(master, &ServiceHandler{})))
+
+			now = now.Add(time.Minute)
+			sampleAppFabric(10)
+			Expect(master.Status.ResourceRecommendations).To(HaveLen(1))
+			recommendation := master.Status.ResourceRecommendations["appfabric"]
+			Expect(recommendation.Samples).To(Equal(int32(10)))
+			Expect(resourceString(recommendation.Requests)).To(Equal(map[corev1.ResourceName]string{
+				corev1.ResourceCPU:    "1035m",
+				corev1.ResourceMemory: "1035Mi",
+			}))
+			Expect(resourceString(recommendation.Limits)).To(Equal(map[corev1.ResourceName]string{
+				corev1.ResourceCPU:    "1150m",
+				corev1.ResourceMemory: "1150Mi",
+			}))
+		})
+		It("Metrics already sampled and samples out of the window are skipped", func() {
+			master.Spec.ResourceRecommendations.MinSamples = 1
+			sampleAppFabric(3)
+			// Same metrics listed again
+			Expect(recommender.recommend(ctx, master)).To(Succeed())
+			Expect(master.Status.ResourceRecommendations["appfabric"].Samples).To(Equal(int32(3)))
+
+			master.Spec.ResourceRecommendations.Window = &metav1.Duration{Duration: 90 * time.Second}
+			Expect(recommender.recommend(ctx, master)).To(Succeed())
+			Expect(master.Status.ResourceRecommendations["appfabric"].Samples).To(Equal(int32(2)))
+
+			now = now.Add(time.Hour)
+			Expect(recommender.recommend(ctx, master)).To(Succeed())
+			Expect(master.Status.ResourceRecommendations).To(BeNil())
+		})
+		It("Samples dropped when recommendations are disabled", func() {
+			sampleAppFabric(10)
+			Expect(master.Status.ResourceRecommendations).To(HaveKey("appfabric"))
+			master.Spec.ResourceRecommendations = nil
+			Expect(recommender.recommend(ctx, master)).To(Succeed())
+			Expect(master.Status.ResourceRecommendations).To(BeNil())
+			Expect(recommender.samples).To(BeEmpty())
+		})
+		It("Recommendations applied within bounds once per maintenance window", func() {
+			master.Spec.AppFabric.Resources = &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+			}
+			master.Spec.ResourceRecommendations.AutoApply = &v1alpha1.ResourceAutoApplySpec{
+				MinAllowed:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1100m")},
+				MaxAllowed:        corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				MaintenanceWindow: v1alpha1.MaintenanceWindow{Start: "03:00"},
+			}
+			sampleAppFabric(10)
+			Expect(master.Status.ResourceRecommendationsAppliedTime).To(BeNil())
+
+			now = now.Add(time.Hour)
+			Expect(recommender.recommend(ctx, master)).To(Succeed())
+			Expect(master.Status.ResourceRecommendationsAppliedTime.Time).To(Equal(now))
+			Expect(resourceString(master.Spec.AppFabric.Resources.Requests)).To(Equal(map[corev1.ResourceName]string{
+				corev1.ResourceCPU:              "1100m",
+				corev1.ResourceMemory:           "1Gi",
+				corev1.ResourceEphemeralStorage: "1Gi",
+			}))
+			Expect(resourceString(master.Spec.AppFabric.Resources.Limits)).To(Equal(map[corev1.ResourceName]string{
+				corev1.ResourceCPU:    "1150m",
+				corev1.ResourceMemory: "1Gi",
+			}))
+
+			// Not applied again in the same window
+			master.Spec.AppFabric.Resources = nil
+			now = now.Add(10 * time.Minute)
+			Expect(recommender.recommend(ctx, master)).To(Succeed())
+			Expect(master.Spec.AppFabric.Resources).To(BeNil())
+		})
+		It("Maintenance windows may span midnight", func() {
+			window := v1alpha1.MaintenanceWindow{Start: "23:30", Duration: &metav1.Duration{Duration: 2 * time.Hour}}
+			start, ok, err := getMaintenanceWindowStart(window, time.Date(2026, 1, 2, 1, 0, 0, 0, time.UTC))
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeTrue())
+			Expect(start).To(Equal(time.Date(2026, 1, 1, 23, 30, 0, 0, time.UTC)))
+			_, ok, err = getMaintenanceWindowStart(window, time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC))
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeFalse())
+			_, _, err = getMaintenanceWindowStart(v1alpha1.MaintenanceWindow{Start: "2am"}, now)
+			Expect(err).NotTo(BeNil())
+		})
+		It("Pod metrics parsed from the metrics API", func() {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "metrics.k8s.io/v1beta1",
+				"kind":       "PodMetrics",
+				"metadata":   map[string]interface{}{"name": "cdap-test-appfabric-0", "namespace": "default"},
+				"timestamp":  "2026-01-01T02:30:00Z",
+				"window":     "15s",
+				"containers": []interface{}{
+					map[string]interface{}{"name": "appfabric", "usage": map[string]interface{}{"cpu": "250m", "memory": "524288Ki"}},
+				},
+			}}
+			m, err := parsePodMetrics(obj)
+			Expect(err).To(BeNil())
+			Expect(m.Pod).To(Equal("cdap-test-appfabric-0"))
+			Expect(m.Timestamp.Equal(now)).To(BeTrue())
+			appfabric := m.Containers["appfabric"]
+			Expect(appfabric.Cpu().MilliValue()).To(Equal(int64(250)))
+			Expect(appfabric.Memory().Value()).To(Equal(512 * megaBytes))
+		})
+		It("Reconciliation not failed by pod metrics errors", func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+			Expect(v1alpha1.AddToScheme(s)).To(Succeed())
+			master = &v1alpha1.CDAPMaster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "uid"},
+				Spec:       v1alpha1.CDAPMasterSpec{ResourceRecommendations: &v1alpha1.ResourceRecommendationSpec{MinSamples: 1}},
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(master).Build()
+			r := (&Reconciler{client: c, rm: k8s.NewRsrcManager(c, s)}).WithResourceRecommender(lister)
+			reconcileMaster := func() {
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(master)})
+				Expect(err).To(BeNil())
+				Expect(c.Get(ctx, client.ObjectKeyFromObject(master), master)).To(Succeed())
+			}
+
+			lister.err = errors.New("the server could not find the requested resource")
+			reconcileMaster()
+			Expect(master.Status.ResourceRecommendations).To(BeNil())
+
+			lister.err = nil
+			lister.metrics = []podMetrics{{Pod: "cdap-test-router-0", Timestamp: time.Now(), Containers: map[string]corev1.ResourceList{"router": usage("100m", "256Mi")}}}
+			reconcileMaster()
+			Expect(master.Status.ResourceRecommendations).To(HaveKey("router"))
+		})
+		It("Samples forgotten once the CDAPMaster is deleted or moves to another shard", func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+			Expect(v1alpha1.AddToScheme(s)).To(Succeed())
+			master = &v1alpha1.CDAPMaster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "uid"},
+				Spec:       v1alpha1.CDAPMasterSpec{ResourceRecommendations: &v1alpha1.ResourceRecommendationSpec{}},
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(master).Build()
+			r := (&Reconciler{client: c, rm: k8s.NewRsrcManager(c, s)}).WithResourceRecommender(lister)
+			key := client.ObjectKeyFromObject(master)
+			lister.metrics = []podMetrics{{Pod: "cdap-test-router-0", Timestamp: time.Now(), Containers: map[string]corev1.ResourceList{"router": usage("100m", "256Mi")}}}
+			reconcileMaster := func() {
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).To(BeNil())
+			}
+
+			reconcileMaster()
+			Expect(r.recommender.samples).To(HaveKey(key))
+			r.WithShardSelector(labels.SelectorFromSet(labels.Set{"shard": "a"}))
+			reconcileMaster()
+			Expect(r.recommender.samples).NotTo(HaveKey(key))
+
+			r.WithShardSelector(nil)
+			reconcileMaster()
+			Expect(r.recommender.samples).To(HaveKey(key))
+			Expect(c.Delete(ctx, master)).To(Succeed())
+			reconcileMaster()
+			Expect(r.recommender.samples).NotTo(HaveKey(key))
+		})
+	})
+})
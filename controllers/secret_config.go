@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Return the name of the secret containing cdap-security.xml, or empty if there is no secretConfig in CR
+func getSConfName(master *v1alpha1.CDAPMaster) string {
+	if len(master.Spec.SecretConfig) == 0 {
+		return ""
+	}
+	return getObjName(master, secretSConf)
+}
+
+// Return the secrets referenced by secretConfig as referred objects, so that they are fetched by the reconciler
+// and supplied to handlers as dependent resources
+func getSecretConfigDependents(master *v1alpha1.CDAPMaster) []reconciler.Object {
+	names := make(map[string]bool)
+	for _, selector := range master.Spec.SecretConfig {
+		names[selector.Name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var objs []reconciler.Object
+	for _, name := range sorted {
+		objs = append(objs, k8s.ReferredItem(&corev1.Secret{}, name, master.Namespace))
+	}
+	return objs
+}
+
+// Resolve the values of secretConfig from the supplied dependent secrets
+func getSecretConfigProperties(master *v1alpha1.CDAPMaster, dependent []reconciler.Object) (map[string]string, error) {
+	secrets := make(map[string]*corev1.Secret)
+	for _, item := range reconciler.ObjectsByType(dependent, k8s.Type) {
+		if secret, ok := item.Obj.(*k8s.Object).Obj.(*corev1.Secret); ok {
+			secrets[secret.Name] = secret
+		}
+	}
+	properties := make(map[string]string)
+	for property, selector := range master.Spec.SecretConfig {
+		secret, ok := secrets[selector.Name]
+		if !ok {
+			return nil, fmt.Errorf("failed to find secret %q for property %q", selector.Name, property)
+		}
+		value, ok := secret.Data[selector.Key]
+		if !ok {
+			return nil, fmt.Errorf("failed to find key %q in secret %q for property %q", selector.Key, selector.Name, property)
+		}
+		properties[property] = string(value)
+	}
+	return properties, nil
+}
+
+// Render cdap-security.xml with the values of secretConfig
+func renderSecurityConf(master *v1alpha1.CDAPMaster, dependent []reconciler.Object) (string, error) {
+	properties, err := getSecretConfigProperties(master, dependent)
+	if err != nil {
+		return "", err
+	}
+	templateData := struct {
+		Properties map[string]string
+	}{
+		Properties: properties,
+	}
+	return fillTemplate(templateCDAPSecurity, templateData)
+}
+
+// Return the hash of configurations that require restarting pods on change, or empty if there is none.
+// At the moment, these are the values of secretConfig, as CDAP doesn't reload cdap-security.xml.
+func getConfigHash(master *v1alpha1.CDAPMaster, dependent []reconciler.Object) (string, error) {
+	if getSConfName(master) == "" {
+		return "", nil
+	}
+	data, err := renderSecurityConf(master, dependent)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(data))), nil
+}
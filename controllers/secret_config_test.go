@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Secret config", func() {
+		var (
+			master    *v1alpha1.CDAPMaster
+			dependent []reconciler.Object
+		)
+		newSecret := func(name string, data map[string]string) reconciler.Object {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Data:       make(map[string][]byte),
+			}
+			for k, v := range data {
+				secret.Data[k] = []byte(v)
+			}
+			return reconciler.Object{Type: k8s.Type, Obj: &k8s.Object{Obj: secret}}
+		}
+		BeforeEach(func() {
+			master = &v1alpha1.CDAPMaster{}
+			err := fromJson("testdata/cdap_master_cr.json", master)
+			Expect(err).To(BeNil())
+			master.Spec.Config["data.storage.sql.jdbc.password"] = "plaintext"
+			master.Spec.SecretConfig = map[string]corev1.SecretKeySelector{
+				"data.storage.sql.jdbc.password": {
+					LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"},
+					Key:                  "password",
+				},
+				"security.auth.server.token.secret": {
+					LocalObjectReference: corev1.LocalObjectReference{Name: "auth"},
+					Key:                  "token",
+				},
+			}
+			ApplyDefaults(master)
+			dependent = []reconciler.Object{
+				newSecret("jdbc", map[string]string{"password": "p<ss&word"}),
+				newSecret("auth", map[string]string{"token": "secret-token"}),
+			}
+		})
+		It("Referenced secrets are dependent resources", func() {
+			objs := getSecretConfigDependents(master)
+			Expect(objs).To(HaveLen(2))
+			for _, obj := range objs {
+				Expect(obj.Lifecycle).To(Equal(reconciler.LifecycleReferred))
+			}
+			// Left in spec.config, but not rendered into cdap-site.xml
+			Expect(master.Spec.Config).To(HaveKeyWithValue("data.storage.sql.jdbc.password", "plaintext"))
+			Expect(getSiteConfig(master)).NotTo(HaveKey("data.storage.sql.jdbc.password"))
+		})
+		It("cdap-security.xml rendered into a secret", func() {
+			handler := &SecretHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, dependent, nil)
+			Expect(err).To(BeNil())
+			Expect(objs).To(HaveLen(1))
+			secret := objs[0].Obj.(*k8s.Object).Obj.(*corev1.Secret)
+			Expect(secret.Name).To(Equal("cdap-test-sconf"))
+			data := string(secret.Data[templateCDAPSecurity])
+			Expect(data).To(ContainSubstring("<name>data.storage.sql.jdbc.password</name>"))
+			Expect(data).To(ContainSubstring("<value>p&lt;ss&amp;word</value>"))
+			Expect(data).To(ContainSubstring("<value>secret-token</value>"))
+		})
+		It("Fail on missing secret key", func() {
+			dependent[1] = newSecret("auth", map[string]string{})
+			handler := &SecretHandler{}
+			_, err := handler.Objects(context.Background(), master, map[string]string{}, nil, dependent, nil)
+			Expect(err).NotTo(BeNil())
+		})
+		It("Config hash changes on secret rotation", func() {
+			hash, err := getConfigHash(master, dependent)
+			Expect(err).To(BeNil())
+			Expect(hash).NotTo(BeEmpty())
+			dependent[0] = newSecret("jdbc", map[string]string{"password": "rotated"})
+			rotated, err := getConfigHash(master, dependent)
+			Expect(err).To(BeNil())
+			Expect(rotated).NotTo(Equal(hash))
+
+			master.Spec.SecretConfig = nil
+			hash, err = getConfigHash(master, nil)
+			Expect(err).To(BeNil())
+			Expect(hash).To(BeEmpty())
+		})
+		It("Pods mount cdap-security.xml and restart on change", func() {
+			handler := &ServiceHandler{}
+			objs, err := handler.Objects(context.Background(), master, map[string]string{}, nil, dependent, nil)
+			Expect(err).To(BeNil())
+			hash, err := getConfigHash(master, dependent)
+			Expect(err).To(BeNil())
+			checkPodTemplate := func(template corev1.PodTemplateSpec) {
+				Expect(template.Annotations[annotationConfigHash]).To(Equal(hash))
+				for _, v := range template.Spec.Volumes {
+					if v.Name != "cdap-conf" {
+						continue
+					}
+					Expect(v.Projected).NotTo(BeNil())
+					Expect(v.Projected.Sources).To(HaveLen(2))
+					Expect(v.Projected.Sources[1].Secret.Name).To(Equal("cdap-test-sconf"))
+				}
+			}
+			for _, obj := range objs {
+				switch o := obj.Obj.(*k8s.Object).Obj.(type) {
+				case *appsv1.Deployment:
+					checkPodTemplate(o.Spec.Template)
+				case *appsv1.StatefulSet:
+					checkPodTemplate(o.Spec.Template)
+				}
+			}
+		})
+		It("Upgrade job mounts cdap-security.xml", func() {
+			spec := buildPreUpgradeJobSpec(getPreUpgradeJobName(master.Status.UpgradeStartTimeMillis), master, map[string]string{})
+			obj, err := buildUpgradeJobObject(spec)
+			Expect(err).To(BeNil())
+			job := obj.Obj.(*k8s.Object).Obj.(*batchv1.Job)
+			for _, v := range job.Spec.Template.Spec.Volumes {
+				if v.Name == "cdap-conf" {
+					Expect(v.Projected.Sources[1].Secret.Name).To(Equal("cdap-test-sconf"))
+				}
+			}
+		})
+	})
+})
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"cdap.io/cdap-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Return the pod security context if all the supplied services have the same setting. Otherwise return an error.
+func getPodSecurityContext(master *v1alpha1.CDAPMaster, services ServiceGroup) (*corev1.PodSecurityContext, error) {
+	val, err := getFieldValueIfUnique(master, services, "PodSecurityContext")
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := getSecurityContext(master, services)
+	if err != nil {
+		return nil, err
+	}
+	var override *corev1.PodSecurityContext
+	if podSecurityContext, ok := val.(corev1.PodSecurityContext); ok {
+		override = &podSecurityContext
+	}
+	return newPodSecurityContext(master, override, legacy), nil
+}
+
+// Return the container security context if all the supplied services have the same setting. Otherwise return an error.
+func getContainerSecurityContext(master *v1alpha1.CDAPMaster, services ServiceGroup) (*corev1.SecurityContext, error) {
+	val, err := getFieldValueIfUnique(master, services, "ContainerSecurityContext")
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := getSecurityContext(master, services)
+	if err != nil {
+		return nil, err
+	}
+	var override *corev1.SecurityContext
+	if containerSecurityContext, ok := val.(corev1.SecurityContext); ok {
+		override = &containerSecurityContext
+	}
+	return newContainerSecurityContext(master, override, legacy), nil
+}
+
+// Return the pod security context from, in order of precedence, the service override, the master spec and the pod-level
+// fields of the legacy SecurityContext. The preset in master spec then fills in the fields left unset.
+func newPodSecurityContext(master *v1alpha1.CDAPMaster, override *corev1.PodSecurityContext, legacy *v1alpha1.SecurityContext) *corev1.PodSecurityContext {
+	var sc *corev1.PodSecurityContext
+	switch {
+	case override != nil:
+		sc = override.DeepCopy()
+	case master.Spec.PodSecurityContext != nil:
+		sc = master.Spec.PodSecurityContext.DeepCopy()
+	case legacy != nil:
+		sc = &corev1.PodSecurityContext{
+			RunAsUser:    legacy.RunAsUser,
+			RunAsGroup:   legacy.RunAsGroup,
+			FSGroup:      legacy.FSGroup,
+			RunAsNonRoot: legacy.RunAsNonRoot,
+		}
+	}
+	if master.Spec.SecurityContextPreset != v1alpha1.SecurityContextPresetRestricted {
+		return sc
+	}
+	if sc == nil {
+		sc = &corev1.PodSecurityContext{}
+	}
+	if sc.RunAsUser == nil {
+		sc.RunAsUser = int64Ptr(defaultSecurityContextUID)
+	}
+	if sc.RunAsGroup == nil {
+		sc.RunAsGroup = int64Ptr(defaultSecurityContextGID)
+	}
+	if sc.FSGroup == nil {
+		sc.FSGroup = int64Ptr(defaultSecurityContextFSGID)
+	}
+	if sc.RunAsNonRoot == nil {
+		sc.RunAsNonRoot = boolPtr(true)
+	}
+	if sc.SeccompProfile == nil {
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	return sc
+}
+
+// Return the container security context from, in order of precedence, the service override, the master spec and the
+// container-level fields of the legacy SecurityContext. The preset in master spec then fills in the fields left unset.
+func newContainerSecurityContext(master *v1alpha1.CDAPMaster, override *corev1.SecurityContext, legacy *v1alpha1.SecurityContext) *corev1.SecurityContext {
+	var sc *corev1.SecurityContext
+	switch {
+	case override != nil:
+		sc = override.DeepCopy()
+	case master.Spec.ContainerSecurityContext != nil:
+		sc = master.Spec.ContainerSecurityContext.DeepCopy()
+	case legacy != nil:
+		sc = &corev1.SecurityContext{
+			Privileged:               legacy.Privileged,
+			AllowPrivilegeEscalation: legacy.AllowPrivilegeEscalation,
+			ReadOnlyRootFilesystem:   legacy.ReadOnlyRootFilesystem,
+		}
+	}
+	if master.Spec.SecurityContextPreset != v1alpha1.SecurityContextPresetRestricted {
+		return sc
+	}
+	if sc == nil {
+		sc = &corev1.SecurityContext{}
+	}
+	if sc.Privileged == nil {
+		sc.Privileged = boolPtr(false)
+	}
+	if sc.AllowPrivilegeEscalation == nil {
+		sc.AllowPrivilegeEscalation = boolPtr(false)
+	}
+	if sc.Capabilities == nil {
+		sc.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+	}
+	return sc
+}
+
+// Set the security context of the pod and all its containers, including init containers
+func setSecurityContextForPod(podSpec *corev1.PodSpec, podSecurityContext *corev1.PodSecurityContext, containerSecurityContext *corev1.SecurityContext) {
+	podSpec.SecurityContext = podSecurityContext
+	for index := range podSpec.InitContainers {
+		podSpec.InitContainers[index].SecurityContext = containerSecurityContext.DeepCopy()
+	}
+	for index := range podSpec.Containers {
+		podSpec.Containers[index].SecurityContext = containerSecurityContext.DeepCopy()
+	}
+}
@@ -13,10 +13,11 @@ import (
 
 // For ConfigMap
 type ConfigMapSpec struct {
-	Name      string            `json:"name,omitempty"`
-	Namespace string            `json:"namespace,omitempty"`
-	Labels    map[string]string `json:"labels,omitempty"`
-	Data      map[string]string `json:"configMap,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Data        map[string]string `json:"configMap,omitempty"`
 }
 
 func newConfigMapSpec(master *v1alpha1.CDAPMaster, name string, labels map[string]string) *ConfigMapSpec {
@@ -33,6 +34,36 @@ func (s *ConfigMapSpec) AddData(key, val string) *ConfigMapSpec {
 	return s
 }
 
+func (s *ConfigMapSpec) AddAnnotation(key, val string) *ConfigMapSpec {
+	if s.Annotations == nil {
+		s.Annotations = make(map[string]string)
+	}
+	s.Annotations[key] = val
+	return s
+}
+
+// For Secret
+type SecretSpec struct {
+	Name      string            `json:"name,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Data      map[string][]byte `json:"data,omitempty"`
+}
+
+func newSecretSpec(master *v1alpha1.CDAPMaster, name string, labels map[string]string) *SecretSpec {
+	s := new(SecretSpec)
+	s.Name = name
+	s.Namespace = master.Namespace
+	s.Labels = labels
+	s.Data = make(map[string][]byte)
+	return s
+}
+
+func (s *SecretSpec) AddData(key, val string) *SecretSpec {
+	s.Data[key] = []byte(val)
+	return s
+}
+
 // For containers in either StatefulSet or Deployment
 type ContainerSpec struct {
 	Name             string                        `json:"name,omitempty"`
@@ -42,10 +73,14 @@ type ContainerSpec struct {
 	Command          []string                      `json:"command,omitempty"`
 	Args             []string                      `json:"args,omitempty"`
 	Env              []corev1.EnvVar               `json:"env,omitempty"`
+	EnvFrom          []corev1.EnvFromSource        `json:"envFrom,omitempty"`
 	ResourceRequests map[string]*resource.Quantity `json:"resourceRequests,omitempty"`
 	ResourceLimits   map[string]*resource.Quantity `json:"resourceLimits,omitempty"`
 	DataDir          string                        `json:"dataDir,omitempty"`
 	Lifecycle        *corev1.Lifecycle             `json:"lifecycle,omitempty"`
+	ReadinessProbe   *corev1.Probe                 `json:"readinessProbe,omitempty"`
+	// JVM is the max heap size and JVM options of the container, reported in the status of CR
+	JVM *v1alpha1.JVMStatus `json:"-"`
 }
 
 func newContainerSpec(master *v1alpha1.CDAPMaster, name, dataDir string) *ContainerSpec {
@@ -93,11 +128,27 @@ func (s *ContainerSpec) addEnv(name, value string) *ContainerSpec {
 	return s
 }
 
+// Append the value to an existing env var separated by a space, or add the env var if it doesn't exist
+func (s *ContainerSpec) appendToEnv(name, value string) *ContainerSpec {
+	for idx, env := range s.Env {
+		if env.Name == name {
+			s.Env[idx].Value += " " + value
+			return s
+		}
+	}
+	return s.addEnv(name, value)
+}
+
 func (s *ContainerSpec) setEnv(envVar []corev1.EnvVar) *ContainerSpec {
 	s.Env = envVar
 	return s
 }
 
+func (s *ContainerSpec) setEnvFrom(envFrom []corev1.EnvFromSource) *ContainerSpec {
+	s.EnvFrom = envFrom
+	return s
+}
+
 func (s *ContainerSpec) setResources(resources *corev1.ResourceRequirements) *ContainerSpec {
 	if resources == nil {
 		return s
@@ -121,33 +172,68 @@ func (s *ContainerSpec) setResources(resources *corev1.ResourceRequirements) *Co
 	return s
 }
 
+// Set the resources, max heap size and JVM options of the container the same as the supplied one
+func (s *ContainerSpec) setJVMFrom(c *ContainerSpec) *ContainerSpec {
+	s.ResourceRequests = c.ResourceRequests
+	s.ResourceLimits = c.ResourceLimits
+	for _, env := range c.Env {
+		if env.Name == javaMaxHeapSizeEnvVarName {
+			s.Env = append(s.Env, env)
+		}
+	}
+	if c.JVM != nil && len(c.JVM.Options) > 0 {
+		s.appendToEnv(javaOptsEnvVarName, strings.Join(c.JVM.Options, " "))
+	}
+	return s
+}
+
 func (s *ContainerSpec) setLifecycle(lifecycle *corev1.Lifecycle) *ContainerSpec {
 	s.Lifecycle = lifecycle
 	return s
 }
 
+func (s *ContainerSpec) setReadinessProbe(probe *corev1.Probe) *ContainerSpec {
+	s.ReadinessProbe = probe
+	return s
+}
+
 // BaseSpec contains command fields for both StatefulSet and Deployment
 type BaseSpec struct {
-	Name                   string                    `json:"name,omitempty"`
-	Namespace              string                    `json:"namespace,omitempty"`
-	Labels                 map[string]string         `json:"labels,omitempty"`
-	ServiceAccountName     string                    `json:"serviceAccountName,omitempty"`
-	Replicas               int32                     `json:"replicas,omitempty"`
-	NodeSelector           map[string]string         `json:"nodeSelector,omitempty"`
-	RuntimeClassName       string                    `json:"runtimeClassName,omitempty"`
-	PriorityClassName      string                    `json:"priorityClassName,omitempty"`
-	SecuritySecret         string                    `json:"securitySecret,omitempty"`
-	SecuritySecretPath     string                    `json:"securitySecretPath,omitempty"`
-	CConf                  string                    `json:"cdapConf,omitempty"`
-	HConf                  string                    `json:"hadoopConf,omitempty"`
-	SysAppConf             string                    `json:"sysAppConf,omitempty"`
-	ConfigMapVolumes       map[string]string         `json:"configMapVolumes,omitempty"`
-	SecretVolumes          map[string]string         `json:"secretVolumes,omitempty"`
-	AdditionalVolumes      []corev1.Volume           `json:"additionalVolumes,omitempty"`
-	AdditionalVolumeMounts []corev1.VolumeMount      `json:"additionalVolumeMounts,omitempty"`
-	SecurityContext        *v1alpha1.SecurityContext `json:"securityContext,omitempty"`
-	Affinity               *corev1.Affinity          `json:"affinity,omitemtpy"`
-	SecretMountDefaultMode int32                     `json:"secretMountDefaultSecret,omitemtpy"`
+	Name                      string                            `json:"name,omitempty"`
+	Namespace                 string                            `json:"namespace,omitempty"`
+	Labels                    map[string]string                 `json:"labels,omitempty"`
+	ServiceAccountName        string                            `json:"serviceAccountName,omitempty"`
+	Replicas                  int32                             `json:"replicas,omitempty"`
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	RuntimeClassName          string                            `json:"runtimeClassName,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+	SecuritySecret            string                            `json:"securitySecret,omitempty"`
+	SecuritySecretPath        string                            `json:"securitySecretPath,omitempty"`
+	CConf                     string                            `json:"cdapConf,omitempty"`
+	HConf                     string                            `json:"hadoopConf,omitempty"`
+	SysAppConf                string                            `json:"sysAppConf,omitempty"`
+	SConf                     string                            `json:"securityConf,omitempty"`
+	ConfigHash                string                            `json:"configHash,omitempty"`
+	ConfigMapVolumes          map[string]string                 `json:"configMapVolumes,omitempty"`
+	SecretVolumes             map[string]string                 `json:"secretVolumes,omitempty"`
+	AdditionalVolumes         []corev1.Volume                   `json:"additionalVolumes,omitempty"`
+	AdditionalVolumeMounts    []corev1.VolumeMount              `json:"additionalVolumeMounts,omitempty"`
+	PodSecurityContext        *corev1.PodSecurityContext        `json:"podSecurityContext,omitempty"`
+	ContainerSecurityContext  *corev1.SecurityContext           `json:"containerSecurityContext,omitempty"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitemtpy"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	ImagePullSecrets          []corev1.LocalObjectReference     `json:"imagePullSecrets,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	SecretMountDefaultMode    int32                             `json:"secretMountDefaultSecret,omitemtpy"`
+	ExtraContainers           []ExtraContainerSpec              `json:"extraContainers,omitempty"`
+	ExtraInitContainers       []ExtraContainerSpec              `json:"extraInitContainers,omitempty"`
+}
+
+// ExtraContainerSpec is a user supplied container added as is to the pods, after the ones generated by the operator
+type ExtraContainerSpec struct {
+	Container corev1.Container `json:"container"`
+	// MountConfig mounts the CDAP configuration volumes into the container
+	MountConfig bool `json:"mountConfig,omitempty"`
 }
 
 func newBaseSpec(master *v1alpha1.CDAPMaster, name string, labels map[string]string, cconf, hconf, sysappconf string) *BaseSpec {
@@ -164,6 +250,7 @@ func newBaseSpec(master *v1alpha1.CDAPMaster, name string, labels map[string]str
 	s.CConf = cconf
 	s.HConf = hconf
 	s.SysAppConf = sysappconf
+	s.SConf = getSConfName(master)
 	s.ConfigMapVolumes = cloneMap(master.Spec.ConfigMapVolumes)
 	s.SecretVolumes = cloneMap(master.Spec.SecretVolumes)
 	s.AdditionalVolumes = master.Spec.AdditionalVolumes
@@ -176,11 +263,48 @@ func (s *BaseSpec) setServiceAccountName(name string) *BaseSpec {
 	return s
 }
 
+func (s *BaseSpec) setConfigHash(hash string) *BaseSpec {
+	s.ConfigHash = hash
+	return s
+}
+
 func (s *BaseSpec) setAffinity(affinity *corev1.Affinity) *BaseSpec {
 	s.Affinity = affinity
 	return s
 }
 
+func (s *BaseSpec) setTolerations(tolerations []corev1.Toleration) *BaseSpec {
+	s.Tolerations = tolerations
+	return s
+}
+
+func (s *BaseSpec) setImagePullSecrets(secrets []corev1.LocalObjectReference) *BaseSpec {
+	s.ImagePullSecrets = secrets
+	return s
+}
+
+// Add the image pull secrets that are not already present
+func (s *BaseSpec) addImagePullSecrets(secrets []corev1.LocalObjectReference) *BaseSpec {
+	for _, secret := range secrets {
+		found := false
+		for _, existing := range s.ImagePullSecrets {
+			if existing.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.ImagePullSecrets = append(s.ImagePullSecrets, secret)
+		}
+	}
+	return s
+}
+
+func (s *BaseSpec) setTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint) *BaseSpec {
+	s.TopologySpreadConstraints = constraints
+	return s
+}
+
 func (s *BaseSpec) setSecretMountDefaultMode(mode int32) *BaseSpec {
 	s.SecretMountDefaultMode = mode
 	return s
@@ -263,37 +387,31 @@ func (s *BaseSpec) addAdditionalVolumeMounts(additionalVolumeMounts []corev1.Vol
 	return s, nil
 }
 
-func (s *BaseSpec) setSecurityContext(securityContext *v1alpha1.SecurityContext) *BaseSpec {
-	s.SecurityContext = securityContext
-	if securityContext != nil {
-		// For non-boolean pointers, it is reasonable to set this value only if it is non-zero. Thus, we
-		// do not need to set a default for the int64 pointers.
-		// For boolean pointers, we always set the value and ensure there is a default set for nil values.
-		// All defaults are least-restrictive and pulled from corev1.SecurityContext preserve backwards compatibility.
-		if s.SecurityContext.RunAsNonRoot == nil {
-			s.SecurityContext.RunAsNonRoot = new(bool)
-			*s.SecurityContext.RunAsNonRoot = false
-		}
-		if s.SecurityContext.Privileged == nil {
-			s.SecurityContext.Privileged = new(bool)
-			*s.SecurityContext.Privileged = false
-		}
-		if s.SecurityContext.AllowPrivilegeEscalation == nil {
-			s.SecurityContext.AllowPrivilegeEscalation = new(bool)
-			*s.SecurityContext.AllowPrivilegeEscalation = true
-		}
-		if s.SecurityContext.ReadOnlyRootFilesystem == nil {
-			s.SecurityContext.ReadOnlyRootFilesystem = new(bool)
-			*s.SecurityContext.ReadOnlyRootFilesystem = false
-		}
+func (s *BaseSpec) addExtraContainers(containers, initContainers []corev1.Container, mountConfig bool) *BaseSpec {
+	for _, c := range containers {
+		s.ExtraContainers = append(s.ExtraContainers, ExtraContainerSpec{Container: *c.DeepCopy(), MountConfig: mountConfig})
 	}
+	for _, c := range initContainers {
+		s.ExtraInitContainers = append(s.ExtraInitContainers, ExtraContainerSpec{Container: *c.DeepCopy(), MountConfig: mountConfig})
+	}
+	return s
+}
+
+func (s *BaseSpec) setPodSecurityContext(securityContext *corev1.PodSecurityContext) *BaseSpec {
+	s.PodSecurityContext = securityContext
+	return s
+}
+
+func (s *BaseSpec) setContainerSecurityContext(securityContext *corev1.SecurityContext) *BaseSpec {
+	s.ContainerSecurityContext = securityContext
 	return s
 }
 
 // For Deployment
 type DeploymentSpec struct {
-	Base       *BaseSpec        `json:"base,inline"`
-	Containers []*ContainerSpec `json:"containers,omitempty"`
+	Base           *BaseSpec        `json:"base,inline"`
+	InitContainers []*ContainerSpec `json:"initContainer,omitempty"`
+	Containers     []*ContainerSpec `json:"containers,omitempty"`
 }
 
 func newDeploymentSpec(master *v1alpha1.CDAPMaster, name string, labels map[string]string, cconf, hconf, sysappconf string) *DeploymentSpec {
@@ -332,6 +450,11 @@ func (s *DeploymentSpec) addLabel(key, val string) *DeploymentSpec {
 	return s
 }
 
+func (s *DeploymentSpec) withInitContainer(containerSpec *ContainerSpec) *DeploymentSpec {
+	s.InitContainers = append(s.InitContainers, containerSpec)
+	return s
+}
+
 func (s *DeploymentSpec) withContainer(containerSpec *ContainerSpec) *DeploymentSpec {
 	s.Containers = append(s.Containers, containerSpec)
 	return s
@@ -365,8 +488,18 @@ func (s *DeploymentSpec) addAdditionalVolumeMounts(additionalVolumeMounts []core
 	return s, nil
 }
 
-func (s *DeploymentSpec) setSecurityContext(securityContext *v1alpha1.SecurityContext) *DeploymentSpec {
-	s.Base.setSecurityContext(securityContext)
+func (s *DeploymentSpec) addExtraContainers(containers, initContainers []corev1.Container, mountConfig bool) *DeploymentSpec {
+	s.Base.addExtraContainers(containers, initContainers, mountConfig)
+	return s
+}
+
+func (s *DeploymentSpec) setPodSecurityContext(securityContext *corev1.PodSecurityContext) *DeploymentSpec {
+	s.Base.setPodSecurityContext(securityContext)
+	return s
+}
+
+func (s *DeploymentSpec) setContainerSecurityContext(securityContext *corev1.SecurityContext) *DeploymentSpec {
+	s.Base.setContainerSecurityContext(securityContext)
 	return s
 }
 
@@ -375,6 +508,21 @@ func (s *DeploymentSpec) setAffinity(affinity *corev1.Affinity) *DeploymentSpec
 	return s
 }
 
+func (s *DeploymentSpec) setTolerations(tolerations []corev1.Toleration) *DeploymentSpec {
+	s.Base.setTolerations(tolerations)
+	return s
+}
+
+func (s *DeploymentSpec) setImagePullSecrets(secrets []corev1.LocalObjectReference) *DeploymentSpec {
+	s.Base.setImagePullSecrets(secrets)
+	return s
+}
+
+func (s *DeploymentSpec) setTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint) *DeploymentSpec {
+	s.Base.setTopologySpreadConstraints(constraints)
+	return s
+}
+
 func (s *DeploymentSpec) setSecretMountDefaultMode(mode int32) *DeploymentSpec {
 	s.Base.setSecretMountDefaultMode(mode)
 	return s
@@ -422,6 +570,21 @@ func (s *StatefulSpec) setAffinity(affinity *corev1.Affinity) *StatefulSpec {
 	return s
 }
 
+func (s *StatefulSpec) setTolerations(tolerations []corev1.Toleration) *StatefulSpec {
+	s.Base.setTolerations(tolerations)
+	return s
+}
+
+func (s *StatefulSpec) setImagePullSecrets(secrets []corev1.LocalObjectReference) *StatefulSpec {
+	s.Base.setImagePullSecrets(secrets)
+	return s
+}
+
+func (s *StatefulSpec) setTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint) *StatefulSpec {
+	s.Base.setTopologySpreadConstraints(constraints)
+	return s
+}
+
 func (s *StatefulSpec) setSecretMountDefaultMode(mode int32) *StatefulSpec {
 	s.Base.setSecretMountDefaultMode(mode)
 	return s
@@ -490,8 +653,18 @@ func (s *StatefulSpec) addAdditionalVolumeMounts(additionalVolumeMounts []corev1
 	return s, nil
 }
 
-func (s *StatefulSpec) setSecurityContext(securityContext *v1alpha1.SecurityContext) *StatefulSpec {
-	s.Base.setSecurityContext(securityContext)
+func (s *StatefulSpec) addExtraContainers(containers, initContainers []corev1.Container, mountConfig bool) *StatefulSpec {
+	s.Base.addExtraContainers(containers, initContainers, mountConfig)
+	return s
+}
+
+func (s *StatefulSpec) setPodSecurityContext(securityContext *corev1.PodSecurityContext) *StatefulSpec {
+	s.Base.setPodSecurityContext(securityContext)
+	return s
+}
+
+func (s *StatefulSpec) setContainerSecurityContext(securityContext *corev1.SecurityContext) *StatefulSpec {
+	s.Base.setContainerSecurityContext(securityContext)
 	return s
 }
 
@@ -504,6 +677,8 @@ type NetworkServiceSpec struct {
 	ServiceType    *string           `json:"serviceType,omitempty"`
 	ServicePort    *int32            `json:"servicePort,omitempty"`
 	LoadBalancerIP *string           `json:"loadBalancerIP,omitempty"`
+	PortName       string            `json:"portName,omitempty"`
+	AppProtocol    string            `json:"appProtocol,omitempty"`
 }
 
 func newNetworkServiceSpec(name string, labels, annotations map[string]string, serviceType, loadBalancerIP *string, port *int32, master *v1alpha1.CDAPMaster) *NetworkServiceSpec {
@@ -526,6 +701,14 @@ func (s *NetworkServiceSpec) addSelector(key, val string) *NetworkServiceSpec {
 	s.Selectors = mergeMaps(s.Selectors, map[string]string{key: val})
 	return s
 }
+func (s *NetworkServiceSpec) setPortName(name string) *NetworkServiceSpec {
+	s.PortName = name
+	return s
+}
+func (s *NetworkServiceSpec) setAppProtocol(protocol string) *NetworkServiceSpec {
+	s.AppProtocol = protocol
+	return s
+}
 
 // Top level CDAP service deployment configuration
 type DeploymentPlanSpec struct {
@@ -551,6 +734,17 @@ func (s *DeploymentPlanSpec) withNetworkService(networkService *NetworkServiceSp
 	return s
 }
 
+// Set the hash of configurations that require restarting pods on change for all statefulsets and deployments
+func (s *DeploymentPlanSpec) setConfigHash(hash string) *DeploymentPlanSpec {
+	for _, stateful := range s.Stateful {
+		stateful.Base.setConfigHash(hash)
+	}
+	for _, deployment := range s.Deployment {
+		deployment.Base.setConfigHash(hash)
+	}
+	return s
+}
+
 func (s *DeploymentPlanSpec) toString() (string, error) {
 	data, err := json.Marshal(*s)
 	if err != nil {
@@ -560,22 +754,26 @@ func (s *DeploymentPlanSpec) toString() (string, error) {
 }
 
 type VersionUpgradeJobSpec struct {
-	Image              string            `json:"image,omitempty"`
-	JobName            string            `json:"jobName,omitempty"`
-	Labels             map[string]string `json:"labels,omitempty"`
-	HostName           string            `json:"hostName,omitempty"`
-	BackoffLimit       int32             `json:"backoffLimit,omitempty"`
-	ReferentName       string            `json:"referentName,omitempty"`
-	ReferentKind       string            `json:"referentKind,omitempty"`
-	ReferentApiVersion string            `json:"referentApiVersion,omitempty"`
-	ReferentUID        types.UID         `json:"referentUID,omitempty"`
-	SecuritySecret     string            `json:"securitySecret,omitempty"`
-	StartTimeMs        int64             `json:"startTimeMs,omitempty"`
-	Namespace          string            `json:"namespace,omitempty"`
-	CConf              string            `json:"cdapConf,omitempty"`
-	HConf              string            `json:"hadoopConf,omitempty"`
-	PreUpgrade         bool              `json:"preUpgrade,omitempty"`
-	PostUpgrade        bool              `json:"postUpgrade,omitempty"`
+	Image                    string                        `json:"image,omitempty"`
+	JobName                  string                        `json:"jobName,omitempty"`
+	Labels                   map[string]string             `json:"labels,omitempty"`
+	HostName                 string                        `json:"hostName,omitempty"`
+	BackoffLimit             int32                         `json:"backoffLimit,omitempty"`
+	ReferentName             string                        `json:"referentName,omitempty"`
+	ReferentKind             string                        `json:"referentKind,omitempty"`
+	ReferentApiVersion       string                        `json:"referentApiVersion,omitempty"`
+	ReferentUID              types.UID                     `json:"referentUID,omitempty"`
+	SecuritySecret           string                        `json:"securitySecret,omitempty"`
+	StartTimeMs              int64                         `json:"startTimeMs,omitempty"`
+	Namespace                string                        `json:"namespace,omitempty"`
+	CConf                    string                        `json:"cdapConf,omitempty"`
+	HConf                    string                        `json:"hadoopConf,omitempty"`
+	SConf                    string                        `json:"securityConf,omitempty"`
+	PodSecurityContext       *corev1.PodSecurityContext    `json:"podSecurityContext,omitempty"`
+	ContainerSecurityContext *corev1.SecurityContext       `json:"containerSecurityContext,omitempty"`
+	ImagePullSecrets         []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	PreUpgrade               bool                          `json:"preUpgrade,omitempty"`
+	PostUpgrade              bool                          `json:"postUpgrade,omitempty"`
 }
 
 func newUpgradeJobSpec(master *v1alpha1.CDAPMaster, name string, labels map[string]string, startTimeMs int64, cconf, hconf string) *VersionUpgradeJobSpec {
@@ -594,6 +792,10 @@ func newUpgradeJobSpec(master *v1alpha1.CDAPMaster, name string, labels map[stri
 	s.StartTimeMs = startTimeMs
 	s.CConf = cconf
 	s.HConf = hconf
+	s.SConf = getSConfName(master)
+	s.PodSecurityContext = newPodSecurityContext(master, nil, master.Spec.SecurityContext)
+	s.ContainerSecurityContext = newContainerSecurityContext(master, nil, master.Spec.SecurityContext)
+	s.ImagePullSecrets = master.Spec.ImagePullSecrets
 	return s
 }
 
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"fmt"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Return true if the service terminates TLS when it is enabled in CR
+func isTLSService(service ServiceName) bool {
+	return service == serviceRouter || service == serviceUserInterface
+}
+
+// Return the secret volume holding the TLS key pair for the router and UI services, or nil if TLS is disabled
+func getTLSSecretVolumes(master *v1alpha1.CDAPMaster, service ServiceName) map[string]string {
+	if master.Spec.TLS == nil || master.Spec.TLS.SecretName == "" || !isTLSService(service) {
+		return nil
+	}
+	return map[string]string{master.Spec.TLS.SecretName: defaultTLSMountPath}
+}
+
+// Return an HTTPS readiness probe on the service port for the router and UI services, or nil if TLS is disabled
+func getHTTPSReadinessProbe(master *v1alpha1.CDAPMaster, service ServiceName) *corev1.Probe {
+	if master.Spec.TLS == nil {
+		return nil
+	}
+	var port *int32
+	path := ""
+	switch service {
+	case serviceRouter:
+		port, path = master.Spec.Router.ServicePort, routerReadinessProbePath
+	case serviceUserInterface:
+		port, path = master.Spec.UserInterface.ServicePort, userInterfaceReadinessProbePath
+	}
+	if port == nil {
+		return nil
+	}
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   path,
+				Port:   intstr.FromInt(int(*port)),
+				Scheme: corev1.URISchemeHTTPS,
+			},
+		},
+		InitialDelaySeconds: 30,
+		PeriodSeconds:       10,
+	}
+}
+
+// Return the DNS names of the router and UI services for the certificate requested from cert-manager
+func getTLSDNSNames(master *v1alpha1.CDAPMaster) []string {
+	var names []string
+	for _, service := range []ServiceName{serviceRouter, serviceUserInterface} {
+		name := getObjName(master, service)
+		names = append(names,
+			name,
+			fmt.Sprintf("%s.%s", name, master.Namespace),
+			fmt.Sprintf("%s.%s.svc", name, master.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", name, master.Namespace))
+	}
+	return append(names, master.Spec.TLS.CertManager.DNSNames...)
+}
+
+// Return a reconciler object for the cert-manager Certificate of the router and UI services. cert-manager types are
+// not part of the scheme, hence the Certificate is built as an unstructured object.
+func buildCertificateObject(master *v1alpha1.CDAPMaster, labels map[string]string) reconciler.Object {
+	cm := master.Spec.TLS.CertManager
+	issuerRef := map[string]interface{}{
+		"name":  cm.IssuerRef.Name,
+		"kind":  certManagerIssuerKind,
+		"group": certManagerGroup,
+	}
+	if cm.IssuerRef.Kind != "" {
+		issuerRef["kind"] = cm.IssuerRef.Kind
+	}
+	if cm.IssuerRef.Group != "" {
+		issuerRef["group"] = cm.IssuerRef.Group
+	}
+	var dnsNames []interface{}
+	for _, name := range getTLSDNSNames(master) {
+		dnsNames = append(dnsNames, name)
+	}
+	spec := map[string]interface{}{
+		"secretName": master.Spec.TLS.SecretName,
+		"issuerRef":  issuerRef,
+		"dnsNames":   dnsNames,
+		// The router reads the private key and the certificate chain from a single PEM file
+		"additionalOutputFormats": []interface{}{
+			map[string]interface{}{"type": certManagerCombinedPEM},
+		},
+	}
+	if cm.Duration != nil {
+		spec["duration"] = cm.Duration.Duration.String()
+	}
+	if cm.RenewBefore != nil {
+		spec["renewBefore"] = cm.RenewBefore.Duration.String()
+	}
+
+	certificate := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	certificate.SetGroupVersionKind(getCertificateGVK())
+	certificate.SetName(getObjName(master, objectNameTLS))
+	certificate.SetNamespace(master.Namespace)
+	certificate.SetLabels(labels)
+
+	return reconciler.Object{
+		Type:      k8s.Type,
+		Lifecycle: reconciler.LifecycleManaged,
+		Obj: &k8s.Object{
+			Obj:     certificate,
+			ObjList: newCertificateList(),
+		},
+	}
+}
+
+func getCertificateGVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: certManagerGroup, Version: certManagerVersion, Kind: certManagerKind}
+}
+
+func newCertificateList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(getCertificateGVK().GroupVersion().WithKind(certManagerKind + "List"))
+	return list
+}
+
+// Return a copy of the spec of the given Certificate object, or nil if it is not a Certificate
+func getCertificateSpec(obj metav1.Object) interface{} {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	spec, _, _ := unstructured.NestedFieldCopy(u.Object, "spec")
+	return spec
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 
 	"cdap.io/cdap-operator/api/v1alpha1"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler"
 )
 
 type Pair struct {
@@ -18,6 +18,16 @@ func int32Ptr(value int32) *int32 {
 	return &value
 }
 
+// Creates a int64 pointer for the given value
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+// Creates a bool pointer for the given value
+func boolPtr(value bool) *bool {
+	return &value
+}
+
 func getObjName(master *v1alpha1.CDAPMaster, name string) string {
 	return fmt.Sprintf("%s%s-%s", objectNamePrefix, master.Name, strings.ToLower(name))
 }
@@ -55,6 +65,48 @@ func getCDAPMasterServiceSpec(master *v1alpha1.CDAPMaster, service ServiceName)
 	return val.Interface(), nil
 }
 
+// serviceConfToggles maps services to the cdap-site.xml property that tells CDAP whether the service runs
+var serviceConfToggles = map[ServiceName]string{
+	servicePreview: confPreviewEnabled,
+}
+
+// isServiceEnabled returns whether the service should be deployed. Optional services with a nil specification are
+// disabled. Otherwise the Enabled field of the service takes precedence over its cdap-site.xml toggle, which
+// like in CDAP is only false when set to "false".
+func isServiceEnabled(master *v1alpha1.CDAPMaster, service ServiceName) (bool, error) {
+	spec, err := getCDAPServiceSpec(master, service)
+	if err != nil {
+		return false, err
+	}
+	if spec == nil {
+		return false, nil
+	}
+	if spec.Enabled != nil {
+		return *spec.Enabled, nil
+	}
+	if key, ok := serviceConfToggles[service]; ok {
+		if val, ok := master.Spec.Config[key]; ok {
+			return !strings.EqualFold(strings.TrimSpace(val), "false"), nil
+		}
+	}
+	return true, nil
+}
+
+// getEnabledServices returns the services of the group that should be deployed
+func getEnabledServices(master *v1alpha1.CDAPMaster, services ServiceGroup) (ServiceGroup, error) {
+	var enabled ServiceGroup
+	for _, s := range services {
+		ok, err := isServiceEnabled(master, s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			enabled = append(enabled, s)
+		}
+	}
+	return enabled, nil
+}
+
 // getCDAPMasterSpec returns a pointer to the given specType for the given service (using reflect).
 // Fail if any of the following occurs
 // - unable to find the field for the service
@@ -1,24 +1,26 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
 	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
 	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler/manager/k8s"
-	"sigs.k8s.io/controller-reconciler/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type VersionUpdated = bool
 
 var (
+	// updateStatus is shared by concurrent reconciles. It is only written by init, and its conditions are
+	// copied into CDAPMaster status.
 	updateStatus *VersionUpdateStatus
 )
 
@@ -31,14 +33,14 @@ func init() {
 ///// Main functions for handle image upgarde/downgrade /////
 /////////////////////////////////////////////////////////////
 
-func handleVersionUpdate(master *v1alpha1.CDAPMaster, labels map[string]string, observed []reconciler.Object) ([]reconciler.Object, error) {
+func handleVersionUpdate(ctx context.Context, master *v1alpha1.CDAPMaster, labels map[string]string, observed []reconciler.Object) ([]reconciler.Object, error) {
 	// Let the current update complete if there is any
 	if isConditionTrue(master, updateStatus.Inprogress) {
-		log.Printf("Version update ingress. Continue... ")
-		return upgradeForBackend(master, labels, observed)
+		log.FromContext(ctx).V(1).Info("Version update in progress, continuing", "phase", "upgrade")
+		return upgradeForBackend(ctx, master, labels, observed)
 	}
 
-	if objs, versionUpdated, err := updateForUserInterface(master); err != nil {
+	if objs, versionUpdated, err := updateForUserInterface(ctx, master); err != nil {
 		return nil, err
 	} else if versionUpdated {
 		return objs, nil
@@ -72,8 +74,9 @@ func handleVersionUpdate(master *v1alpha1.CDAPMaster, labels map[string]string,
 
 		setCondition(master, updateStatus.Inprogress)
 		master.Status.UpgradeStartTimeMillis = getCurrentTimeMs()
-		log.Printf("Version update: start upgrading %s -> %s ", curVersion.rawString, newVersion.rawString)
-		return upgradeForBackend(master, labels, observed)
+		log.FromContext(ctx).Info("Version update: start upgrading", "phase", "upgrade",
+			"from", curVersion.rawString, "to", newVersion.rawString)
+		return upgradeForBackend(ctx, master, labels, observed)
 	case 0:
 		// Reset all condition so that failed upgraded/downgrade can be retried later if needed.
 		// This is needed when last upgrade failed and user has reset the version in spec.
@@ -86,14 +89,15 @@ func handleVersionUpdate(master *v1alpha1.CDAPMaster, labels map[string]string,
 		updateStatus.clearAllConditions(master)
 		setCondition(master, updateStatus.Inprogress)
 		master.Status.DowngradeStartTimeMillis = getCurrentTimeMs()
-		log.Printf("Version update: start downgrading %s -> %s ", curVersion.rawString, newVersion.rawString)
-		return downgradeForBackend(master)
+		log.FromContext(ctx).Info("Version update: start downgrading", "phase", "downgrade",
+			"from", curVersion.rawString, "to", newVersion.rawString)
+		return downgradeForBackend(ctx, master)
 
 	}
 	return []reconciler.Object{}, nil
 }
 
-func updateForUserInterface(master *v1alpha1.CDAPMaster) ([]reconciler.Object, VersionUpdated, error) {
+func updateForUserInterface(ctx context.Context, master *v1alpha1.CDAPMaster) ([]reconciler.Object, VersionUpdated, error) {
 	// Update UI image version
 	curUIVersion, err := getCurrentUserInterfaceVersion(master)
 	if err != nil {
@@ -105,22 +109,25 @@ func updateForUserInterface(master *v1alpha1.CDAPMaster) ([]reconciler.Object, V
 	}
 	if len(curUIVersion.rawString) == 0 || compareVersion(curUIVersion, newUIVersion) != 0 {
 		setUserInterfaceVersionToUse(master)
-		log.Printf("Version update: for UserInterface %s->%s", curUIVersion.rawString, newUIVersion.rawString)
+		log.FromContext(ctx).Info("Version update: set UserInterface version", "phase", "ui-update",
+			"from", curUIVersion.rawString, "to", newUIVersion.rawString)
 		return []reconciler.Object{}, true, nil
 	}
 	return []reconciler.Object{}, false, nil
 }
 
-func downgradeForBackend(master *v1alpha1.CDAPMaster) ([]reconciler.Object, error) {
+func downgradeForBackend(ctx context.Context, master *v1alpha1.CDAPMaster) ([]reconciler.Object, error) {
 	// Directly set the image to use. No pre- post- downgrade to run at the moment.
 	setImageToUse(master)
 	setCondition(master, updateStatus.DowngradeSucceeded)
 	clearCondition(master, updateStatus.Inprogress)
-	log.Printf("Version update: downgrade completed")
+	log.FromContext(ctx).Info("Version update: downgrade completed", "phase", "downgrade")
 	return []reconciler.Object{}, nil
 }
 
-func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, observed []reconciler.Object) ([]reconciler.Object, error) {
+func upgradeForBackend(ctx context.Context, master *v1alpha1.CDAPMaster, labels map[string]string, observed []reconciler.Object) ([]reconciler.Object, error) {
+	logger := log.FromContext(ctx)
+
 	// Find either pre- or post- upgrade job
 	findJob := func(jobName string) *batchv1.Job {
 		var job *batchv1.Job = nil
@@ -161,7 +168,8 @@ func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, ob
 	// needed to set an overall deadline for the pre-upgrade job, the logic below needs to check
 	// deadline exceeded condition on job's status
 	if !isConditionTrue(master, updateStatus.PreUpgradeSucceeded) {
-		log.Printf("Version update: pre-upgrade job not completed")
+		logger := logger.WithValues("phase", "pre-upgrade")
+		logger.V(1).Info("Version update: pre-upgrade job not completed")
 		preJobName := getPreUpgradeJobName(master.Status.UpgradeStartTimeMillis)
 		preJobSpec := buildPreUpgradeJobSpec(getPreUpgradeJobName(master.Status.UpgradeStartTimeMillis), master, labels)
 		job := findJob(preJobName)
@@ -170,21 +178,21 @@ func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, ob
 			if err != nil {
 				return nil, err
 			}
-			log.Printf("Version update: creating pre-upgrade job")
+			logger.Info("Version update: creating pre-upgrade job")
 			return []reconciler.Object{*obj}, nil
 		} else if job.Status.Succeeded > 0 {
 			setCondition(master, updateStatus.PreUpgradeSucceeded)
-			log.Printf("Version update: pre-upgrade job succeeded")
+			logger.Info("Version update: pre-upgrade job succeeded")
 			// Return empty to delete preUpgrade jobObj
 			return []reconciler.Object{}, nil
 		} else if job.Status.Failed > imageVersionUpgradeJobMaxRetryCount {
 			setCondition(master, updateStatus.PreUpgradeFailed)
 			setCondition(master, updateStatus.UpgradeFailed)
 			clearCondition(master, updateStatus.Inprogress)
-			log.Printf("Version update: pre-upgrade job failed, exceeded max retries.")
+			logger.Info("Version update: pre-upgrade job failed, exceeded max retries", "failed", job.Status.Failed)
 			return []reconciler.Object{}, nil
 		} else {
-			log.Printf("Version update: pre-upgrade job inprogress.")
+			logger.V(1).Info("Version update: pre-upgrade job in progress")
 			return []reconciler.Object{*buildObject(job)}, nil
 		}
 	}
@@ -193,7 +201,7 @@ func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, ob
 	if !isConditionTrue(master, updateStatus.VersionUpdated) {
 		setImageToUse(master)
 		setCondition(master, updateStatus.VersionUpdated)
-		log.Printf("Version update: set new version.")
+		logger.Info("Version update: set new version", "phase", "set-version")
 		return []reconciler.Object{}, nil
 	}
 
@@ -204,7 +212,8 @@ func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, ob
 	// needed to set an overall deadline for the post-upgrade job, the logic below needs to check
 	// deadline exceeded condition on job's status
 	if !isConditionTrue(master, updateStatus.PostUpgradeSucceeded) {
-		log.Printf("Version update: post-upgrade job not completed")
+		logger := logger.WithValues("phase", "post-upgrade")
+		logger.V(1).Info("Version update: post-upgrade job not completed")
 		postJobName := getPostUpgradeJobName(master.Status.UpgradeStartTimeMillis)
 		postJobSpec := buildPostUpgradeJobSpec(getPostUpgradeJobName(master.Status.UpgradeStartTimeMillis), master, labels)
 		job := findJob(postJobName)
@@ -213,27 +222,27 @@ func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, ob
 			if err != nil {
 				return nil, err
 			}
-			log.Printf("Version update: creating post-upgrade job")
+			logger.Info("Version update: creating post-upgrade job")
 			return []reconciler.Object{*obj}, nil
 		} else if job.Status.Succeeded > 0 {
 			setCondition(master, updateStatus.PostUpgradeSucceeded)
-			log.Printf("Version update: post-upgrade job succeeded")
+			logger.Info("Version update: post-upgrade job succeeded")
 			// Return empty to delete postUpgrade job
 			return []reconciler.Object{}, nil
 		} else if job.Status.Failed > imageVersionUpgradeJobMaxRetryCount {
 			setCondition(master, updateStatus.PostUpgradeFailed)
 			setCondition(master, updateStatus.UpgradeFailed)
 			clearCondition(master, updateStatus.Inprogress)
-			log.Printf("Version update: post-upgrade job failed, exceeded max retries.")
+			logger.Info("Version update: post-upgrade job failed, exceeded max retries", "failed", job.Status.Failed)
 			return []reconciler.Object{*buildObject(job)}, nil
 		} else {
-			log.Printf("Version update: post-upgrade job inprogress.")
+			logger.V(1).Info("Version update: post-upgrade job in progress")
 			return []reconciler.Object{*buildObject(job)}, nil
 		}
 	}
 	setCondition(master, updateStatus.UpgradeSucceeded)
 	clearCondition(master, updateStatus.Inprogress)
-	log.Printf("Version update: upgrade succeeded.")
+	logger.Info("Version update: upgrade succeeded", "phase", "upgrade")
 	return []reconciler.Object{}, nil
 }
 
@@ -262,68 +271,68 @@ func upgradeForBackend(master *v1alpha1.CDAPMaster, labels map[string]string, ob
 // - When failed (currently not possible, as we just set the new version directly)
 type VersionUpdateStatus struct {
 	// common states
-	Inprogress     status.Condition
-	VersionUpdated status.Condition
+	Inprogress     v1alpha1.Condition
+	VersionUpdated v1alpha1.Condition
 
 	// states specifically upgrade
-	PreUpgradeSucceeded  status.Condition
-	PreUpgradeFailed     status.Condition
-	PostUpgradeSucceeded status.Condition
-	PostUpgradeFailed    status.Condition
-	UpgradeSucceeded     status.Condition
-	UpgradeFailed        status.Condition
+	PreUpgradeSucceeded  v1alpha1.Condition
+	PreUpgradeFailed     v1alpha1.Condition
+	PostUpgradeSucceeded v1alpha1.Condition
+	PostUpgradeFailed    v1alpha1.Condition
+	UpgradeSucceeded     v1alpha1.Condition
+	UpgradeFailed        v1alpha1.Condition
 
 	// states specifically downgrade
-	DowngradeSucceeded status.Condition
+	DowngradeSucceeded v1alpha1.Condition
 }
 
 func (s *VersionUpdateStatus) init() {
 	// common states
-	s.Inprogress = status.Condition{
+	s.Inprogress = v1alpha1.Condition{
 		Type:    "VersionUpdateInprogress",
 		Reason:  "Start",
 		Message: "Version update is inprogress",
 	}
-	s.VersionUpdated = status.Condition{
+	s.VersionUpdated = v1alpha1.Condition{
 		Type:    "VersionUpdated",
 		Reason:  "Start",
 		Message: "Version to be used has been updated ",
 	}
 
 	// States for upgrade
-	s.PreUpgradeSucceeded = status.Condition{
+	s.PreUpgradeSucceeded = v1alpha1.Condition{
 		Type:    "VersionPreUpgradeJobSucceeded",
 		Reason:  "Start",
 		Message: "Version pre-upgrade job is succeeded",
 	}
-	s.PreUpgradeFailed = status.Condition{
+	s.PreUpgradeFailed = v1alpha1.Condition{
 		Type:    "VersionPreUpgradeJobFailed",
 		Reason:  "Start",
 		Message: "Version pre-upgrade job is failed",
 	}
-	s.PostUpgradeSucceeded = status.Condition{
+	s.PostUpgradeSucceeded = v1alpha1.Condition{
 		Type:    "VersionPostUpgradeJobSucceeded",
 		Reason:  "Start",
 		Message: "Version post-upgrade job succeeded",
 	}
-	s.PostUpgradeFailed = status.Condition{
+	s.PostUpgradeFailed = v1alpha1.Condition{
 		Type:    "VersionPostUpgradeJobFailed",
 		Reason:  "Start",
 		Message: "Version post-upgrade job failed",
 	}
-	s.UpgradeSucceeded = status.Condition{
+	s.UpgradeSucceeded = v1alpha1.Condition{
 		Type:    "VersionUpgradeSucceeded",
 		Reason:  "Start",
 		Message: "Version upgrade has completed successfully",
 	}
-	s.UpgradeFailed = status.Condition{
+	s.UpgradeFailed = v1alpha1.Condition{
 		Type:    "VersionUpgradeFailed",
 		Reason:  "Start",
 		Message: "Version upgrade has failed",
 	}
 
 	// States for downgrade
-	s.DowngradeSucceeded = status.Condition{
+	s.DowngradeSucceeded = v1alpha1.Condition{
 		Type:    "VersionDowngradeSucceeded",
 		Reason:  "Start",
 		Message: "Version downgrade has succeeded",
@@ -336,7 +345,7 @@ func (s *VersionUpdateStatus) init() {
 func (s *VersionUpdateStatus) clearAllConditions(master *v1alpha1.CDAPMaster) error {
 	conditions := reflect.ValueOf(*s)
 	for i := 0; i < conditions.NumField(); i++ {
-		condition, ok := conditions.Field(i).Interface().(status.Condition)
+		condition, ok := conditions.Field(i).Interface().(v1alpha1.Condition)
 		if !ok {
 			return fmt.Errorf("failed to convert field %s to condition type", conditions.Field(i).Type().Name())
 		}
@@ -345,15 +354,15 @@ func (s *VersionUpdateStatus) clearAllConditions(master *v1alpha1.CDAPMaster) er
 	return nil
 }
 
-func isConditionTrue(master *v1alpha1.CDAPMaster, condition status.Condition) bool {
+func isConditionTrue(master *v1alpha1.CDAPMaster, condition v1alpha1.Condition) bool {
 	return master.Status.IsConditionTrue(condition.Type)
 }
 
-func setCondition(master *v1alpha1.CDAPMaster, condition status.Condition) {
+func setCondition(master *v1alpha1.CDAPMaster, condition v1alpha1.Condition) {
 	master.Status.SetCondition(condition.Type, condition.Reason, condition.Message)
 }
 
-func clearCondition(master *v1alpha1.CDAPMaster, condition status.Condition) {
+func clearCondition(master *v1alpha1.CDAPMaster, condition v1alpha1.Condition) {
 	master.Status.ClearCondition(condition.Type, condition.Reason, condition.Message)
 }
 
@@ -536,5 +545,12 @@ func buildUpgradeJobObject(spec *VersionUpgradeJobSpec) (*reconciler.Object, err
 	if err != nil {
 		return nil, err
 	}
+	// Security context and image pull secrets are passed as structs to bypass the YAML templating logic.
+	job, ok := obj.Obj.(*k8s.Object).Obj.(*batchv1.Job)
+	if !ok {
+		return nil, fmt.Errorf("failed to convert meta object to job object")
+	}
+	setSecurityContextForPod(&job.Spec.Template.Spec, spec.PodSecurityContext, spec.ContainerSecurityContext)
+	job.Spec.Template.Spec.ImagePullSecrets = spec.ImagePullSecrets
 	return obj, nil
 }
@@ -2,6 +2,9 @@ package controllers
 
 import (
 	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	"context"
 	"encoding/json"
 	"github.com/nsf/jsondiff"
 	. "github.com/onsi/ginkgo"
@@ -10,9 +13,6 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler"
-	"sigs.k8s.io/controller-reconciler/pkg/reconciler/manager/k8s"
-	"sigs.k8s.io/controller-reconciler/pkg/status"
 )
 
 var _ = Describe("Controller Suite", func() {
@@ -136,7 +136,7 @@ var _ = Describe("Controller Suite", func() {
 
 			conditions := reflect.ValueOf(*s)
 			for i := 0; i < conditions.NumField(); i++ {
-				condition, ok := conditions.Field(i).Interface().(status.Condition)
+				condition, ok := conditions.Field(i).Interface().(v1alpha1.Condition)
 				Expect(ok).To(BeTrue())
 				Expect(isConditionTrue(master, condition)).To(BeFalse())
 				setCondition(master, condition)
@@ -147,7 +147,7 @@ var _ = Describe("Controller Suite", func() {
 			}
 			s.clearAllConditions(master)
 			for i := 0; i < conditions.NumField(); i++ {
-				condition, ok := conditions.Field(i).Interface().(status.Condition)
+				condition, ok := conditions.Field(i).Interface().(v1alpha1.Condition)
 				Expect(ok).To(BeTrue())
 				Expect(isConditionTrue(master, condition)).To(BeFalse())
 			}
@@ -167,13 +167,13 @@ var _ = Describe("Controller Suite", func() {
 				},
 			}
 
-			objs, updated, err := updateForUserInterface(master)
+			objs, updated, err := updateForUserInterface(context.Background(), master)
 			Expect(objs).To(Equal([]reconciler.Object{}))
 			Expect(updated).To(BeTrue())
 			Expect(err).To(BeNil())
 			Expect(master.Status.UserInterfaceImageToUse).To(Equal(newUIImage))
 
-			objs, updated, err = updateForUserInterface(master)
+			objs, updated, err = updateForUserInterface(context.Background(), master)
 			Expect(objs).To(Equal([]reconciler.Object{}))
 			Expect(updated).To(BeFalse())
 			Expect(err).To(BeNil())
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	"cdap.io/cdap-operator/controllers/reconciler"
+	"cdap.io/cdap-operator/controllers/reconciler/k8s"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Field indexes of CDAPMaster objects by the names of the objects they refer to in their namespace, which are
+// not owned by CDAPMaster
+const (
+	referredConfigMapsIndex = "spec.referredConfigMaps"
+	referredSecretsIndex    = "spec.referredSecrets"
+)
+
+// ownedObjectPredicate filters update events of the objects owned by CDAPMaster, so that status-only churn
+// doesn't trigger reconciliation. Create, delete and generic events always pass.
+var ownedObjectPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return ownedObjectChanged(e.ObjectOld, e.ObjectNew)
+	},
+}
+
+// shardPredicate filters the events of CDAPMaster objects that don't match the shard selector, so that operator
+// replicas with different selectors split CDAPMaster objects between them. A nil selector matches everything.
+func shardPredicate(selector labels.Selector) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return inShard(selector, o)
+	})
+}
+
+// Return whether the object belongs to the shard of the selector
+func inShard(selector labels.Selector, o client.Object) bool {
+	return selector == nil || selector.Matches(labels.Set(o.GetLabels()))
+}
+
+// Return whether the update of an owned object needs to be reconciled. Spec and metadata changes always do,
+// while status changes only do when CDAPMaster reacts to them.
+func ownedObjectChanged(oldObj, newObj client.Object) bool {
+	if oldObj.GetGeneration() != newObj.GetGeneration() ||
+		!reflect.DeepEqual(oldObj.GetLabels(), newObj.GetLabels()) ||
+		!reflect.DeepEqual(oldObj.GetAnnotations(), newObj.GetAnnotations()) ||
+		!reflect.DeepEqual(oldObj.GetOwnerReferences(), newObj.GetOwnerReferences()) ||
+		!oldObj.GetDeletionTimestamp().Equal(newObj.GetDeletionTimestamp()) {
+		return true
+	}
+	switch o := oldObj.(type) {
+	case *batchv1.Job:
+		// Version upgrade advances when upgrade jobs succeed or fail
+		n := newObj.(*batchv1.Job)
+		return o.Status.Succeeded != n.Status.Succeeded || o.Status.Failed != n.Status.Failed
+	// Objects below don't have their generation bumped on changes
+	case *corev1.Service:
+		n := newObj.(*corev1.Service)
+		return !equality.Semantic.DeepEqual(o.Spec, n.Spec)
+	case *corev1.ConfigMap:
+		n := newObj.(*corev1.ConfigMap)
+		return !reflect.DeepEqual(o.Data, n.Data) || !reflect.DeepEqual(o.BinaryData, n.BinaryData)
+	case *corev1.Secret:
+		n := newObj.(*corev1.Secret)
+		return !reflect.DeepEqual(o.Data, n.Data)
+	}
+	return false
+}
+
+// Return the objects referred by the CDAPMaster: the ConfigMaps of manifest patches and log appenders, and the
+// Secrets of secretConfig
+func getReferredDependents(master *v1alpha1.CDAPMaster) []reconciler.Object {
+	var objs []reconciler.Object
+	objs = append(objs, getManifestPatchesDependents(master)...)
+	objs = append(objs, getLoggingDependents(master)...)
+	return append(objs, getSecretConfigDependents(master)...)
+}
+
+// referredNamesIndexer returns the indexer of CDAPMaster objects by the names of the referred objects of the
+// same type as obj
+func referredNamesIndexer(obj client.Object) client.IndexerFunc {
+	return func(o client.Object) []string {
+		var names []string
+		for _, item := range getReferredDependents(o.(*v1alpha1.CDAPMaster)) {
+			referred := item.Obj.(*k8s.Object).Obj
+			if reflect.TypeOf(referred) == reflect.TypeOf(obj) {
+				names = append(names, referred.GetName())
+			}
+		}
+		return names
+	}
+}
+
+// referringMasters returns the event handler enqueuing the CDAPMaster objects of the shard that refer to the
+// object through the index, since referred objects are not owned and their changes would otherwise only be
+// picked up on the next periodic reconciliation
+func referringMasters(c client.Reader, index string, selector labels.Selector) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+		masters := &v1alpha1.CDAPMasterList{}
+		if err := c.List(context.Background(), masters, client.InNamespace(o.GetNamespace()), client.MatchingFields{index: o.GetName()}); err != nil {
+			log.Log.Error(err, "Failed to list CDAPMaster objects referring to object", "namespace", o.GetNamespace(), "name", o.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range masters.Items {
+			if inShard(selector, &masters.Items[i]) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&masters.Items[i])})
+			}
+		}
+		return requests
+	})
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"context"
+
+	"cdap.io/cdap-operator/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// indexedReader filters the CDAPMaster objects listed with a field selector through the indexers, like the cache
+// of the manager does, since the fake client doesn't support field indexes
+type indexedReader struct {
+	client.Reader
+	indexers map[string]client.IndexerFunc
+}
+
+func (r *indexedReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	selector := listOpts.FieldSelector
+	listOpts.FieldSelector = nil
+	if err := r.Reader.List(ctx, list, listOpts); err != nil || selector == nil {
+		return err
+	}
+	masters := list.(*v1alpha1.CDAPMasterList)
+	var matched []v1alpha1.CDAPMaster
+	for _, m := range masters.Items {
+		matches := true
+		for _, req := range selector.Requirements() {
+			found := false
+			for _, value := range r.indexers[req.Field](&m) {
+				found = found || value == req.Value
+			}
+			matches = matches && found
+		}
+		if matches {
+			matched = append(matched, m)
+		}
+	}
+	masters.Items = matched
+	return nil
+}
+
+var _ = Describe("Controller Suite", func() {
+	Describe("Shard watches", func() {
+		master := func(l map[string]string) client.Object {
+			return &v1alpha1.CDAPMaster{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: l}}
+		}
+		It("All CDAPMaster objects are reconciled without a selector", func() {
+			p := shardPredicate(nil)
+			Expect(p.Create(event.CreateEvent{Object: master(nil)})).To(BeTrue())
+		})
+		It("Only CDAPMaster objects matching the selector are reconciled", func() {
+			p := shardPredicate(labels.SelectorFromSet(labels.Set{"shard": "a"}))
+			Expect(p.Create(event.CreateEvent{Object: master(map[string]string{"shard": "a"})})).To(BeTrue())
+			Expect(p.Create(event.CreateEvent{Object: master(map[string]string{"shard": "b"})})).To(BeFalse())
+			Expect(p.Update(event.UpdateEvent{
+				ObjectOld: master(map[string]string{"shard": "b"}),
+				ObjectNew: master(map[string]string{"shard": "a"}),
+			})).To(BeTrue())
+		})
+	})
+	Describe("Owned object watches", func() {
+		updated := func(oldObj client.Object, mutate func(client.Object)) bool {
+			newObj := oldObj.DeepCopyObject().(client.Object)
+			newObj.SetResourceVersion("2")
+			mutate(newObj)
+			return ownedObjectPredicate.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj})
+		}
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "cdap-test-appfabric", Generation: 1, ResourceVersion: "1"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(1)},
+		}
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "cdap-test-pre-upgrade-job", Generation: 1, ResourceVersion: "1"}}
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "cdap-test-router", ResourceVersion: "1"}}
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cdap-test-cconf", ResourceVersion: "1"},
+			Data:       map[string]string{"cdap-site.xml": "<configuration/>"},
+		}
+
+		It("Spec and metadata changes are reconciled", func() {
+			Expect(updated(sts, func(o client.Object) { o.SetGeneration(2) })).To(BeTrue())
+			Expect(updated(sts, func(o client.Object) { o.SetLabels(map[string]string{"a": "b"}) })).To(BeTrue())
+			Expect(updated(job, func(o client.Object) { o.SetAnnotations(map[string]string{"a": "b"}) })).To(BeTrue())
+			Expect(updated(service, func(o client.Object) {
+				o.(*corev1.Service).Spec.Ports = []corev1.ServicePort{{Port: 11015, TargetPort: intstr.FromInt(11015)}}
+			})).To(BeTrue())
+			Expect(updated(configMap, func(o client.Object) {
+				o.(*corev1.ConfigMap).Data["cdap-site.xml"] = "<configuration></configuration>"
+			})).To(BeTrue())
+		})
+		It("Status changes CDAPMaster depends on are reconciled", func() {
+			Expect(updated(job, func(o client.Object) { o.(*batchv1.Job).Status.Succeeded = 1 })).To(BeTrue())
+			Expect(updated(job, func(o client.Object) { o.(*batchv1.Job).Status.Failed = 1 })).To(BeTrue())
+		})
+		It("Status-only churn is ignored", func() {
+			Expect(updated(sts, func(o client.Object) { o.(*appsv1.StatefulSet).Status.ObservedGeneration = 1 })).To(BeFalse())
+			Expect(updated(sts, func(o client.Object) { o.(*appsv1.StatefulSet).Status.ReadyReplicas = 1 })).To(BeFalse())
+			Expect(updated(job, func(o client.Object) { o.(*batchv1.Job).Status.Active = 1 })).To(BeFalse())
+			Expect(updated(service, func(o client.Object) {
+				o.(*corev1.Service).Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+			})).To(BeFalse())
+			Expect(updated(configMap, func(o client.Object) {})).To(BeFalse())
+		})
+	})
+	Describe("Referred object watches", func() {
+		var (
+			reader *indexedReader
+		)
+		newMaster := func(name, namespace string, l map[string]string) *v1alpha1.CDAPMaster {
+			return &v1alpha1.CDAPMaster{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: l}}
+		}
+		BeforeEach(func() {
+			patches := newMaster("patches", "default", nil)
+			patches.Spec.ManifestPatchesConfigMap = "referred"
+			appenders := newMaster("appenders", "default", nil)
+			appenders.Spec.Logging = &v1alpha1.LoggingSpec{AppendersConfigMap: "referred"}
+			secrets := newMaster("secrets", "default", nil)
+			secrets.Spec.SecretConfig = map[string]corev1.SecretKeySelector{
+				"security.store.password": {LocalObjectReference: corev1.LocalObjectReference{Name: "referred"}, Key: "password"},
+			}
+			otherNamespace := newMaster("patches", "other", nil)
+			otherNamespace.Spec.ManifestPatchesConfigMap = "referred"
+			otherShard := newMaster("other-shard", "default", map[string]string{"shard": "b"})
+			otherShard.Spec.ManifestPatchesConfigMap = "referred"
+
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+			Expect(v1alpha1.AddToScheme(s)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(s).
+				WithObjects(patches, appenders, secrets, otherNamespace, otherShard, newMaster("unrelated", "default", nil)).
+				Build()
+			reader = &indexedReader{Reader: c, indexers: map[string]client.IndexerFunc{
+				referredConfigMapsIndex: referredNamesIndexer(&corev1.ConfigMap{}),
+				referredSecretsIndex:    referredNamesIndexer(&corev1.Secret{}),
+			}}
+		})
+		// Return the requests enqueued when the object is updated from old to new
+		enqueued := func(index string, oldObj, newObj client.Object) []reconcile.Request {
+			queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer queue.ShutDown()
+			selector, err := labels.Parse("shard!=b")
+			Expect(err).To(BeNil())
+			h := referringMasters(reader, index, selector)
+			h.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj}, queue)
+			var requests []reconcile.Request
+			for queue.Len() > 0 {
+				item, _ := queue.Get()
+				requests = append(requests, item.(reconcile.Request))
+				queue.Done(item)
+			}
+			return requests
+		}
+		It("Editing a referred ConfigMap reconciles the CDAPMaster objects referring to it", func() {
+			oldConfigMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "referred", Namespace: "default", ResourceVersion: "1"},
+				Data:       map[string]string{"patch.yaml": "{}"},
+			}
+			newConfigMap := oldConfigMap.DeepCopy()
+			newConfigMap.ResourceVersion = "2"
+			newConfigMap.Data["patch.yaml"] = "target: {kind: StatefulSet}"
+			Expect(ownedObjectPredicate.Update(event.UpdateEvent{ObjectOld: oldConfigMap, ObjectNew: newConfigMap})).To(BeTrue())
+			Expect(enqueued(referredConfigMapsIndex, oldConfigMap, newConfigMap)).To(ConsistOf(
+				reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "patches"}},
+				reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "appenders"}},
+			))
+		})
+		It("Editing a referred Secret reconciles the CDAPMaster objects referring to it", func() {
+			oldSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "referred", Namespace: "default", ResourceVersion: "1"},
+				Data:       map[string][]byte{"password": []byte("a")},
+			}
+			newSecret := oldSecret.DeepCopy()
+			newSecret.ResourceVersion = "2"
+			newSecret.Data["password"] = []byte("b")
+			Expect(enqueued(referredSecretsIndex, oldSecret, newSecret)).To(ConsistOf(
+				reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "secrets"}},
+			))
+		})
+		It("Objects that are not referred don't reconcile anything", func() {
+			configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cdap-test-cconf", Namespace: "default"}}
+			Expect(enqueued(referredConfigMapsIndex, configMap, configMap)).To(BeEmpty())
+		})
+	})
+})
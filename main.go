@@ -17,28 +17,44 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	cdapv1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	cdapv1beta1 "cdap.io/cdap-operator/api/v1beta1"
 	"cdap.io/cdap-operator/controllers"
 	cdapwebhooks "cdap.io/cdap-operator/webhooks"
 	//+kubebuilder:scaffold:imports
 )
 
+// serviceAccountNamespaceFile contains the namespace of the pod the operator runs in.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// crdName is the name of the CDAPMaster CustomResourceDefinition.
+const crdName = "cdapmasters.cdap.cdap.io"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -46,8 +62,10 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(cdapv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(cdapv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -57,6 +75,16 @@ func main() {
 	var probeAddr string
 	var enableWebhook bool
 	var webhookPort int
+	var webhookFailurePolicy string
+	var webhookCertDir string
+	var webhookSelfSignedCerts bool
+	var webhookNamespace string
+	var webhookServiceName string
+	var webhookCertSecret string
+	var webhookConfigName string
+	var watchNamespaces string
+	var maxConcurrentReconciles int
+	var shardSelector string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -66,6 +94,33 @@ func main() {
 		"Enable the admission controller webhook server. "+
 			"Enabling this will allow the operator to mutate CDAP pods based on the mutation configuration in the CR.")
 	flag.IntVar(&webhookPort, "webhook-server-port", 9443, "The port on which the webhook server will listen.")
+	flag.StringVar(&webhookFailurePolicy, "webhook-failure-policy", string(cdapv1alpha1.MutationFailurePolicyFail),
+		"The policy for pods that the webhook fails to mutate, e.g. when the CDAPMaster they belong to doesn't exist. "+
+			"\"Fail\" rejects the pods and \"Ignore\" admits them without mutations. "+
+			"It can be overridden by the mutationFailurePolicy of each CDAPMaster.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"),
+		"The directory containing the tls.crt and tls.key of the webhook server.")
+	flag.BoolVar(&webhookSelfSignedCerts, "webhook-self-signed-certs", false,
+		"Generate a self-signed CA and serving certificate for the webhook server, store them in a secret, "+
+			"inject the CA into the MutatingWebhookConfiguration and the CRD conversion webhook and rotate them before they expire. "+
+			"Enabling this removes the need for cert-manager.")
+	flag.StringVar(&webhookNamespace, "webhook-namespace", "",
+		"The namespace of the webhook service and certificate secret. Defaults to the namespace of the operator pod.")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", "cdap-webhook-server",
+		"The name of the service of the webhook server, used for the self-signed certificate.")
+	flag.StringVar(&webhookCertSecret, "webhook-cert-secret", "cdap-webhook-server-cert",
+		"The name of the secret storing the self-signed certificates.")
+	flag.StringVar(&webhookConfigName, "webhook-configuration-name", "cdap-webhook",
+		"The name of the MutatingWebhookConfiguration to inject the self-signed CA into.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated namespaces the operator watches CDAPMaster objects and the objects they own in. "+
+			"Defaults to all namespaces. The webhook only mutates pods of CDAPMaster objects in these namespaces.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of CDAPMaster objects reconciled in parallel.")
+	flag.StringVar(&shardSelector, "shard-selector", "",
+		"A label selector of the CDAPMaster objects the operator reconciles, e.g. \"cdap.io/shard=a\". "+
+			"Operator replicas with disjoint selectors split CDAPMaster objects between them. "+
+			"Each selector elects its own leader. Defaults to all CDAPMaster objects.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -74,14 +129,43 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	shard, err := labels.Parse(shardSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid shard selector", "selector", shardSelector)
+		os.Exit(1)
+	}
+	leaderElectionID := "548f2421.cdap.io"
+	if !shard.Empty() {
+		// Replicas of different shards must not compete for the same lease
+		sum := sha256.Sum256([]byte(shard.String()))
+		leaderElectionID = fmt.Sprintf("%x-%s", sum[:4], leaderElectionID)
+		setupLog.Info("reconciling a shard", "selector", shard.String())
+	} else {
+		shard = nil
+	}
+
+	options := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   webhookPort,
+		CertDir:                webhookCertDir,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "548f2421.cdap.io",
-	})
+		LeaderElectionID:       leaderElectionID,
+	}
+	// Restrict the cache, and thus the watches and the RBAC the operator needs, to the watched namespaces.
+	namespaces := parseNamespaces(watchNamespaces)
+	switch len(namespaces) {
+	case 0:
+		setupLog.Info("watching all namespaces")
+	case 1:
+		setupLog.Info("watching a single namespace", "namespace", namespaces[0])
+		options.Namespace = namespaces[0]
+	default:
+		setupLog.Info("watching multiple namespaces", "namespaces", namespaces)
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
@@ -89,7 +173,11 @@ func main() {
 
 	if err = (&controllers.CDAPMasterReconciler{
 		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("CDAPMaster"),
 		Scheme: mgr.GetScheme(),
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		ShardSelector:           shard,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CDAPMaster")
 		os.Exit(1)
@@ -97,7 +185,50 @@ func main() {
 
 	if enableWebhook {
 		setupLog.Info(fmt.Sprintf("Starting webhook server at port %d", webhookPort))
-		mgr.GetWebhookServer().Register("/mutate-v1-pod", &webhook.Admission{Handler: cdapwebhooks.NewPodMutator(mgr.GetClient())})
+		failurePolicy := cdapv1alpha1.MutationFailurePolicy(webhookFailurePolicy)
+		if failurePolicy != cdapv1alpha1.MutationFailurePolicyFail && failurePolicy != cdapv1alpha1.MutationFailurePolicyIgnore {
+			setupLog.Error(fmt.Errorf("invalid webhook failure policy %q", webhookFailurePolicy), "unable to create webhook")
+			os.Exit(1)
+		}
+		// The manager cache is informer-backed and already watches CDAPMaster objects for the controller.
+		mutator := cdapwebhooks.NewPodMutator(mgr.GetCache(), failurePolicy)
+		mutator.Namespaces = namespaces
+		mgr.GetWebhookServer().Register("/mutate-v1-pod", &webhook.Admission{Handler: mutator})
+		clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create webhook preview endpoint")
+			os.Exit(1)
+		}
+		mgr.GetWebhookServer().Register("/preview-v1-pod", cdapwebhooks.NewPreviewHandler(mutator, clientset))
+		// The API server converts CDAPMaster objects between v1alpha1 and v1beta1 through /convert.
+		if err := (&cdapv1alpha1.CDAPMaster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create conversion webhook")
+			os.Exit(1)
+		}
+
+		if webhookSelfSignedCerts {
+			if webhookNamespace == "" {
+				ns, err := os.ReadFile(serviceAccountNamespaceFile)
+				if err != nil {
+					setupLog.Error(err, "unable to determine the webhook namespace, set --webhook-namespace")
+					os.Exit(1)
+				}
+				webhookNamespace = strings.TrimSpace(string(ns))
+			}
+			rotator := cdapwebhooks.NewCertRotator(mgr.GetClient(), mgr.GetAPIReader(),
+				client.ObjectKey{Namespace: webhookNamespace, Name: webhookCertSecret}, webhookConfigName, webhookServiceName, webhookCertDir)
+			rotator.CRDName = crdName
+			// The webhook server needs the certificate when it starts, so provision it before the manager starts.
+			// Replicas starting at the same time adopt the certificates of the first one to store them.
+			if err := rotator.EnsureCerts(context.Background()); err != nil {
+				setupLog.Error(err, "unable to provision webhook certificates")
+				os.Exit(1)
+			}
+			if err := mgr.Add(rotator); err != nil {
+				setupLog.Error(err, "unable to set up webhook certificate rotation")
+				os.Exit(1)
+			}
+		}
 	}
 
 	//+kubebuilder:scaffold:builder
@@ -117,3 +248,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseNamespaces returns the non-empty namespaces of the comma-separated list.
+func parseNamespaces(list string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(list, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
@@ -1,30 +0,0 @@
-/*
-Copyright 2018 The Kubernetes Authors.
-
-Licensed under the Apache License, Version 2.0 (the "License");
-you may not use this file except in compliance with the License.
-You may obtain a copy of the License at
-
-    http://www.apache.org/licenses/LICENSE-2.0
-
-Unless required by applicable law or agreed to in writing, software
-distributed under the License is distributed on an "AS IS" BASIS,
-WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-See the License for the specific language governing permissions and
-limitations under the License.
-*/
-
-/*
-Package pkg provides libraries for building Controller reconciler.
-
-Reconciler
-
-Reconciler provides a library to implement reconciler for a controller built using controller-runtime
-
-Usage
-
-The following example shows creating a new Controller program which Reconciles a FooBar CRD
-
-*/
-//go:generate go run ../vendor/k8s.io/code-generator/cmd/deepcopy-gen/main.go -O zz_generated.deepcopy -i ./... -h boilerplate.go.txt
-package pkg
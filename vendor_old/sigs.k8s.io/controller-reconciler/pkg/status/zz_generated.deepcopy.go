@@ -1,160 +0,0 @@
-// +build !ignore_autogenerated
-
-/*
-Copyright 2018 The Kubernetes Authors.
-
-Licensed under the Apache License, Version 2.0 (the "License");
-you may not use this file except in compliance with the License.
-You may obtain a copy of the License at
-
-    http://www.apache.org/licenses/LICENSE-2.0
-
-Unless required by applicable law or agreed to in writing, software
-distributed under the License is distributed on an "AS IS" BASIS,
-WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-See the License for the specific language governing permissions and
-limitations under the License.
-*/
-// Code generated by main. DO NOT EDIT.
-
-package status
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ComponentList) DeepCopyInto(out *ComponentList) {
-	*out = *in
-	if in.Objects != nil {
-		in, out := &in.Objects, &out.Objects
-		*out = make([]ObjectStatus, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentList.
-func (in *ComponentList) DeepCopy() *ComponentList {
-	if in == nil {
-		return nil
-	}
-	out := new(ComponentList)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ComponentMeta) DeepCopyInto(out *ComponentMeta) {
-	*out = *in
-	in.ComponentList.DeepCopyInto(&out.ComponentList)
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentMeta.
-func (in *ComponentMeta) DeepCopy() *ComponentMeta {
-	if in == nil {
-		return nil
-	}
-	out := new(ComponentMeta)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Condition) DeepCopyInto(out *Condition) {
-	*out = *in
-	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
-func (in *Condition) DeepCopy() *Condition {
-	if in == nil {
-		return nil
-	}
-	out := new(Condition)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExtendedStatus) DeepCopyInto(out *ExtendedStatus) {
-	*out = *in
-	if in.STS != nil {
-		in, out := &in.STS, &out.STS
-		*out = new(Statefulset)
-		**out = **in
-	}
-	if in.PDB != nil {
-		in, out := &in.PDB, &out.PDB
-		*out = new(Pdb)
-		**out = **in
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtendedStatus.
-func (in *ExtendedStatus) DeepCopy() *ExtendedStatus {
-	if in == nil {
-		return nil
-	}
-	out := new(ExtendedStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Meta) DeepCopyInto(out *Meta) {
-	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Meta.
-func (in *Meta) DeepCopy() *Meta {
-	if in == nil {
-		return nil
-	}
-	out := new(Meta)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectStatus) DeepCopyInto(out *ObjectStatus) {
-	*out = *in
-	in.ExtendedStatus.DeepCopyInto(&out.ExtendedStatus)
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStatus.
-func (in *ObjectStatus) DeepCopy() *ObjectStatus {
-	if in == nil {
-		return nil
-	}
-	out := new(ObjectStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Statefulset) DeepCopyInto(out *Statefulset) {
-	*out = *in
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Statefulset.
-func (in *Statefulset) DeepCopy() *Statefulset {
-	if in == nil {
-		return nil
-	}
-	out := new(Statefulset)
-	in.DeepCopyInto(out)
-	return out
-}
@@ -1,92 +0,0 @@
-// +build !ignore_autogenerated
-
-/*
-Copyright 2018 The Kubernetes Authors.
-
-Licensed under the Apache License, Version 2.0 (the "License");
-you may not use this file except in compliance with the License.
-You may obtain a copy of the License at
-
-    http://www.apache.org/licenses/LICENSE-2.0
-
-Unless required by applicable law or agreed to in writing, software
-distributed under the License is distributed on an "AS IS" BASIS,
-WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-See the License for the specific language governing permissions and
-limitations under the License.
-*/
-// Code generated by main. DO NOT EDIT.
-
-package storage
-
-import (
-	v1 "k8s.io/api/core/v1"
-)
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FS) DeepCopyInto(out *FS) {
-	*out = *in
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FS.
-func (in *FS) DeepCopy() *FS {
-	if in == nil {
-		return nil
-	}
-	out := new(FS)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GCS) DeepCopyInto(out *GCS) {
-	*out = *in
-	if in.Secret != nil {
-		in, out := &in.Secret, &out.Secret
-		*out = new(v1.SecretReference)
-		**out = **in
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCS.
-func (in *GCS) DeepCopy() *GCS {
-	if in == nil {
-		return nil
-	}
-	out := new(GCS)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Spec) DeepCopyInto(out *Spec) {
-	*out = *in
-	if in.GCS != nil {
-		in, out := &in.GCS, &out.GCS
-		*out = new(GCS)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.NFS != nil {
-		in, out := &in.NFS, &out.NFS
-		*out = new(v1.NFSVolumeSource)
-		**out = **in
-	}
-	if in.FS != nil {
-		in, out := &in.FS, &out.FS
-		*out = new(FS)
-		**out = **in
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Spec.
-func (in *Spec) DeepCopy() *Spec {
-	if in == nil {
-		return nil
-	}
-	out := new(Spec)
-	in.DeepCopyInto(out)
-	return out
-}
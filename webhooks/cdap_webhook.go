@@ -4,15 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"time"
 
 	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -20,83 +22,263 @@ const (
 	labelInstanceKey           = "cdap.instance"
 	// cdapMasterNamespaceKey is the label added by CDAP on pods launched by CDAP.
 	cdapMasterNamespaceKey     = "cdap.k8s.namespace"
-	// customResourceNamespaceKey is the label added by controller-reconciler on resources
+	// customResourceNamespaceKey is the label added by the CDAPMaster reconciler on resources
 	// managed by the CDAP operator.
 	customResourceNamespaceKey = "custom-resource-namespace"
+	// mutationFailurePolicyAnnotation is the annotation holding the failure policy of the CDAPMaster on the
+	// ConfigMaps the operator creates for it, which outlive the CDAPMaster until they are garbage collected.
+	mutationFailurePolicyAnnotation = "cdap.io/mutation-failure-policy"
 )
 
+// webhookLog is the logger of the webhooks. Loggers with the keys of the request are passed along in the context.
+var webhookLog = logf.Log.WithName("webhooks")
+
 type PodMutator struct {
-	Client  client.Client
-	decoder *admission.Decoder
+	// Client reads CDAPMaster objects. The webhook server uses the informer-backed cache of the manager,
+	// so that admission requests don't hit the API server.
+	Client client.Reader
+	// FailurePolicy is used for pods that fail to be mutated, unless overridden in the CDAPMaster.
+	FailurePolicy v1alpha1.MutationFailurePolicy
+	// Namespaces are the namespaces of the CDAPMaster objects whose pods are mutated. All namespaces if empty.
+	// It should match the namespaces the operator watches, since the cache only holds CDAPMaster objects there.
+	Namespaces []string
+	decoder    *admission.Decoder
 }
 
-func NewPodMutator(client client.Client) *PodMutator {
+func NewPodMutator(reader client.Reader, failurePolicy v1alpha1.MutationFailurePolicy) *PodMutator {
 	return &PodMutator{
-		Client: client,
+		Client:        reader,
+		FailurePolicy: failurePolicy,
 	}
 }
 
 func (s *PodMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	start := time.Now()
+	ctx = logf.IntoContext(ctx, webhookLog.WithValues("namespace", req.Namespace, "uid", req.UID))
+	resp, result := s.handle(ctx, req)
+	podMutationsTotal.WithLabelValues(result).Inc()
+	podMutationDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	return resp
+}
+
+// handle mutates the pod in the request and returns the response along with the result for metrics.
+func (s *PodMutator) handle(ctx context.Context, req admission.Request) (admission.Response, string) {
 	pod := &corev1.Pod{}
 	err := s.decoder.Decode(req, pod)
 	if err != nil {
-		return admission.Errored(http.StatusBadRequest, err)
+		return admission.Errored(http.StatusBadRequest, err), mutationResultErrored
 	}
 
 	// Apply pod mutations.
-	if err := s.mutatePod(ctx, pod); err != nil {
-		log.Printf("Error while getting cdap master: %v", err)
+	if _, err := s.mutatePod(ctx, pod); err != nil {
+		logf.FromContext(ctx).Error(err, "Error while getting cdap master", "pod", pod.Name)
+		if s.failurePolicy(ctx, pod) == v1alpha1.MutationFailurePolicyIgnore {
+			return admission.Allowed("").WithWarnings(fmt.Sprintf("Pod admitted without mutations: %v", err)), mutationResultIgnored
+		}
 		if errors.IsNotFound(err) {
-			return admission.Denied(err.Error())
+			return admission.Denied(err.Error()), mutationResultDenied
 		}
-		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("Error while getting cdap master: %v", err))
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("Error while getting cdap master: %v", err)), mutationResultErrored
 
 	}
+	return patchResponse(req.Object.Raw, pod), mutationResultMutated
+}
+
+// patchResponse returns the admission response patching the original pod into the mutated one.
+func patchResponse(original []byte, pod *corev1.Pod) admission.Response {
 	marshaledPod, err := json.Marshal(pod)
 	if err != nil {
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
+	return admission.PatchResponseFromRaw(original, marshaledPod)
 }
 
-func (s *PodMutator) mutatePod(ctx context.Context, pod *corev1.Pod) error {
-	log.Printf("Got admission request for pod name: %s", pod.Name)
-
+// mutatePod applies the mutation configs of the CDAPMaster the pod belongs to and returns the matching label selectors.
+func (s *PodMutator) mutatePod(ctx context.Context, pod *corev1.Pod) ([]metav1.LabelSelector, error) {
 	cdapMasterName := pod.ObjectMeta.Labels[labelInstanceKey]
-	cdapMaster, err := s.cdapMaster(ctx, cdapMasterName, cdapMasterNamespace(pod))
+	logger := logf.FromContext(ctx, "pod", pod.Name, "cdapmaster", cdapMasterName)
+	logger.V(1).Info("Got admission request for pod")
+	ctx = logf.IntoContext(ctx, logger)
+
+	namespace := cdapMasterNamespace(pod)
+	if !s.watches(namespace) {
+		// Treat the CDAPMaster as missing, so that the failure policy applies like for a deleted CDAPMaster.
+		err := errors.NewNotFound(v1alpha1.GroupVersion.WithResource("cdapmasters").GroupResource(), cdapMasterName)
+		return nil, fmt.Errorf("CDAPMaster namespace %q is not watched by the operator: %w", namespace, err)
+	}
+	cdapMaster, err := s.cdapMaster(ctx, cdapMasterName, namespace)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return fmt.Errorf("pod label %q refers to a non-existent CDAPMaster object %q: %w", labelInstanceKey, cdapMasterName, err)
+			return nil, fmt.Errorf("pod label %q refers to a non-existent CDAPMaster object %q: %w", labelInstanceKey, cdapMasterName, err)
 		}
-		return err
+		return nil, err
 	}
+	return applyMutationConfigs(logger, cdapMaster, pod), nil
+}
 
+// applyMutationConfigs applies the mutation configs whose label selector matches the pod, in order, and returns
+// the matching label selectors.
+func applyMutationConfigs(logger logr.Logger, cdapMaster *v1alpha1.CDAPMaster, pod *corev1.Pod) []metav1.LabelSelector {
+	var matched []metav1.LabelSelector
 	mutationConfigs := cdapMaster.Spec.MutationConfigs
 	for _, mc := range mutationConfigs {
 		selector, err := metav1.LabelSelectorAsSelector(&mc.LabelSelector)
 		if err != nil {
-			log.Printf("Ignoring failure to parse label selector: %v", err)
+			logger.Error(err, "Ignoring mutation config with invalid label selector")
 			continue
 		}
 		if !selector.Matches(labels.Set(pod.Labels)) {
 			continue
 		}
 
-		if mc.PodMutations.NodeSelector != nil {
-			if pod.Spec.NodeSelector == nil {
-				pod.Spec.NodeSelector = map[string]string{}
+		applyPodMutations(pod, &mc.PodMutations)
+		matched = append(matched, mc.LabelSelector)
+	}
+	return matched
+}
+
+// applyPodMutations applies the mutations to the pod. It is idempotent so that the pod stays the same when
+// it is admitted again, e.g. on webhook reinvocation.
+func applyPodMutations(pod *corev1.Pod, pm *v1alpha1.PodMutationConfig) {
+	if pm.NodeSelector != nil {
+		if pod.Spec.NodeSelector == nil {
+			pod.Spec.NodeSelector = map[string]string{}
+		}
+		for key, value := range *pm.NodeSelector {
+			pod.Spec.NodeSelector[key] = value
+		}
+	}
+	pod.Labels = mergeStringMaps(pod.Labels, pm.Labels)
+	pod.Annotations = mergeStringMaps(pod.Annotations, pm.Annotations)
+
+	for _, toleration := range pm.Tolerations {
+		if !hasToleration(pod.Spec.Tolerations, toleration) {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+		}
+	}
+
+	if pm.Affinity != nil {
+		pod.Spec.Affinity = pm.Affinity.DeepCopy()
+	}
+	if pm.PriorityClassName != nil && pod.Spec.PriorityClassName != *pm.PriorityClassName {
+		pod.Spec.PriorityClassName = *pm.PriorityClassName
+		// Priority is resolved from the priority class on admission and must not conflict with it
+		pod.Spec.Priority = nil
+	}
+
+	for _, volume := range pm.Volumes {
+		if !hasVolume(pod.Spec.Volumes, volume.Name) {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+		}
+	}
+
+	// Env vars, volume mounts and resources only apply to the containers in the pod spec, not the injected ones.
+	for i := range pod.Spec.Containers {
+		if hasContainer(pm.ContainersAfter, pod.Spec.Containers[i].Name) {
+			continue
+		}
+		mutateContainer(&pod.Spec.Containers[i], pm)
+	}
+
+	var ics []corev1.Container
+	for _, c := range pm.InitContainersBefore {
+		if !hasContainer(pod.Spec.InitContainers, c.Name) {
+			ics = append(ics, c)
+		}
+	}
+	pod.Spec.InitContainers = append(ics, pod.Spec.InitContainers...)
+
+	for _, c := range pm.ContainersAfter {
+		if !hasContainer(pod.Spec.Containers, c.Name) {
+			pod.Spec.Containers = append(pod.Spec.Containers, c)
+		}
+	}
+}
+
+// mutateContainer sets the env vars, volume mounts and resources of the container.
+func mutateContainer(c *corev1.Container, pm *v1alpha1.PodMutationConfig) {
+	for _, envVar := range pm.Env {
+		found := false
+		for i := range c.Env {
+			if c.Env[i].Name == envVar.Name {
+				c.Env[i] = envVar
+				found = true
+				break
 			}
-			for key, value := range *mc.PodMutations.NodeSelector {
-				pod.Spec.NodeSelector[key] = value
+		}
+		if !found {
+			c.Env = append(c.Env, envVar)
+		}
+	}
+
+	for _, mount := range pm.VolumeMounts {
+		found := false
+		for _, m := range c.VolumeMounts {
+			if m.MountPath == mount.MountPath {
+				found = true
+				break
 			}
 		}
+		if !found {
+			c.VolumeMounts = append(c.VolumeMounts, mount)
+		}
+	}
+
+	if pm.Resources == nil {
+		return
+	}
+	for name, quantity := range pm.Resources.Requests {
+		if c.Resources.Requests == nil {
+			c.Resources.Requests = corev1.ResourceList{}
+		}
+		c.Resources.Requests[name] = quantity
+	}
+	for name, quantity := range pm.Resources.Limits {
+		if c.Resources.Limits == nil {
+			c.Resources.Limits = corev1.ResourceList{}
+		}
+		c.Resources.Limits[name] = quantity
+	}
+}
 
-		pod.Spec.Tolerations = append(pod.Spec.Tolerations, mc.PodMutations.Tolerations...)
+func mergeStringMaps(current, added map[string]string) map[string]string {
+	if len(added) == 0 {
+		return current
+	}
+	if current == nil {
+		current = map[string]string{}
+	}
+	for key, value := range added {
+		current[key] = value
+	}
+	return current
+}
 
-		ics := append([]corev1.Container{}, mc.PodMutations.InitContainersBefore...)
-		pod.Spec.InitContainers = append(ics, pod.Spec.InitContainers...)
+func hasToleration(tolerations []corev1.Toleration, toleration corev1.Toleration) bool {
+	for _, t := range tolerations {
+		if t.MatchToleration(&toleration) {
+			return true
+		}
 	}
-	return nil
+	return false
+}
+
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasContainer(containers []corev1.Container, name string) bool {
+	for _, c := range containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *PodMutator) InjectDecoder(d *admission.Decoder) error {
@@ -105,15 +287,57 @@ func (s *PodMutator) InjectDecoder(d *admission.Decoder) error {
 }
 
 func (s *PodMutator) cdapMaster(ctx context.Context, cdapMasterName, namespace string) (*v1alpha1.CDAPMaster, error) {
-	log.Printf("Fetching CDAP CR with name %q.", cdapMasterName)
+	logf.FromContext(ctx).V(1).Info("Fetching CDAPMaster", "namespace", namespace)
 	var cdapMaster v1alpha1.CDAPMaster
-	err := s.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: cdapMasterName}, &cdapMaster)
+	key := client.ObjectKey{Namespace: namespace, Name: cdapMasterName}
+	err := s.Client.Get(ctx, key, &cdapMaster)
 	if err != nil {
 		return nil, err
 	}
 	return &cdapMaster, nil
 }
 
+// failurePolicy returns the failure policy of the CDAPMaster the pod belongs to, or the default one. The policy
+// of a deleted CDAPMaster is read from the annotation on its ConfigMaps, as long as they are not garbage collected.
+func (s *PodMutator) failurePolicy(ctx context.Context, pod *corev1.Pod) v1alpha1.MutationFailurePolicy {
+	namespace, name := cdapMasterNamespace(pod), pod.Labels[labelInstanceKey]
+	if s.watches(namespace) {
+		var cdapMaster v1alpha1.CDAPMaster
+		err := s.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &cdapMaster)
+		if err == nil && cdapMaster.Spec.MutationFailurePolicy != "" {
+			return cdapMaster.Spec.MutationFailurePolicy
+		}
+		if errors.IsNotFound(err) {
+			var configMaps corev1.ConfigMapList
+			if err := s.Client.List(ctx, &configMaps, client.InNamespace(namespace), client.MatchingLabels{labelInstanceKey: name}); err != nil {
+				logf.FromContext(ctx).Error(err, "Failed to list ConfigMaps of deleted CDAPMaster", "cdapmaster", name)
+			}
+			for _, cm := range configMaps.Items {
+				if policy := cm.Annotations[mutationFailurePolicyAnnotation]; policy != "" {
+					return v1alpha1.MutationFailurePolicy(policy)
+				}
+			}
+		}
+	}
+	if s.FailurePolicy == "" {
+		return v1alpha1.MutationFailurePolicyFail
+	}
+	return s.FailurePolicy
+}
+
+// watches returns whether pods of CDAPMaster objects in the namespace are mutated.
+func (s *PodMutator) watches(namespace string) bool {
+	if len(s.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range s.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 func cdapMasterNamespace(pod *corev1.Pod) string {
 	// First look for the cdap root namespace labels.
 	if ns, ok := pod.Labels[customResourceNamespaceKey]; ok {
@@ -1,20 +1,34 @@
 package webhooks
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"cdap.io/cdap-operator/api/v1alpha1"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 func TestMutatePod(t *testing.T) {
@@ -190,7 +204,7 @@ func TestMutatePod(t *testing.T) {
 			}
 
 			pod := tc.pod.DeepCopy()
-			err = webhook.mutatePod(ctx, pod)
+			_, err = webhook.mutatePod(ctx, pod)
 			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
 				t.Fatalf("mutatePod(%+v) returned unexpected error: want %v, got %v", tc.pod, tc.wantErr, err)
 			}
@@ -219,6 +233,9 @@ func newScheme() (*runtime.Scheme, error) {
 	if err := scheme.AddToScheme(sch); err != nil {
 		return nil, fmt.Errorf("failed to add scheme: %v", err)
 	}
+	if err := apiextensionsv1.AddToScheme(sch); err != nil {
+		return nil, fmt.Errorf("failed to add apiextensions to scheme: %v", err)
+	}
 
 	if err := v1alpha1.AddToScheme(sch); err != nil {
 		return nil, fmt.Errorf("failed to add cdap to scheme: %v", err)
@@ -227,3 +244,510 @@ func newScheme() (*runtime.Scheme, error) {
 	metav1.AddToGroupVersion(sch, v1alpha1.GroupVersion)
 	return sch, nil
 }
+
+func TestApplyPodMutations(t *testing.T) {
+	priorityClass := "high-priority"
+	priority := int32(100)
+	mutations := v1alpha1.PodMutationConfig{
+		InitContainersBefore: []v1.Container{{Name: "init-container-1"}},
+		ContainersAfter:      []v1.Container{{Name: "sidecar-1", Image: "sidecar"}},
+		Tolerations: []v1.Toleration{
+			{
+				Key:   "toleration-key",
+				Value: "toleration-value",
+			},
+		},
+		Env: []v1.EnvVar{
+			{Name: "ENV_1", Value: "mutated"},
+			{Name: "ENV_2", Value: "added"},
+		},
+		Volumes: []v1.Volume{
+			{Name: "volume-1", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: "volume-1", MountPath: "/mnt/volume-1"},
+			{Name: "volume-1", MountPath: "/mnt/existing"},
+		},
+		Labels:            map[string]string{"label-1": "value-1"},
+		Annotations:       map[string]string{"annotation-1": "value-1"},
+		PriorityClassName: &priorityClass,
+		Affinity: &v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+					{
+						Weight: 1,
+						Preference: v1.NodeSelectorTerm{
+							MatchExpressions: []v1.NodeSelectorRequirement{
+								{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"cdap"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		Resources: &v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+		},
+	}
+
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "pod-1",
+			Labels: map[string]string{"cdap.instance": "cdap-instance-1"},
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "original-init-container"}},
+			Containers: []v1.Container{
+				{
+					Name: "container-1",
+					Env:  []v1.EnvVar{{Name: "ENV_1", Value: "original"}},
+					VolumeMounts: []v1.VolumeMount{
+						{Name: "other-volume", MountPath: "/mnt/existing"},
+					},
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+						Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				},
+			},
+			Priority: &priority,
+		},
+	}
+
+	wantPod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod-1",
+			Labels:      map[string]string{"cdap.instance": "cdap-instance-1", "label-1": "value-1"},
+			Annotations: map[string]string{"annotation-1": "value-1"},
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init-container-1"}, {Name: "original-init-container"}},
+			Containers: []v1.Container{
+				{
+					Name: "container-1",
+					Env: []v1.EnvVar{
+						{Name: "ENV_1", Value: "mutated"},
+						{Name: "ENV_2", Value: "added"},
+					},
+					VolumeMounts: []v1.VolumeMount{
+						{Name: "other-volume", MountPath: "/mnt/existing"},
+						{Name: "volume-1", MountPath: "/mnt/volume-1"},
+					},
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+						Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+					},
+				},
+				{Name: "sidecar-1", Image: "sidecar"},
+			},
+			Volumes:           mutations.Volumes,
+			Tolerations:       mutations.Tolerations,
+			Affinity:          mutations.Affinity,
+			PriorityClassName: priorityClass,
+		},
+	}
+
+	testCases := []struct {
+		description string
+		times       int
+	}{
+		{
+			description: "mutations_applied",
+			times:       1,
+		},
+		{
+			description: "mutations_reapplied_without_duplicates",
+			times:       3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got := pod.DeepCopy()
+			for i := 0; i < tc.times; i++ {
+				applyPodMutations(got, &mutations)
+			}
+			if diff := cmp.Diff(wantPod, *got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("applyPodMutations(%+v) returned unexpected diff:(-want +got):\n%s", pod, diff)
+			}
+		})
+	}
+}
+
+func TestHandleFailurePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	newCDAPMaster := func(policy v1alpha1.MutationFailurePolicy) *v1alpha1.CDAPMaster {
+		return &v1alpha1.CDAPMaster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cdap-instance-1",
+				Namespace: "cdap-namespace",
+			},
+			Spec: v1alpha1.CDAPMasterSpec{
+				MutationFailurePolicy: policy,
+			},
+		}
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-1",
+			Namespace: "cdap-namespace",
+			Labels: map[string]string{
+				"cdap.instance": "cdap-instance-1",
+			},
+		},
+	}
+
+	testCases := []struct {
+		description string
+		cdapMaster  *v1alpha1.CDAPMaster
+		// deleted deletes cdapMaster, leaving behind its ConfigMap until it is garbage collected.
+		deleted       bool
+		failurePolicy v1alpha1.MutationFailurePolicy
+		namespaces    []string
+		wantAllowed   bool
+		wantResult    string
+	}{
+		{
+			description: "existing_cdap_master",
+			cdapMaster:  newCDAPMaster(""),
+			wantAllowed: true,
+			wantResult:  mutationResultMutated,
+		},
+		{
+			description: "non_existent_cdap_master_fails_by_default",
+			wantAllowed: false,
+			wantResult:  mutationResultDenied,
+		},
+		{
+			description:   "non_existent_cdap_master_ignored_globally",
+			failurePolicy: v1alpha1.MutationFailurePolicyIgnore,
+			wantAllowed:   true,
+			wantResult:    mutationResultIgnored,
+		},
+		{
+			description:   "deleted_cdap_master_ignored",
+			cdapMaster:    newCDAPMaster(v1alpha1.MutationFailurePolicyIgnore),
+			deleted:       true,
+			failurePolicy: v1alpha1.MutationFailurePolicyFail,
+			wantAllowed:   true,
+			wantResult:    mutationResultIgnored,
+		},
+		{
+			description: "cdap_master_in_watched_namespace",
+			cdapMaster:  newCDAPMaster(""),
+			namespaces:  []string{"other-namespace", "cdap-namespace"},
+			wantAllowed: true,
+			wantResult:  mutationResultMutated,
+		},
+		{
+			description: "cdap_master_in_unwatched_namespace_fails",
+			cdapMaster:  newCDAPMaster(""),
+			namespaces:  []string{"other-namespace"},
+			wantAllowed: false,
+			wantResult:  mutationResultDenied,
+		},
+		{
+			description:   "cdap_master_in_unwatched_namespace_ignored",
+			cdapMaster:    newCDAPMaster(""),
+			failurePolicy: v1alpha1.MutationFailurePolicyIgnore,
+			namespaces:    []string{"other-namespace"},
+			wantAllowed:   true,
+			wantResult:    mutationResultIgnored,
+		},
+		{
+			description:   "deleted_cdap_master_fails",
+			cdapMaster:    newCDAPMaster(v1alpha1.MutationFailurePolicyFail),
+			deleted:       true,
+			failurePolicy: v1alpha1.MutationFailurePolicyIgnore,
+			wantAllowed:   false,
+			wantResult:    mutationResultDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			client, err := newFakeClient()
+			if err != nil {
+				t.Fatalf("Failed to create fake client: %v", err)
+			}
+			decoder, err := admission.NewDecoder(client.Scheme())
+			if err != nil {
+				t.Fatalf("Failed to create decoder: %v", err)
+			}
+			webhook := NewPodMutator(client, tc.failurePolicy)
+			webhook.Namespaces = tc.namespaces
+			if err := webhook.InjectDecoder(decoder); err != nil {
+				t.Fatalf("Failed to inject decoder: %v", err)
+			}
+			raw, err := json.Marshal(pod)
+			if err != nil {
+				t.Fatalf("Failed to marshal pod: %v", err)
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+
+			if tc.cdapMaster != nil {
+				if err := client.Create(ctx, tc.cdapMaster); err != nil {
+					t.Fatalf("Failed to create CDAP CR: %v", err)
+				}
+				if tc.deleted {
+					configMap := &v1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "cdap-cdap-instance-1-cconf",
+							Namespace:   tc.cdapMaster.Namespace,
+							Labels:      map[string]string{"cdap.instance": tc.cdapMaster.Name},
+							Annotations: map[string]string{"cdap.io/mutation-failure-policy": string(tc.cdapMaster.Spec.MutationFailurePolicy)},
+						},
+					}
+					if err := client.Create(ctx, configMap); err != nil {
+						t.Fatalf("Failed to create ConfigMap: %v", err)
+					}
+					if err := client.Delete(ctx, tc.cdapMaster); err != nil {
+						t.Fatalf("Failed to delete CDAP CR: %v", err)
+					}
+				}
+			}
+
+			before := testutil.ToFloat64(podMutationsTotal.WithLabelValues(tc.wantResult))
+			resp := webhook.Handle(ctx, req)
+			if resp.Allowed != tc.wantAllowed {
+				t.Errorf("Handle() returned unexpected allowed: want %v, got %v (%+v)", tc.wantAllowed, resp.Allowed, resp.Result)
+			}
+			if got := testutil.ToFloat64(podMutationsTotal.WithLabelValues(tc.wantResult)) - before; got != 1 {
+				t.Errorf("Handle() recorded unexpected number of %q results: want 1, got %v", tc.wantResult, got)
+			}
+		})
+	}
+}
+
+func TestPreviewPodMutations(t *testing.T) {
+	workerSelector := metav1.LabelSelector{
+		MatchLabels: map[string]string{"cdap.twill.app": "task.worker"},
+	}
+	cdapMaster := &v1alpha1.CDAPMaster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cdap-instance-1",
+			Namespace: "cdap-namespace",
+		},
+		Spec: v1alpha1.CDAPMasterSpec{
+			MutationConfigs: []v1alpha1.MutationConfig{
+				{
+					LabelSelector: workerSelector,
+					PodMutations: v1alpha1.PodMutationConfig{
+						NodeSelector: &map[string]string{"cloud.google.com/gke-nodepool": "worker-pool"},
+					},
+				},
+				{
+					LabelSelector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"cdap.twill.app": "preview.runner"},
+					},
+					PodMutations: v1alpha1.PodMutationConfig{
+						Labels: map[string]string{"preview": "true"},
+					},
+				},
+			},
+		},
+	}
+	newPod := func(app string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pod-1",
+				Namespace: "cdap-namespace",
+				Labels: map[string]string{
+					"cdap.instance":  "cdap-instance-1",
+					"cdap.twill.app": app,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		description  string
+		pod          *v1.Pod
+		wantSelector []metav1.LabelSelector
+		wantPatch    []jsonpatch.JsonPatchOperation
+	}{
+		{
+			description:  "matching_pod",
+			pod:          newPod("task.worker"),
+			wantSelector: []metav1.LabelSelector{workerSelector},
+			wantPatch: []jsonpatch.JsonPatchOperation{
+				{
+					Operation: "add",
+					Path:      "/spec/nodeSelector",
+					Value:     map[string]interface{}{"cloud.google.com/gke-nodepool": "worker-pool"},
+				},
+			},
+		},
+		{
+			description: "non_matching_pod",
+			pod:         newPod("appfabric"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pod := tc.pod.DeepCopy()
+			preview, err := PreviewPodMutations(cdapMaster, pod)
+			if err != nil {
+				t.Fatalf("PreviewPodMutations() returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.pod, pod); diff != "" {
+				t.Errorf("PreviewPodMutations() modified the input pod:(-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantSelector, preview.MatchedSelectors); diff != "" {
+				t.Errorf("PreviewPodMutations() returned unexpected selectors:(-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantPatch, preview.Patch, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("PreviewPodMutations() returned unexpected patch:(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	ctx := context.Background()
+
+	cdapMaster := &v1alpha1.CDAPMaster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cdap-instance-1",
+			Namespace: "cdap-namespace",
+		},
+		Spec: v1alpha1.CDAPMasterSpec{
+			MutationConfigs: []v1alpha1.MutationConfig{
+				{
+					PodMutations: v1alpha1.PodMutationConfig{
+						NodeSelector: &map[string]string{"secure-nodepool": "true"},
+					},
+				},
+			},
+		},
+	}
+	newPod := func(instance string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pod-1",
+				Namespace: "cdap-namespace",
+				Labels:    map[string]string{"cdap.instance": instance},
+			},
+		}
+	}
+
+	testCases := []struct {
+		description string
+		method      string
+		token       string
+		pod         *v1.Pod
+		wantStatus  int
+	}{
+		{
+			description: "allowed_user",
+			method:      http.MethodPost,
+			token:       "allowed",
+			pod:         newPod("cdap-instance-1"),
+			wantStatus:  http.StatusOK,
+		},
+		{
+			description: "missing_token",
+			method:      http.MethodPost,
+			pod:         newPod("cdap-instance-1"),
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			description: "invalid_token",
+			method:      http.MethodPost,
+			token:       "invalid",
+			pod:         newPod("cdap-instance-1"),
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			description: "forbidden_user",
+			method:      http.MethodPost,
+			token:       "forbidden",
+			pod:         newPod("cdap-instance-1"),
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			description: "non_existent_cdap_master",
+			method:      http.MethodPost,
+			token:       "allowed",
+			pod:         newPod("cdap-instance-2"),
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			description: "get_not_allowed",
+			method:      http.MethodGet,
+			token:       "allowed",
+			pod:         newPod("cdap-instance-1"),
+			wantStatus:  http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			client, err := newFakeClient()
+			if err != nil {
+				t.Fatalf("Failed to create fake client: %v", err)
+			}
+			if err := client.Create(ctx, cdapMaster.DeepCopy()); err != nil {
+				t.Fatalf("Failed to create CDAP CR: %v", err)
+			}
+			// Tokens authenticate as the user of the same name, and only the user "allowed" is authorized.
+			clientset := kubefake.NewSimpleClientset()
+			clientset.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+				review.Status.Authenticated = review.Spec.Token != "invalid"
+				review.Status.User.Username = review.Spec.Token
+				return true, review, nil
+			})
+			clientset.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				attrs := review.Spec.ResourceAttributes
+				review.Status.Allowed = review.Spec.User == "allowed" && attrs.Verb == "get" && attrs.Resource == "cdapmasters" &&
+					attrs.Namespace == "cdap-namespace"
+				return true, review, nil
+			})
+			handler := NewPreviewHandler(NewPodMutator(client, ""), clientset)
+
+			raw, err := json.Marshal(tc.pod)
+			if err != nil {
+				t.Fatalf("Failed to marshal pod: %v", err)
+			}
+			req := httptest.NewRequest(tc.method, "/preview-v1-pod", bytes.NewReader(raw))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("ServeHTTP() returned unexpected status: want %d, got %d (%s)", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var got MutationPreview
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode preview: %v", err)
+			}
+			want, err := PreviewPodMutations(cdapMaster, tc.pod)
+			if err != nil {
+				t.Fatalf("PreviewPodMutations() returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(want.Pod, got.Pod); diff != "" {
+				t.Errorf("ServeHTTP() returned unexpected pod:(-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(want.MatchedSelectors, got.MatchedSelectors); diff != "" {
+				t.Errorf("ServeHTTP() returned unexpected selectors:(-want +got):\n%s", diff)
+			}
+			if len(got.Patch) != len(want.Patch) {
+				t.Errorf("ServeHTTP() returned unexpected patch: want %+v, got %+v", want.Patch, got.Patch)
+			}
+		})
+	}
+}
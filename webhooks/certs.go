@@ -0,0 +1,444 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;update
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;update
+
+const (
+	// caKeyKey is the key of the CA private key in the certificate Secret. The other keys follow kubernetes.io/tls.
+	caKeyKey = "ca.key"
+
+	defaultCAValidity    = 10 * 365 * 24 * time.Hour
+	defaultCertValidity  = 90 * 24 * time.Hour
+	defaultRenewBefore   = 15 * 24 * time.Hour
+	defaultCheckInterval = time.Hour
+)
+
+// CertRotator provisions the serving certificate of the webhook server without cert-manager. It generates a
+// self-signed CA and a serving certificate signed by it, stores them in a Secret shared by all the operator
+// replicas, writes them to the certificate directory of the webhook server and injects the CA bundle into the
+// MutatingWebhookConfiguration and the conversion webhook of the CRD. Certificates are renewed before they expire. When the CA is renewed, the
+// previous CA stays in the bundle until it expires, so that replicas still serving the old certificate keep working.
+type CertRotator struct {
+	// Client writes the Secret, the MutatingWebhookConfiguration and the CRD.
+	Client client.Client
+	// Reader reads the Secret, the MutatingWebhookConfiguration and the CRD. It should not be cached, since the
+	// certificates are provisioned before the manager starts.
+	Reader client.Reader
+	// SecretKey is the namespaced name of the Secret holding the certificates.
+	SecretKey client.ObjectKey
+	// WebhookConfigName is the name of the MutatingWebhookConfiguration to inject the CA bundle into.
+	WebhookConfigName string
+	// CRDName is the name of the CustomResourceDefinition whose conversion webhook trusts the CA bundle.
+	// The CA bundle is only injected when the CRD uses the Webhook conversion strategy.
+	CRDName string
+	// DNSNames are the names of the webhook service the serving certificate is valid for.
+	DNSNames []string
+	// CertDir is the directory the webhook server reads tls.crt and tls.key from.
+	CertDir string
+
+	CAValidity    time.Duration
+	CertValidity  time.Duration
+	RenewBefore   time.Duration
+	CheckInterval time.Duration
+
+	now func() time.Time
+}
+
+// certLog is the logger of the certificate rotation, which runs outside of admission requests.
+var certLog = webhookLog.WithName("cert-rotator")
+
+// NewCertRotator returns a CertRotator for the webhook service with the given name and namespace.
+func NewCertRotator(c client.Client, reader client.Reader, secretKey client.ObjectKey, webhookConfigName, serviceName, certDir string) *CertRotator {
+	return &CertRotator{
+		Client:            c,
+		Reader:            reader,
+		SecretKey:         secretKey,
+		WebhookConfigName: webhookConfigName,
+		DNSNames: []string{
+			serviceName,
+			fmt.Sprintf("%s.%s.svc", serviceName, secretKey.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, secretKey.Namespace),
+		},
+		CertDir:       certDir,
+		CAValidity:    defaultCAValidity,
+		CertValidity:  defaultCertValidity,
+		RenewBefore:   defaultRenewBefore,
+		CheckInterval: defaultCheckInterval,
+		now:           time.Now,
+	}
+}
+
+// Start periodically renews the certificates. It implements manager.Runnable.
+func (r *CertRotator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.EnsureCerts(ctx); err != nil {
+				certLog.Error(err, "Failed to rotate webhook certificates", "secret", r.SecretKey)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false, since every replica serves the webhook and needs the certificate on disk.
+func (r *CertRotator) NeedLeaderElection() bool {
+	return false
+}
+
+// EnsureCerts makes sure that the Secret holds valid certificates, that the webhook server uses them and that
+// the MutatingWebhookConfiguration and the CRD conversion webhook trust them.
+func (r *CertRotator) EnsureCerts(ctx context.Context) error {
+	var data map[string][]byte
+	// Replicas starting or renewing the certificates at the same time race to write the Secret. The replicas
+	// losing the race read it again and adopt the certificates of the winner.
+	err := retry.OnError(retry.DefaultRetry, lostSecretRace, func() error {
+		var err error
+		data, err = r.syncSecret(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeCertsIfChanged(r.CertDir, map[string][]byte{
+		corev1.TLSCertKey:       data[corev1.TLSCertKey],
+		corev1.TLSPrivateKeyKey: data[corev1.TLSPrivateKeyKey],
+	}); err != nil {
+		return fmt.Errorf("failed to write certificates to %s: %w", r.CertDir, err)
+	}
+	// The other replicas inject the same CA bundle, so a conflict only needs the injection to be checked again.
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return r.injectCABundle(ctx, data[corev1.ServiceAccountRootCAKey])
+	}); err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return r.injectConversionCABundle(ctx, data[corev1.ServiceAccountRootCAKey])
+	})
+}
+
+// syncSecret creates or renews the certificates in the Secret if needed and returns its data.
+func (r *CertRotator) syncSecret(ctx context.Context) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	err := r.Reader.Get(ctx, r.SecretKey, secret)
+	create := errors.IsNotFound(err)
+	if err != nil && !create {
+		return nil, fmt.Errorf("failed to get secret %s: %w", r.SecretKey, err)
+	}
+
+	data, changed, err := r.renewCerts(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case create:
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: r.SecretKey.Name, Namespace: r.SecretKey.Namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to create secret %s: %w", r.SecretKey, err)
+		}
+		certLog.Info("Created webhook certificates", "secret", r.SecretKey)
+	case changed:
+		secret.Data = data
+		if err := r.Client.Update(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to update secret %s: %w", r.SecretKey, err)
+		}
+		certLog.Info("Renewed webhook certificates", "secret", r.SecretKey)
+	}
+	return data, nil
+}
+
+// lostSecretRace returns whether the error is caused by another replica writing the Secret concurrently.
+func lostSecretRace(err error) bool {
+	return errors.IsAlreadyExists(err) || errors.IsConflict(err)
+}
+
+// renewCerts returns the certificate data with the CA and the serving certificate renewed if they are missing,
+// invalid or about to expire, and whether anything was renewed.
+func (r *CertRotator) renewCerts(current map[string][]byte) (map[string][]byte, bool, error) {
+	now := r.now()
+	data := make(map[string][]byte)
+	for k, v := range current {
+		data[k] = v
+	}
+
+	caBundle := parseCerts(data[corev1.ServiceAccountRootCAKey])
+	caKey, _ := parsePrivateKey(data[caKeyKey])
+	renewCA := len(caBundle) == 0 || caKey == nil || !now.Add(r.RenewBefore).Before(caBundle[0].NotAfter)
+	if renewCA {
+		caCert, caCertPEM, caKeyPEM, err := newCertificate(&x509.Certificate{
+			Subject:               pkix.Name{CommonName: "cdap-webhook-ca"},
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+		}, nil, nil, now, r.CAValidity)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create CA certificate: %w", err)
+		}
+		bundle := caCertPEM
+		// Keep trusting the previous CA until it expires.
+		for _, cert := range caBundle {
+			if now.Before(cert.NotAfter) {
+				bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+			}
+		}
+		caBundle = []*x509.Certificate{caCert}
+		caKey, _ = parsePrivateKey(caKeyPEM)
+		data[corev1.ServiceAccountRootCAKey] = bundle
+		data[caKeyKey] = caKeyPEM
+	}
+
+	if !renewCA && r.certValid(data, caBundle[0], now) {
+		return data, false, nil
+	}
+	_, certPEM, keyPEM, err := newCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: r.DNSNames[0]},
+		DNSNames:    r.DNSNames,
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caBundle[0], caKey, now, r.CertValidity)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create serving certificate: %w", err)
+	}
+	data[corev1.TLSCertKey] = certPEM
+	data[corev1.TLSPrivateKeyKey] = keyPEM
+	return data, true, nil
+}
+
+// certValid returns whether the serving certificate is signed by the CA, matches its private key, is valid
+// for all the DNS names and doesn't need to be renewed yet.
+func (r *CertRotator) certValid(data map[string][]byte, ca *x509.Certificate, now time.Time) bool {
+	certs := parseCerts(data[corev1.TLSCertKey])
+	if len(certs) == 0 {
+		return false
+	}
+	if key, err := parsePrivateKey(data[corev1.TLSPrivateKeyKey]); err != nil || !key.Public().(*ecdsa.PublicKey).Equal(certs[0].PublicKey) {
+		return false
+	}
+	if !now.Add(r.RenewBefore).Before(certs[0].NotAfter) || certs[0].CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	for _, name := range r.DNSNames {
+		if certs[0].VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// injectCABundle sets the CA bundle of all the webhooks in the MutatingWebhookConfiguration. A missing
+// configuration is not an error, since it may be created after the operator starts.
+func (r *CertRotator) injectCABundle(ctx context.Context, caBundle []byte) error {
+	config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := r.Reader.Get(ctx, client.ObjectKey{Name: r.WebhookConfigName}, config); err != nil {
+		if errors.IsNotFound(err) {
+			certLog.Info("MutatingWebhookConfiguration not found, skipping CA injection", "webhookConfig", r.WebhookConfigName)
+			return nil
+		}
+		return fmt.Errorf("failed to get MutatingWebhookConfiguration %q: %w", r.WebhookConfigName, err)
+	}
+	changed := false
+	for i := range config.Webhooks {
+		if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.Client.Update(ctx, config); err != nil {
+		return fmt.Errorf("failed to update MutatingWebhookConfiguration %q: %w", r.WebhookConfigName, err)
+	}
+	certLog.Info("Injected CA bundle into MutatingWebhookConfiguration", "webhookConfig", r.WebhookConfigName)
+	return nil
+}
+
+// injectConversionCABundle sets the CA bundle of the conversion webhook of the CRD. CRDs that are missing or
+// that don't use the Webhook conversion strategy are skipped.
+func (r *CertRotator) injectConversionCABundle(ctx context.Context, caBundle []byte) error {
+	if r.CRDName == "" {
+		return nil
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := r.Reader.Get(ctx, client.ObjectKey{Name: r.CRDName}, crd); err != nil {
+		if errors.IsNotFound(err) {
+			certLog.Info("CustomResourceDefinition not found, skipping CA injection", "crd", r.CRDName)
+			return nil
+		}
+		return fmt.Errorf("failed to get CustomResourceDefinition %q: %w", r.CRDName, err)
+	}
+	conversion := crd.Spec.Conversion
+	if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter || conversion.Webhook == nil ||
+		conversion.Webhook.ClientConfig == nil {
+		return nil
+	}
+	if bytes.Equal(conversion.Webhook.ClientConfig.CABundle, caBundle) {
+		return nil
+	}
+	conversion.Webhook.ClientConfig.CABundle = caBundle
+	if err := r.Client.Update(ctx, crd); err != nil {
+		return fmt.Errorf("failed to update CustomResourceDefinition %q: %w", r.CRDName, err)
+	}
+	certLog.Info("Injected CA bundle into CustomResourceDefinition", "crd", r.CRDName)
+	return nil
+}
+
+// newCertificate creates a certificate from the template with a new ECDSA P-256 key. It is self-signed if
+// parent is nil. It returns the certificate along with the PEM encoded certificate and private key.
+func newCertificate(template, parent *x509.Certificate, parentKey crypto.Signer, now time.Time, validity time.Duration) (*x509.Certificate, []byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	template.SerialNumber = serial
+	// Tolerate clock skew between the operator and the API server.
+	template.NotBefore = now.Add(-time.Hour)
+	template.NotAfter = now.Add(validity)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		nil
+}
+
+// parseCerts returns the certificates in the PEM data, skipping the invalid ones.
+func parseCerts(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+func parsePrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+const (
+	// certDataDir is the symlink to the directory holding the current certificate files. The files in the
+	// certificate directory are symlinks into it, so that swapping it replaces all of them at once.
+	certDataDir = "..data"
+	// certDataDirPrefix is the prefix of the directories the certificate files are written to.
+	certDataDirPrefix = "..certs-"
+)
+
+// writeCertsIfChanged writes the files into the directory, unless they already have the content, so that the
+// webhook server only reloads the certificate when it changes. The files are written into a new directory that
+// is swapped in atomically, the same way the kubelet updates Secret volumes, so that the webhook server never
+// reads a certificate along with the private key of another one.
+func writeCertsIfChanged(dir string, files map[string][]byte) error {
+	changed := false
+	for name, content := range files {
+		if current, err := os.ReadFile(filepath.Join(dir, name)); err != nil || !bytes.Equal(current, content) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dataDir, err := os.MkdirTemp(dir, certDataDirPrefix)
+	if err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dataDir, name), content, 0600); err != nil {
+			os.RemoveAll(dataDir)
+			return err
+		}
+	}
+
+	oldDataDir, _ := os.Readlink(filepath.Join(dir, certDataDir))
+	if err := replaceWithSymlink(filepath.Join(dir, certDataDir), filepath.Base(dataDir)); err != nil {
+		os.RemoveAll(dataDir)
+		return err
+	}
+	for name := range files {
+		target := filepath.Join(certDataDir, name)
+		if current, err := os.Readlink(filepath.Join(dir, name)); err == nil && current == target {
+			continue
+		}
+		if err := replaceWithSymlink(filepath.Join(dir, name), target); err != nil {
+			return err
+		}
+	}
+	if oldDataDir != "" && oldDataDir != filepath.Base(dataDir) {
+		return os.RemoveAll(filepath.Join(dir, oldDataDir))
+	}
+	return nil
+}
+
+// replaceWithSymlink atomically replaces the path with a symlink to the target.
+func replaceWithSymlink(path, target string) error {
+	tmp := path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,233 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCertRotator(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	secretKey := client.ObjectKey{Namespace: "cdap-operator", Name: "cdap-webhook-server-cert"}
+
+	testCases := []struct {
+		description string
+		// elapsed is the time between the initial provisioning and the check.
+		elapsed       time.Duration
+		wantCARenewed bool
+		wantRenewed   bool
+		// wantCAs is the number of CAs in the bundle after the check.
+		wantCAs int
+	}{
+		{
+			description: "valid_certificates_kept",
+			elapsed:     24 * time.Hour,
+			wantCAs:     1,
+		},
+		{
+			description: "serving_certificate_renewed_before_expiry",
+			elapsed:     defaultCertValidity - defaultRenewBefore + time.Hour,
+			wantRenewed: true,
+			wantCAs:     1,
+		},
+		{
+			description:   "ca_renewed_before_expiry",
+			elapsed:       defaultCAValidity - defaultRenewBefore + time.Hour,
+			wantCARenewed: true,
+			wantRenewed:   true,
+			wantCAs:       2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			client, err := newFakeClient()
+			if err != nil {
+				t.Fatalf("Failed to create fake client: %v", err)
+			}
+			config := &admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "cdap-webhook"},
+				Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "cdap-webhook-server.cdap-operator.svc.cluster.local"}},
+			}
+			if err := client.Create(ctx, config); err != nil {
+				t.Fatalf("Failed to create MutatingWebhookConfiguration: %v", err)
+			}
+			crd := &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "cdapmasters.cdap.cdap.io"},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Conversion: &apiextensionsv1.CustomResourceConversion{
+						Strategy: apiextensionsv1.WebhookConverter,
+						Webhook: &apiextensionsv1.WebhookConversion{
+							ClientConfig:             &apiextensionsv1.WebhookClientConfig{},
+							ConversionReviewVersions: []string{"v1"},
+						},
+					},
+				},
+			}
+			if err := client.Create(ctx, crd); err != nil {
+				t.Fatalf("Failed to create CustomResourceDefinition: %v", err)
+			}
+			certDir := t.TempDir()
+			rotator := NewCertRotator(client, client, secretKey, "cdap-webhook", "cdap-webhook-server", certDir)
+			rotator.CRDName = crd.Name
+			now := start
+			rotator.now = func() time.Time { return now }
+
+			if err := rotator.EnsureCerts(ctx); err != nil {
+				t.Fatalf("EnsureCerts() returned unexpected error: %v", err)
+			}
+			initial := &v1.Secret{}
+			if err := client.Get(ctx, secretKey, initial); err != nil {
+				t.Fatalf("EnsureCerts() didn't create the secret: %v", err)
+			}
+
+			now = start.Add(tc.elapsed)
+			if err := rotator.EnsureCerts(ctx); err != nil {
+				t.Fatalf("EnsureCerts() returned unexpected error: %v", err)
+			}
+			secret := &v1.Secret{}
+			if err := client.Get(ctx, secretKey, secret); err != nil {
+				t.Fatalf("Failed to get secret: %v", err)
+			}
+			caBundle := secret.Data[v1.ServiceAccountRootCAKey]
+			if got := string(caBundle) != string(initial.Data[v1.ServiceAccountRootCAKey]); got != tc.wantCARenewed {
+				t.Errorf("EnsureCerts() renewed CA: want %v, got %v", tc.wantCARenewed, got)
+			}
+			if got := string(secret.Data[v1.TLSCertKey]) != string(initial.Data[v1.TLSCertKey]); got != tc.wantRenewed {
+				t.Errorf("EnsureCerts() renewed serving certificate: want %v, got %v", tc.wantRenewed, got)
+			}
+			cas := parseCerts(caBundle)
+			if len(cas) != tc.wantCAs {
+				t.Errorf("EnsureCerts() returned unexpected number of CAs in bundle: want %d, got %d", tc.wantCAs, len(cas))
+			}
+
+			// The certificate served from disk must be trusted by the CA bundle injected into the webhook configuration.
+			if err := client.Get(ctx, types.NamespacedName{Name: config.Name}, config); err != nil {
+				t.Fatalf("Failed to get MutatingWebhookConfiguration: %v", err)
+			}
+			if got := string(config.Webhooks[0].ClientConfig.CABundle); got != string(caBundle) {
+				t.Errorf("EnsureCerts() injected unexpected CA bundle: want %q, got %q", caBundle, got)
+			}
+			if err := client.Get(ctx, types.NamespacedName{Name: crd.Name}, crd); err != nil {
+				t.Fatalf("Failed to get CustomResourceDefinition: %v", err)
+			}
+			if got := string(crd.Spec.Conversion.Webhook.ClientConfig.CABundle); got != string(caBundle) {
+				t.Errorf("EnsureCerts() injected unexpected CA bundle into CRD: want %q, got %q", caBundle, got)
+			}
+			certPEM, err := os.ReadFile(filepath.Join(certDir, v1.TLSCertKey))
+			if err != nil {
+				t.Fatalf("EnsureCerts() didn't write the certificate: %v", err)
+			}
+			if string(certPEM) != string(secret.Data[v1.TLSCertKey]) {
+				t.Errorf("EnsureCerts() wrote a certificate different from the secret")
+			}
+			if _, err := os.Stat(filepath.Join(certDir, v1.TLSPrivateKeyKey)); err != nil {
+				t.Errorf("EnsureCerts() didn't write the private key: %v", err)
+			}
+			if dataDirs, _ := filepath.Glob(filepath.Join(certDir, certDataDirPrefix+"*")); len(dataDirs) != 1 {
+				t.Errorf("EnsureCerts() left unexpected certificate directories: %v", dataDirs)
+			}
+			roots := x509.NewCertPool()
+			roots.AppendCertsFromPEM(caBundle)
+			certs := parseCerts(certPEM)
+			for _, name := range rotator.DNSNames {
+				if _, err := certs[0].Verify(x509.VerifyOptions{DNSName: name, Roots: roots, CurrentTime: now}); err != nil {
+					t.Errorf("Certificate not valid for %q: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+// racingReader runs race after the first Get of the object returns, like another replica writing it after it
+// was read.
+type racingReader struct {
+	client.Reader
+	race func()
+}
+
+func (r *racingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := r.Reader.Get(ctx, key, obj, opts...)
+	if _, ok := obj.(*v1.Secret); ok && r.race != nil {
+		race := r.race
+		r.race = nil
+		race()
+	}
+	return err
+}
+
+func TestCertRotatorAdoptsConcurrentCerts(t *testing.T) {
+	ctx := context.Background()
+	secretKey := client.ObjectKey{Namespace: "cdap-operator", Name: "cdap-webhook-server-cert"}
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		description string
+		// elapsed is the time between the initial provisioning and the concurrent checks. Zero means that
+		// both replicas create the secret.
+		elapsed time.Duration
+	}{
+		{
+			description: "concurrent_create",
+		},
+		{
+			description: "concurrent_renewal",
+			elapsed:     defaultCertValidity - defaultRenewBefore + time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			c, err := newFakeClient()
+			if err != nil {
+				t.Fatalf("Failed to create fake client: %v", err)
+			}
+			winner := NewCertRotator(c, c, secretKey, "cdap-webhook", "cdap-webhook-server", t.TempDir())
+			winner.now = func() time.Time { return now }
+			reader := &racingReader{Reader: c}
+			loser := NewCertRotator(c, reader, secretKey, "cdap-webhook", "cdap-webhook-server", t.TempDir())
+			loser.now = func() time.Time { return now.Add(tc.elapsed) }
+			if tc.elapsed > 0 {
+				if err := winner.EnsureCerts(ctx); err != nil {
+					t.Fatalf("EnsureCerts() returned unexpected error: %v", err)
+				}
+				winner.now = loser.now
+			}
+			reader.race = func() {
+				if err := winner.EnsureCerts(ctx); err != nil {
+					t.Fatalf("EnsureCerts() returned unexpected error: %v", err)
+				}
+			}
+
+			if err := loser.EnsureCerts(ctx); err != nil {
+				t.Fatalf("EnsureCerts() returned unexpected error after losing the race: %v", err)
+			}
+			secret := &v1.Secret{}
+			if err := c.Get(ctx, secretKey, secret); err != nil {
+				t.Fatalf("Failed to get secret: %v", err)
+			}
+			for _, rotator := range []*CertRotator{winner, loser} {
+				for _, key := range []string{v1.TLSCertKey, v1.TLSPrivateKeyKey} {
+					got, err := os.ReadFile(filepath.Join(rotator.CertDir, key))
+					if err != nil {
+						t.Fatalf("EnsureCerts() didn't write %s: %v", key, err)
+					}
+					if string(got) != string(secret.Data[key]) {
+						t.Errorf("EnsureCerts() wrote %s different from the secret", key)
+					}
+				}
+			}
+		})
+	}
+}
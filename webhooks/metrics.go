@@ -0,0 +1,37 @@
+package webhooks
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// Values of the result label of the pod mutation metrics.
+	mutationResultMutated = "mutated"
+	mutationResultIgnored = "ignored"
+	mutationResultDenied  = "denied"
+	mutationResultErrored = "errored"
+)
+
+var (
+	podMutationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cdap_webhook_pod_mutations_total",
+			Help: "Total number of pod admission requests handled by the CDAP webhook, partitioned by result.",
+		},
+		[]string{"result"},
+	)
+	podMutationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cdap_webhook_pod_mutation_duration_seconds",
+			Help:    "Latency of pod admission requests handled by the CDAP webhook, partitioned by result.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	// Metrics are served on the metrics endpoint of the controller manager.
+	metrics.Registry.MustRegister(podMutationsTotal, podMutationDuration)
+}
@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	v1alpha1 "cdap.io/cdap-operator/api/v1alpha1"
+	"gomodules.xyz/jsonpatch/v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// maxPreviewRequestBytes limits the size of the pod manifest accepted by the preview endpoint.
+const maxPreviewRequestBytes = 3 * 1024 * 1024
+
+// MutationPreview is the result of admitting a pod through the webhook without creating it.
+type MutationPreview struct {
+	// Pod is the pod after mutations.
+	Pod *corev1.Pod `json:"pod"`
+	// MatchedSelectors are the label selectors of the mutation configs that matched the pod, in the order applied.
+	MatchedSelectors []metav1.LabelSelector `json:"matchedSelectors"`
+	// Patch is the JSON patch the webhook returns to the API server for the pod.
+	Patch []jsonpatch.JsonPatchOperation `json:"patch"`
+}
+
+// PreviewPodMutations returns the pod as the webhook would mutate it with the mutation configs of the CDAPMaster,
+// without contacting the API server. It is meant for testing mutation configs, e.g. in CI.
+func PreviewPodMutations(cdapMaster *v1alpha1.CDAPMaster, pod *corev1.Pod) (*MutationPreview, error) {
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	mutated := pod.DeepCopy()
+	return newMutationPreview(original, mutated, applyMutationConfigs(webhookLog, cdapMaster, mutated))
+}
+
+// preview mutates the pod manifest like Handle does, without applying the failure policy or recording metrics.
+func (s *PodMutator) preview(ctx context.Context, raw []byte) (*MutationPreview, error) {
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(raw, pod); err != nil {
+		return nil, err
+	}
+	matched, err := s.mutatePod(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	return newMutationPreview(raw, pod, matched)
+}
+
+func newMutationPreview(original []byte, pod *corev1.Pod, matched []metav1.LabelSelector) (*MutationPreview, error) {
+	resp := patchResponse(original, pod)
+	if !resp.Allowed {
+		return nil, fmt.Errorf("failed to create patch: %s", resp.Result.Message)
+	}
+	return &MutationPreview{
+		Pod:              pod,
+		MatchedSelectors: matched,
+		Patch:            resp.Patches,
+	}, nil
+}
+
+// PreviewHandler serves dry-run previews of pod mutations. It accepts a pod manifest in a POST request and
+// responds with a MutationPreview. Callers authenticate with a bearer token and must be allowed to get the
+// CDAPMaster the pod belongs to.
+type PreviewHandler struct {
+	Mutator *PodMutator
+	// Clientset creates TokenReviews and SubjectAccessReviews to authenticate and authorize callers.
+	Clientset kubernetes.Interface
+}
+
+func NewPreviewHandler(mutator *PodMutator, clientset kubernetes.Interface) *PreviewHandler {
+	return &PreviewHandler{
+		Mutator:   mutator,
+		Clientset: clientset,
+	}
+}
+
+func (h *PreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := logf.IntoContext(r.Context(), webhookLog.WithName("preview"))
+	user, err := h.authenticate(ctx, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r.Body, maxPreviewRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(raw, pod); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode pod: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := h.authorize(ctx, user, pod); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	preview, err := h.Mutator.preview(ctx, raw)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to write mutation preview")
+	}
+}
+
+// authenticate returns the user identified by the bearer token of the request.
+func (h *PreviewHandler) authenticate(ctx context.Context, r *http.Request) (*authenticationv1.UserInfo, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return nil, fmt.Errorf("missing bearer token")
+	}
+	review, err := h.Clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, fmt.Errorf("invalid bearer token: %s", review.Status.Error)
+	}
+	return &review.Status.User, nil
+}
+
+// authorize checks that the user is allowed to get the CDAPMaster the pod belongs to, since the preview
+// reveals its mutation configs.
+func (h *PreviewHandler) authorize(ctx context.Context, user *authenticationv1.UserInfo, pod *corev1.Pod) error {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review, err := h.Clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: cdapMasterNamespace(pod),
+				Verb:      "get",
+				Group:     v1alpha1.GroupVersion.Group,
+				Resource:  "cdapmasters",
+				Name:      pod.Labels[labelInstanceKey],
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to review access: %v", err)
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("user %q is not allowed to get CDAPMaster %q in namespace %q", user.Username, pod.Labels[labelInstanceKey], cdapMasterNamespace(pod))
+	}
+	return nil
+}